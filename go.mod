@@ -3,7 +3,22 @@ module github.com/BrandonIrizarry/gator
 go 1.23.5
 
 require (
+	github.com/andybalholm/cascadia v1.3.3
+	github.com/chromedp/chromedp v0.13.7
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 	github.com/michaljemala/pqerror v0.3.0
+	golang.org/x/crypto v0.36.0
+	golang.org/x/net v0.38.0
+	golang.org/x/term v0.30.0
+)
+
+require (
+	github.com/chromedp/cdproto v0.0.0-20250403032234-65de8f5d025b // indirect
+	github.com/chromedp/sysutil v1.1.0 // indirect
+	github.com/go-json-experiment/json v0.0.0-20250211171154-1ae217ad3535 // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/gobwas/ws v1.4.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
 )
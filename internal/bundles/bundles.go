@@ -0,0 +1,74 @@
+package bundles
+
+import (
+	"embed"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/opml"
+	"path"
+	"sort"
+	"strings"
+)
+
+/** The curated bundles, one OPML file apiece, named after the file. */
+//go:embed *.opml
+var files embed.FS
+
+/** A curated set of feeds, for getting a new user started. */
+type Bundle struct {
+	Name  string
+	Title string
+	Feeds []opml.Outline
+}
+
+/** Return every bundle, ordered by name. */
+func List() ([]Bundle, error) {
+	entries, err := files.ReadDir(".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make([]Bundle, 0, len(entries))
+
+	for _, entry := range entries {
+		bundle, err := Get(strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+
+		if err != nil {
+			return nil, err
+		}
+
+		bundles = append(bundles, bundle)
+	}
+
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].Name < bundles[j].Name
+	})
+
+	return bundles, nil
+}
+
+/*
+  - Return the bundle with the given name, or an error if there's no
+    such bundle.
+*/
+func Get(name string) (Bundle, error) {
+	file, err := files.Open(name + ".opml")
+
+	if err != nil {
+		return Bundle{}, fmt.Errorf("No bundle named %q", name)
+	}
+
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+
+	if err != nil {
+		return Bundle{}, fmt.Errorf("Malformed bundle %q: %w", name, err)
+	}
+
+	return Bundle{
+		Name:  name,
+		Title: doc.Head.Title,
+		Feeds: doc.Feeds(),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package canonical
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+/*
+  - Query parameters which only track where a click came from, and so
+    don't change which article a link leads to.
+*/
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"ref_src": true,
+}
+
+/*
+  - Reduce a link to a form shared by the other links to the same
+    article: the scheme is taken to be HTTPS, the host is lowercased
+    and loses any "www.", the fragment and trailing slash go, and so
+    do tracking parameters (such as utm_source), with the rest put in
+    order. Links which aren't HTTP(S) are returned as they are.
+*/
+func URL(link string) string {
+	link = strings.TrimSpace(link)
+	parsed, err := url.Parse(link)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return link
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := parsed.Query()
+
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") || trackingParams[strings.ToLower(name)] {
+			query.Del(name)
+		}
+	}
+
+	normalized := url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     strings.TrimSuffix(parsed.Path, "/"),
+		RawPath:  strings.TrimSuffix(parsed.RawPath, "/"),
+		RawQuery: encodeSorted(query),
+	}
+
+	return normalized.String()
+}
+
+/** Encode query parameters in order of name, keeping each one's values in order. */
+func encodeSorted(query url.Values) string {
+	names := make([]string, 0, len(query))
+
+	for name := range query {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+/*
+  - The key by which copies of the same article in different feeds
+    are recognized. An ID which is unique by design (an Atom "tag:"
+    or "urn:" ID, say) survives syndication intact, so it's used when
+    there is one; otherwise, it's the item's link, as reduced by
+    'URL'. (Other IDs, such as "1234", are only unique within their
+    own feed.)
+*/
+func Key(link string, guid string) string {
+	guid = strings.TrimSpace(guid)
+
+	if strings.HasPrefix(guid, "tag:") || strings.HasPrefix(guid, "urn:") {
+		return guid
+	}
+
+	return URL(link)
+}
@@ -0,0 +1,88 @@
+package canonical
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+/** How much of a page is searched for its canonical link. */
+const maxPageLength = 1 << 20
+
+/** A <link> tag, and the attributes which matter within one. */
+var (
+	linkPattern      = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)\s(rel|href)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+/*
+  - Fetch the page at the given URL, and return the URL it names as
+    its canonical one (with <link rel="canonical">), which is where
+    the article really lives when the page is a syndicated copy or a
+    redirect through a tracker. A page naming none yields the URL it
+    was finally fetched from, after any redirects.
+*/
+func Resolve(ctx context.Context, client *http.Client, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPageLength))
+
+	if err != nil {
+		return "", err
+	}
+
+	finalURL := resp.Request.URL
+
+	for _, tag := range linkPattern.FindAll(page, -1) {
+		attrs := make(map[string]string)
+
+		for _, match := range attributePattern.FindAllSubmatch(tag, -1) {
+			value := strings.Trim(string(match[2]), `"'`)
+			attrs[strings.ToLower(string(match[1]))] = html.UnescapeString(value)
+		}
+
+		rels := strings.Fields(strings.ToLower(attrs["rel"]))
+
+		if !slices.Contains(rels, "canonical") || strings.TrimSpace(attrs["href"]) == "" {
+			continue
+		}
+
+		href, err := url.Parse(strings.TrimSpace(attrs["href"]))
+
+		if err != nil {
+			continue
+		}
+
+		resolved := finalURL.ResolveReference(href)
+
+		if resolved.Scheme == "http" || resolved.Scheme == "https" {
+			return resolved.String(), nil
+		}
+	}
+
+	return finalURL.String(), nil
+}
@@ -0,0 +1,82 @@
+package categorize
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/** The most tags suggested for any one feed. */
+const maxSuggestions = 3
+
+/*
+  - How many keyword hits a tag needs before it's suggested, so that a
+    single passing mention doesn't count.
+*/
+const minHits = 2
+
+/** Words which suggest each tag. */
+var keywords = map[string][]string{
+	"ai":          {"ai", "llm", "llms", "gpt", "neural", "ml", "chatbot", "transformer"},
+	"business":    {"business", "startup", "startups", "market", "markets", "economy", "finance", "investing"},
+	"design":      {"design", "ux", "ui", "typography", "css", "figma"},
+	"gaming":      {"game", "games", "gaming", "nintendo", "playstation", "xbox", "steam"},
+	"news":        {"news", "politics", "election", "world", "breaking"},
+	"podcast":     {"podcast", "episode", "episodes", "listen"},
+	"programming": {"programming", "code", "coding", "developer", "developers", "software", "golang", "go", "rust", "python", "javascript", "typescript", "compiler", "api"},
+	"science":     {"science", "research", "physics", "biology", "chemistry", "space", "nasa", "climate"},
+	"security":    {"security", "vulnerability", "vulnerabilities", "cve", "exploit", "malware", "breach", "encryption", "infosec"},
+	"video":       {"video", "videos", "youtube", "watch"},
+}
+
+/*
+  - Suggest tags for a feed from its text (for example, the channel's
+    title and description, and its recent items' titles), by counting
+    occurrences of each tag's keywords. The best-scoring tags come
+    first.
+*/
+func Suggest(texts ...string) []string {
+	tagsByKeyword := make(map[string][]string)
+
+	for tag, words := range keywords {
+		for _, word := range words {
+			tagsByKeyword[word] = append(tagsByKeyword[word], tag)
+		}
+	}
+
+	hits := make(map[string]int)
+
+	for _, text := range texts {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		for _, word := range words {
+			for _, tag := range tagsByKeyword[word] {
+				hits[tag]++
+			}
+		}
+	}
+
+	tags := make([]string, 0)
+
+	for tag, count := range hits {
+		if count >= minHits {
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if hits[tags[i]] != hits[tags[j]] {
+			return hits[tags[i]] > hits[tags[j]]
+		}
+
+		return tags[i] < tags[j]
+	})
+
+	if len(tags) > maxSuggestions {
+		tags = tags[:maxSuggestions]
+	}
+
+	return tags
+}
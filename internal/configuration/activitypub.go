@@ -0,0 +1,153 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+/** The media type of ActivityPub documents. */
+const activityJSON = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+/** How many of the most recently starred posts the outbox lists. */
+const outboxSize = 20
+
+/*
+  - Publish the given user's starred posts as an
+    ActivityPub actor, so that the fediverse can see what they're
+    reading. The actor is reachable as USERNAME@HOST, where HOST is
+    taken from 'serve_url'.
+
+    Publishing is pull-only for now: the outbox can be read, but
+    nothing is delivered to followers, and the inbox accepts nothing.
+*/
+func registerActivityPub(state state, mux *http.ServeMux, user database.User) error {
+	if state.Config.ServeURL == "" {
+		return fmt.Errorf("ActivityPub needs 'serve_url' set to where 'serve' can be reached")
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(state.Config.ServeURL, "/"))
+
+	if err != nil || base.Host == "" {
+		return fmt.Errorf("Can't parse serve_url %q as an absolute URL", state.Config.ServeURL)
+	}
+
+	actorURL := fmt.Sprintf("%s/users/%s", base, url.PathEscape(user.Name))
+	account := fmt.Sprintf("acct:%s@%s", user.Name, base.Host)
+
+	mux.HandleFunc("GET /.well-known/webfinger", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("resource") != account {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeActivityJSON(w, "application/jrd+json", map[string]any{
+			"subject": account,
+			"links": []map[string]string{{
+				"rel":  "self",
+				"type": "application/activity+json",
+				"href": actorURL,
+			}},
+		})
+	})
+
+	mux.HandleFunc("GET /users/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != user.Name {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeActivityJSON(w, activityJSON, map[string]any{
+			"@context":          "https://www.w3.org/ns/activitystreams",
+			"id":                actorURL,
+			"type":              "Person",
+			"preferredUsername": user.Name,
+			"name":              user.Name,
+			"summary":           "What I'm reading, as saved with gator",
+			"inbox":             actorURL + "/inbox",
+			"outbox":            actorURL + "/outbox",
+		})
+	})
+
+	mux.HandleFunc("POST /users/{name}/inbox", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "This actor doesn't accept activities", http.StatusNotImplemented)
+	})
+
+	mux.HandleFunc("GET /users/{name}/outbox", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != user.Name {
+			http.NotFound(w, r)
+			return
+		}
+
+		state, cancel := requestState(state, r)
+		defer cancel()
+
+		starred, err := readFromReplica(state).db.GetStarredPosts(state.ctx, user.ID)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fetch the starred posts of user %q: %v\n", user.Name, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		starred = starred[:min(len(starred), outboxSize)]
+		items := make([]map[string]any, 0, len(starred))
+
+		for _, post := range starred {
+			items = append(items, starredPostActivity(actorURL, post))
+		}
+
+		writeActivityJSON(w, activityJSON, map[string]any{
+			"@context":     "https://www.w3.org/ns/activitystreams",
+			"id":           actorURL + "/outbox",
+			"type":         "OrderedCollection",
+			"totalItems":   len(items),
+			"orderedItems": items,
+		})
+	})
+
+	fmt.Printf("Publishing the starred posts of %s via ActivityPub\n", strings.TrimPrefix(account, "acct:"))
+
+	return nil
+}
+
+/** The 'Create' activity announcing a starred post as a note. */
+func starredPostActivity(actorURL string, post database.StarredPost) map[string]any {
+	// Notes are identified by the post's URL, which is as stable as
+	// anything gator has for it.
+	noteID := fmt.Sprintf("%s/notes/%s", actorURL, url.PathEscape(post.Url))
+	published := post.StarredAt.UTC().Format(time.RFC3339)
+
+	return map[string]any{
+		"id":        noteID + "/activity",
+		"type":      "Create",
+		"actor":     actorURL,
+		"published": published,
+		"to":        []string{"https://www.w3.org/ns/activitystreams#Public"},
+		"object": map[string]any{
+			"id":           noteID,
+			"type":         "Note",
+			"attributedTo": actorURL,
+			"published":    published,
+			"to":           []string{"https://www.w3.org/ns/activitystreams#Public"},
+			"url":          post.Url,
+			"content": fmt.Sprintf(`<p>Reading: <a href="%s">%s</a></p>`,
+				html.EscapeString(post.Url),
+				html.EscapeString(post.Title)),
+		},
+	}
+}
+
+func writeActivityJSON(w http.ResponseWriter, contentType string, document any) {
+	w.Header().Set("Content-Type", contentType)
+
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write response: %v\n", err)
+	}
+}
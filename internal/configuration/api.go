@@ -0,0 +1,366 @@
+package configuration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** How many posts 'GET /api/posts' returns unless asked otherwise, and at most. */
+const (
+	defaultAPIPageSize = 20
+	maxAPIPageSize     = 200
+)
+
+/** The causes of errors reported to API clients as "400 Bad Request" and "401 Unauthorized". */
+var (
+	errBadRequest   = errors.New("bad request")
+	errUnauthorized = errors.New("unauthorized")
+)
+
+/*
+  - Serve a JSON API over users' feeds, follows, posts, and read state,
+    for scripts and clients such as mobile apps:
+
+    GET    /api/feeds                all feeds, as by 'feeds'
+    GET    /api/follows              the user's feeds, as by 'following'
+    POST   /api/follows              follow {"url": FEED-URL}
+    DELETE /api/follows?url=FEED-URL unfollow a feed
+    GET    /api/posts                posts, as by 'browse'
+    PUT    /api/posts/{id}/read      mark a post as read
+    DELETE /api/posts/{id}/read      mark a post as unread
+
+    'GET /api/posts' takes the query parameters 'limit', 'offset',
+    'all', 'order', 'folder', 'tag', and 'dedupe', after the 'browse'
+    flags of the same names. Errors come back as {"error": MESSAGE}.
+
+    Each request must carry one of the keys made with 'apikey create'
+    as "Authorization: Bearer KEY", and acts as the user who made it.
+*/
+func registerAPI(state state, mux *http.ServeMux) {
+	handle := func(pattern string, handler apiHandler) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			state, cancel := requestState(state, r)
+			defer cancel()
+
+			user, err := apiKeyUser(state, r)
+
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeAPIError(w, err)
+				return
+			}
+
+			if r.Method == http.MethodGet {
+				state = readFromReplica(state)
+			}
+
+			result, err := handler(state, r, user)
+
+			if err != nil {
+				writeAPIError(w, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+
+			if err = output.JSON(w, result); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write response: %v\n", err)
+			}
+		})
+	}
+
+	handle("GET /api/feeds", apiFeeds)
+	handle("GET /api/follows", apiFollows)
+	handle("POST /api/follows", apiFollow)
+	handle("DELETE /api/follows", apiUnfollow)
+	handle("GET /api/posts", apiPosts)
+	handle("PUT /api/posts/{id}/read", apiMarkRead)
+	handle("DELETE /api/posts/{id}/read", apiMarkUnread)
+}
+
+/** Handles an API request, returning the result to send back as JSON. */
+type apiHandler func(state state, r *http.Request, user database.User) (any, error)
+
+/** The HTTP status matching the cause of an error. */
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, errUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+/** Report an error to an API client, with a status to match its cause. */
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+
+	// Internal errors are logged in full, and their details kept from
+	// clients.
+	message := err.Error()
+
+	if status == http.StatusInternalServerError {
+		fmt.Fprintf(os.Stderr, "API request failed: %v\n", err)
+		message = "Internal server error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err = output.JSON(w, map[string]string{"error": message}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write response: %v\n", err)
+	}
+}
+
+func apiFeeds(state state, r *http.Request, user database.User) (any, error) {
+	feeds, err := state.db.ListFeeds(state.ctx, database.ListFeedsParams{
+		MaxFailures: maxFeedFailures(state),
+		SortBy:      "name",
+	})
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch feeds")
+	}
+
+	listed := make([]listedFeed, 0, len(feeds))
+
+	for _, feed := range feeds {
+		listed = append(listed, newListedFeed(state, feed))
+	}
+
+	return listed, nil
+}
+
+func apiFollows(state state, r *http.Request, user database.User) (any, error) {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, user.ID)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch the feeds followed by user %q", user.Name)
+	}
+
+	followed := make([]followedFeed, 0, len(follows))
+
+	for _, info := range follows {
+		followed = append(followed, newFollowedFeed(info))
+	}
+
+	return followed, nil
+}
+
+/*
+  - Follow a feed which has already been added (with 'addfeed', say),
+    given its URL in the request body.
+*/
+func apiFollow(state state, r *http.Request, user database.User) (any, error) {
+	var body struct {
+		URL string `json:"url"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.URL == "" {
+		return nil, wrapError(errBadRequest, "Expected a JSON body of the form {\"url\": FEED-URL}")
+	}
+
+	feed, err := state.db.GetFeedByURL(state.ctx, body.URL)
+
+	if err == sql.ErrNoRows {
+		return nil, wrapError(ErrNotFound, "No feed with URL %q", body.URL)
+	}
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to look up feed %q", body.URL)
+	}
+
+	_, err = state.db.CreateFeedFollow(state.ctx, database.CreateFeedFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+
+	result := followResult{
+		User:             user.Name,
+		Feed:             feed.Name,
+		URL:              feed.Url,
+		AlreadyFollowing: err == sql.ErrNoRows,
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, wrapError(err, "Failed to make user %q follow feed %q", user.Name, feed.Name)
+	}
+
+	return result, nil
+}
+
+func apiUnfollow(state state, r *http.Request, user database.User) (any, error) {
+	url := r.URL.Query().Get("url")
+
+	if url == "" {
+		return nil, wrapError(errBadRequest, "Expected the feed to unfollow as ?url=FEED-URL")
+	}
+
+	count, err := state.db.DeleteFeedFollow(state.ctx, database.DeleteFeedFollowParams{
+		UserID: user.ID,
+		Url:    url,
+	})
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to unfollow feed %q", url)
+	}
+
+	if count == 0 {
+		return nil, wrapError(ErrNotFound, "User %q isn't following a feed with URL %q", user.Name, url)
+	}
+
+	return followResult{User: user.Name, URL: url}, nil
+}
+
+func apiPosts(state state, r *http.Request, user database.User) (any, error) {
+	query := r.URL.Query()
+
+	limit, err := queryInt(query.Get("limit"), defaultAPIPageSize)
+
+	if err != nil || limit > maxAPIPageSize {
+		return nil, wrapError(errBadRequest, "Expected 'limit' to be a number from 0 to %d", maxAPIPageSize)
+	}
+
+	offset, err := queryInt(query.Get("offset"), 0)
+
+	if err != nil {
+		return nil, wrapError(errBadRequest, "Expected 'offset' to be a non-negative number")
+	}
+
+	all, _ := strconv.ParseBool(query.Get("all"))
+	dedupe, err := strconv.ParseBool(query.Get("dedupe"))
+
+	if err != nil {
+		dedupe = true
+	}
+
+	params := database.GetPostsForUserParams{
+		UserID:     user.ID,
+		UnreadOnly: !all,
+		OrderBy:    query.Get("order"),
+		Dedupe:     dedupe,
+		Limit:      int32(limit),
+		Offset:     int32(offset),
+	}
+
+	if params.OrderBy == "" {
+		params.OrderBy = "published"
+	}
+
+	if params.OrderBy != "published" && params.OrderBy != "added" {
+		return nil, wrapError(errBadRequest, "Can't order posts by %q (use published or added)", params.OrderBy)
+	}
+
+	if name := query.Get("folder"); name != "" {
+		folder, err := lookUpFolder(state, user, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		params.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	if tag := query.Get("tag"); tag != "" {
+		params.Tag = sql.NullString{String: strings.ToLower(tag), Valid: true}
+	}
+
+	posts, err := state.db.GetPostsForUser(state.ctx, params)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch posts for user %q", user.Name)
+	}
+
+	browsed := make([]browsedPost, 0, len(posts))
+
+	for _, post := range posts {
+		browsed = append(browsed, newBrowsedPost(post))
+	}
+
+	return browsed, nil
+}
+
+func apiMarkRead(state state, r *http.Request, user database.User) (any, error) {
+	post, err := apiPost(state, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	}); err != nil {
+		return nil, wrapError(err, "Failed to mark post %q as read", post.Title)
+	}
+
+	return readState{ID: post.ID, Title: post.Title, Read: true}, nil
+}
+
+func apiMarkUnread(state state, r *http.Request, user database.User) (any, error) {
+	post, err := apiPost(state, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = state.db.MarkPostUnread(state.ctx, database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}); err != nil {
+		return nil, wrapError(err, "Failed to mark post %q as unread", post.Title)
+	}
+
+	return readState{ID: post.ID, Title: post.Title, Read: false}, nil
+}
+
+/** The post whose ID is given in the request's path. */
+func apiPost(state state, r *http.Request) (database.Post, error) {
+	postID, err := uuid.Parse(r.PathValue("id"))
+
+	if err != nil {
+		return database.Post{}, wrapError(errBadRequest, "Can't parse %q as a post ID", r.PathValue("id"))
+	}
+
+	return lookUpPost(state, postID.String())
+}
+
+/** Parse a non-negative query parameter, which defaults to 'fallback'. */
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 32)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("%d is negative", n)
+	}
+
+	return int(n), nil
+}
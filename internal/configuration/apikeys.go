@@ -0,0 +1,192 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/** What every API key starts with, so that leaked keys are easy to spot. */
+const apiKeyPrefix = "gator_"
+
+/*
+  - Manage the current user's keys for the JSON API served by
+    'serve --api': create a named key (shown only this once), revoke
+    one, or list them.
+*/
+func handlerAPIKey(state state, args []string, currentUser database.User) error {
+	if len(args) == 0 {
+		return fmt.Errorf("The 'apikey' command takes a subcommand: create, revoke, or list")
+	}
+
+	subcommand, args := args[0], args[1:]
+
+	switch subcommand {
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("The 'apikey create' command takes a single NAME argument")
+		}
+
+		return createAPIKey(state, currentUser, args[0])
+	case "revoke":
+		if len(args) != 1 {
+			return fmt.Errorf("The 'apikey revoke' command takes a single NAME argument")
+		}
+
+		return revokeAPIKey(state, currentUser, args[0])
+	case "list":
+		if len(args) > 0 {
+			return fmt.Errorf("The 'apikey list' command takes no arguments")
+		}
+
+		return listAPIKeys(state, currentUser)
+	default:
+		return fmt.Errorf("Unknown 'apikey' subcommand %q", subcommand)
+	}
+}
+
+/** An API key, as reported by 'apikey'; the key itself only on creation. */
+type listedAPIKey struct {
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+/** An API key, as reported by 'apikey revoke'. */
+type revokedAPIKey struct {
+	Name    string `json:"name"`
+	Revoked bool   `json:"revoked"`
+}
+
+func createAPIKey(state state, currentUser database.User, name string) error {
+	if name == "" {
+		return fmt.Errorf("An API key's name can't be empty")
+	}
+
+	token, err := newToken()
+
+	if err != nil {
+		return err
+	}
+
+	key := apiKeyPrefix + token
+
+	apiKey, err := state.db.CreateAPIKey(state.ctx, database.CreateAPIKeyParams{
+		ID:        ids.New(),
+		UserID:    currentUser.ID,
+		Name:      name,
+		KeyHash:   tokenHash(key),
+		CreatedAt: time.Now(),
+	})
+
+	if database.IsUniqueViolation(err, database.ApiKeysUserIDNameKey) {
+		return wrapError(ErrAlreadyExists, "User %q already has an API key named %q", currentUser.Name, name)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to create API key %q", name)
+	}
+
+	created := listedAPIKey{Name: apiKey.Name, Key: key, CreatedAt: apiKey.CreatedAt}
+
+	return output.Print(os.Stdout, state.JSON, created, func(w io.Writer) error {
+		fmt.Fprintln(w, key)
+		fmt.Fprintln(os.Stderr, "Keep this key somewhere safe: it won't be shown again.")
+
+		return nil
+	})
+}
+
+func revokeAPIKey(state state, currentUser database.User, name string) error {
+	numDeleted, err := state.db.DeleteAPIKey(state.ctx, database.DeleteAPIKeyParams{
+		UserID: currentUser.ID,
+		Name:   name,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to revoke API key %q", name)
+	}
+
+	if numDeleted == 0 {
+		return wrapError(ErrNotFound, "User %q has no API key named %q", currentUser.Name, name)
+	}
+
+	return output.Message(os.Stdout, state.JSON, revokedAPIKey{Name: name, Revoked: true}, "API key %q has been revoked", name)
+}
+
+func listAPIKeys(state state, currentUser database.User) error {
+	rows, err := state.db.GetAPIKeysForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the API keys of user %q", currentUser.Name)
+	}
+
+	apiKeys := make([]listedAPIKey, 0, len(rows))
+
+	for _, row := range rows {
+		listed := listedAPIKey{Name: row.Name, CreatedAt: row.CreatedAt}
+
+		if row.LastUsedAt.Valid {
+			listed.LastUsedAt = &row.LastUsedAt.Time
+		}
+
+		apiKeys = append(apiKeys, listed)
+	}
+
+	return output.Print(os.Stdout, state.JSON, apiKeys, func(w io.Writer) error {
+		table := make([][]string, 0, len(apiKeys))
+
+		for _, apiKey := range apiKeys {
+			lastUsed := "never"
+
+			if apiKey.LastUsedAt != nil {
+				lastUsed = apiKey.LastUsedAt.Format(time.DateTime)
+			}
+
+			table = append(table, []string{apiKey.Name, apiKey.CreatedAt.Format(time.DateTime), lastUsed})
+		}
+
+		return output.Table(w, []string{"NAME", "CREATED", "LAST USED"}, table)
+	})
+}
+
+/*
+  - Return the user an API request acts as, going by the key it carries
+    as "Authorization: Bearer KEY". The key's last use is recorded.
+*/
+func apiKeyUser(state state, r *http.Request) (database.User, error) {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	if !ok || key == "" {
+		return database.User{}, wrapError(errUnauthorized, "Missing API key (send 'Authorization: Bearer KEY'; see 'gator apikey create')")
+	}
+
+	userID, err := state.db.UseAPIKey(state.ctx, database.UseAPIKeyParams{
+		KeyHash:    tokenHash(key),
+		LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+
+	if err == sql.ErrNoRows {
+		return database.User{}, wrapError(errUnauthorized, "Invalid or revoked API key")
+	}
+
+	if err != nil {
+		return database.User{}, wrapError(err, "Failed to look up API key")
+	}
+
+	user, err := state.db.GetUserByID(state.ctx, userID)
+
+	if err != nil {
+		return database.User{}, wrapError(err, "Failed to look up the user of an API key")
+	}
+
+	return user, nil
+}
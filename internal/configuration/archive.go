@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/links"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"time"
+)
+
+/** An archived post, as reported by 'archive'. */
+type archivedPost struct {
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Snapshot   string    `json:"snapshot"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+/*
+  - Submit the given post to the Wayback Machine, and remember the
+    snapshot's URL alongside it. With no arguments, list the posts
+    archived so far from the current user's feeds.
+*/
+func handlerArchive(state state, args []string, currentUser database.User) error {
+	if len(args) > 1 {
+		return fmt.Errorf("The 'archive' command takes at most a single POST-URL argument")
+	}
+
+	if len(args) == 0 {
+		return listArchives(state, currentUser)
+	}
+
+	post, err := state.db.GetPostByURL(state.ctx, args[0])
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No saved post with URL %q", args[0])
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up post %q", args[0])
+	}
+
+	snapshot, err := archivePost(state, post.Url)
+
+	if err != nil {
+		return err
+	}
+
+	archived := archivedPost{
+		Title:      post.Title,
+		URL:        post.Url,
+		Snapshot:   snapshot,
+		ArchivedAt: time.Now(),
+	}
+
+	return output.Message(os.Stdout, state.JSON, archived, "Archived %q as %s", post.Title, snapshot)
+}
+
+func listArchives(state state, currentUser database.User) error {
+	archives, err := state.db.GetPostArchivesForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch archived posts for user %q", currentUser.Name)
+	}
+
+	archived := make([]archivedPost, 0, len(archives))
+
+	for _, archive := range archives {
+		archived = append(archived, archivedPost{
+			Title:      archive.Title,
+			URL:        archive.Url,
+			Snapshot:   archive.SnapshotUrl,
+			ArchivedAt: archive.ArchivedAt,
+		})
+	}
+
+	return output.Print(os.Stdout, state.JSON, archived, func(w io.Writer) error {
+		rows := make([][]string, 0, len(archived))
+
+		for _, post := range archived {
+			rows = append(rows, []string{post.Title, post.Snapshot, post.ArchivedAt.Format(time.DateOnly)})
+		}
+
+		return output.Table(w, []string{"POST", "SNAPSHOT", "ARCHIVED"}, rows)
+	})
+}
+
+/*
+  - Have the Wayback Machine snapshot the post with the given URL, and
+    record the snapshot's URL. Archiving a post again replaces its
+    snapshot.
+*/
+func archivePost(state state, url string) (string, error) {
+	snapshot, err := links.Archive(state.ctx, url)
+
+	if err != nil {
+		return "", wrapError(err, "Failed to archive %q", url)
+	}
+
+	if _, err = state.db.SavePostArchive(state.ctx, database.SavePostArchiveParams{
+		Url:         url,
+		SnapshotUrl: snapshot,
+		ArchivedAt:  time.Now(),
+	}); err != nil {
+		return "", wrapError(err, "Failed to record the snapshot of %q", url)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,142 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** A followed author, as reported by 'authors'. */
+type followedAuthor struct {
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+/*
+  - Follow an author across every feed, so that 'browse --authors'
+    shows their posts wherever they were published. Authors are
+    matched by name, ignoring case.
+*/
+func handlerFollowAuthor(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'follow-author' command takes a single NAME argument")
+	}
+
+	author := strings.TrimSpace(args[0])
+
+	if author == "" {
+		return fmt.Errorf("The author's name can't be empty")
+	}
+
+	_, err := state.db.CreateAuthorFollow(state.ctx, database.CreateAuthorFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UserID:    currentUser.ID,
+		Author:    author,
+	})
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrAlreadyExists, "User %q is already following author %q", currentUser.Name, author)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to make user %q follow author %q", currentUser.Name, author)
+	}
+
+	return output.Message(os.Stdout, state.JSON, authorFollow{User: currentUser.Name, Author: author},
+		"User %q is now following author %q", currentUser.Name, author)
+}
+
+/** The outcome of 'follow-author' and 'unfollow-author'. */
+type authorFollow struct {
+	User   string `json:"user"`
+	Author string `json:"author"`
+}
+
+func handlerUnfollowAuthor(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'unfollow-author' command takes a single NAME argument")
+	}
+
+	author := strings.TrimSpace(args[0])
+
+	if numDeleted, err := state.db.DeleteAuthorFollow(state.ctx, database.DeleteAuthorFollowParams{
+		UserID: currentUser.ID,
+		Author: author,
+	}); err != nil {
+		return wrapError(err, "Failed to unfollow author %q", author)
+	} else if numDeleted == 0 {
+		return wrapError(ErrNotFound, "User %q isn't following author %q", currentUser.Name, author)
+	}
+
+	return output.Print(os.Stdout, state.JSON, authorFollow{User: currentUser.Name, Author: author}, output.Nothing)
+}
+
+/** List the authors the current user follows, with their post counts. */
+func handlerAuthors(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("authors", flag.ContinueOnError)
+	paging := addListPaging(flagSet)
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'authors' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'authors' command takes no arguments")
+	}
+
+	follows, err := state.db.GetAuthorFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the authors followed by user %q", currentUser.Name)
+	}
+
+	followed := make([]followedAuthor, 0, len(follows))
+
+	for _, follow := range follows {
+		followed = append(followed, followedAuthor{
+			Name:  follow.Author,
+			Posts: follow.Posts,
+		})
+	}
+
+	return printPage(state, paging, followed, func(w io.Writer, followed []followedAuthor) error {
+		rows := make([][]string, 0, len(followed))
+
+		for _, author := range followed {
+			rows = append(rows, []string{author.Name, strconv.FormatInt(author.Posts, 10)})
+		}
+
+		return output.Table(w, []string{"AUTHOR", "POSTS"}, rows)
+	})
+}
+
+/*
+  - The "virtual feed" of posts by the authors the current user
+    follows, in the same form as their regular posts.
+*/
+func postsByFollowedAuthors(state state, params database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	rows, err := state.db.GetPostsByFollowedAuthors(state.ctx, database.GetPostsByFollowedAuthorsParams(params))
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]database.GetPostsForUserRow, len(rows))
+
+	for i, row := range rows {
+		posts[i] = database.GetPostsForUserRow(row)
+	}
+
+	return posts, nil
+}
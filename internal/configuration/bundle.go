@@ -0,0 +1,96 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/bundles"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"strconv"
+)
+
+/*
+  - Work with the curated starter bundles shipped with gator, so that
+    a brand-new user can get a useful river in one command.
+
+    The subcommands are:
+
+    list:         show the available bundles
+    install NAME: follow every feed in the named bundle
+*/
+func handlerBundle(state state, args []string, currentUser database.User) error {
+	if len(args) == 0 {
+		return fmt.Errorf("The 'bundle' command takes a subcommand: list or install")
+	}
+
+	flagSet := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	skipValidation := flagSet.Bool("skip-validation", false, "install feeds without fetching them first")
+	workers := flagSet.Int("workers", 8, "number of feeds to validate concurrently")
+
+	subcommand := args[0]
+	args, err := parseFlags(flagSet, args[1:])
+
+	if err != nil {
+		return fmt.Errorf("The 'bundle' command: %v", err)
+	}
+
+	switch subcommand {
+	case "list":
+		if len(args) > 0 {
+			return fmt.Errorf("The 'bundle list' command takes no arguments")
+		}
+
+		return listBundles(state)
+	case "install":
+		if len(args) != 1 {
+			return fmt.Errorf("The 'bundle install' command takes a single bundle NAME argument")
+		}
+
+		bundle, err := bundles.Get(args[0])
+
+		if err != nil {
+			return wrapError(ErrNotFound, "%v (see 'bundle list')", err)
+		}
+
+		return importOutlines(state, bundle.Feeds, *skipValidation, *workers, currentUser)
+	default:
+		return fmt.Errorf("Unknown 'bundle' subcommand %q", subcommand)
+	}
+}
+
+/** A bundle, as reported by 'bundle list'. */
+type bundleSummary struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Feeds int    `json:"feeds"`
+}
+
+func listBundles(state state) error {
+	available, err := bundles.List()
+
+	if err != nil {
+		return wrapError(err, "Failed to load the bundles")
+	}
+
+	summaries := make([]bundleSummary, 0, len(available))
+
+	for _, bundle := range available {
+		summaries = append(summaries, bundleSummary{
+			Name:  bundle.Name,
+			Title: bundle.Title,
+			Feeds: len(bundle.Feeds),
+		})
+	}
+
+	return output.Print(os.Stdout, state.JSON, summaries, func(w io.Writer) error {
+		rows := make([][]string, 0, len(summaries))
+
+		for _, summary := range summaries {
+			rows = append(rows, []string{summary.Name, summary.Title, strconv.Itoa(summary.Feeds)})
+		}
+
+		return output.Table(w, []string{"NAME", "TITLE", "FEEDS"}, rows)
+	})
+}
@@ -0,0 +1,88 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/ics"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+  - Export the posts of the given feeds as iCalendar events, one per
+    post, dated by the post's publication date. This is meant for
+    feeds which announce events (meetups, releases), so that they can
+    be subscribed to from a calendar app.
+*/
+func handlerCalendar(state state, args []string) error {
+	flagSet := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	outputFlag := flagSet.String("output", "", "write the calendar to this file instead of standard output")
+	durationFlag := flagSet.Duration("duration", time.Hour, "how long each event lasts")
+
+	urls, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'calendar' command: %v", err)
+	}
+
+	if len(urls) == 0 {
+		return fmt.Errorf("The 'calendar' command takes one or more feed URLs")
+	}
+
+	events := make([]ics.Event, 0)
+	name := ""
+
+	for _, url := range urls {
+		feed, err := state.db.GetFeedByURL(state.ctx, url)
+
+		if err == sql.ErrNoRows {
+			return wrapError(ErrNotFound, "No feed with URL %q (use 'addfeed' to add it)", url)
+		}
+
+		if err != nil {
+			return wrapError(err, "Failed to look up feed %q", url)
+		}
+
+		posts, err := state.db.GetPostsForFeed(state.ctx, feed.ID)
+
+		if err != nil {
+			return wrapError(err, "Failed to fetch posts for feed %q", feed.Name)
+		}
+
+		for _, post := range posts {
+			events = append(events, ics.Event{
+				UID:         fmt.Sprintf("%s@gator", post.ID),
+				Start:       post.PublishedAt,
+				Duration:    *durationFlag,
+				Summary:     post.Title,
+				Description: summarize(post.Description),
+				URL:         post.Url,
+			})
+		}
+
+		// Name the calendar after its feed, when there's only one.
+		if len(urls) == 1 {
+			name = feed.Name
+		} else {
+			name = "Gator events"
+		}
+	}
+
+	var out io.Writer = os.Stdout
+
+	if *outputFlag != "" {
+		file, err := os.Create(*outputFlag)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	return ics.Write(out, name, events)
+}
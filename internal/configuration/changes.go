@@ -0,0 +1,168 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"time"
+)
+
+/** A change in what a feed says about itself, as reported by 'changes'. */
+type feedChange struct {
+	Feed      string    `json:"feed"`
+	URL       string    `json:"url"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+/** How the fields of a feed's channel are named in the changes to them. */
+var channelFieldNames = map[string]string{
+	"title":       "title",
+	"description": "description",
+	"self_url":    "self URL",
+}
+
+/*
+  - Show the changes 'agg' has seen in the title, description, or self
+    URL of the current user's feeds, newest first. A feed renamed or
+    moved without notice is often on its way to going quiet, so this
+    is worth a look now and then.
+*/
+func handlerChanges(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("changes", flag.ContinueOnError)
+	since := flagSet.Duration("since", 0, "only show changes from within this long ago (such as 720h)")
+	paging := addListPaging(flagSet)
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'changes' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'changes' command takes no arguments")
+	}
+
+	if *since < 0 {
+		return fmt.Errorf("The 'changes' command's --since can't be negative")
+	}
+
+	params := database.GetFeedChangesForUserParams{UserID: currentUser.ID}
+
+	if *since > 0 {
+		params.Since = sql.NullTime{Time: time.Now().Add(-*since), Valid: true}
+	}
+
+	rows, err := state.db.GetFeedChangesForUser(state.ctx, params)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the changes to the feeds followed by user %q", currentUser.Name)
+	}
+
+	changes := make([]feedChange, 0, len(rows))
+
+	for _, row := range rows {
+		changes = append(changes, feedChange{
+			Feed:      row.FeedName,
+			URL:       row.FeedUrl,
+			Field:     row.Field,
+			OldValue:  row.OldValue,
+			NewValue:  row.NewValue,
+			ChangedAt: row.ChangedAt,
+		})
+	}
+
+	return printPage(state, paging, changes, func(w io.Writer, changes []feedChange) error {
+		if len(changes) == 0 {
+			fmt.Fprintln(w, "No changes")
+			return nil
+		}
+
+		for _, change := range changes {
+			fmt.Fprintf(w, "%s %q (%s) changed its %s from %q to %q\n",
+				change.ChangedAt.Format(time.DateTime),
+				change.Feed,
+				change.URL,
+				channelFieldNames[change.Field],
+				change.OldValue,
+				change.NewValue)
+		}
+
+		return nil
+	})
+}
+
+/*
+  - Compare what the given feed says about itself with what it said on
+    the last fetch, recording any change. A field the feed stops
+    giving isn't taken to have changed, since that's more often a
+    glitch than a rebranding. The first fetch only sets the baseline.
+*/
+func recordChannelChanges(state state, feed database.Feed, channel rss.Channel) error {
+	previous, err := state.db.GetFeedChannel(state.ctx, feed.ID)
+	first := err == sql.ErrNoRows
+
+	if err != nil && !first {
+		return wrapError(err, "Failed to look up what feed %q last said about itself", feed.Url)
+	}
+
+	current := database.SetFeedChannelParams{
+		FeedID:      feed.ID,
+		Title:       previous.Title,
+		Description: previous.Description,
+		SelfUrl:     previous.SelfUrl,
+		UpdatedAt:   time.Now(),
+	}
+
+	fields := []struct {
+		name     string
+		value    string
+		recorded *string
+	}{
+		{"title", channel.Title, &current.Title},
+		{"description", channel.Description, &current.Description},
+		{"self_url", channel.SelfURL, &current.SelfUrl},
+	}
+
+	changed := first
+
+	for _, field := range fields {
+		if field.value == "" || field.value == *field.recorded {
+			continue
+		}
+
+		if !first && *field.recorded != "" {
+			if err = state.db.CreateFeedChange(state.ctx, database.CreateFeedChangeParams{
+				ID:        ids.New(),
+				FeedID:    feed.ID,
+				ChangedAt: current.UpdatedAt,
+				Field:     field.name,
+				OldValue:  *field.recorded,
+				NewValue:  field.value,
+			}); err != nil {
+				return wrapError(err, "Failed to record a change to feed %q", feed.Url)
+			}
+
+			fmt.Printf("Feed %q changed its %s from %q to %q\n", feed.Name, channelFieldNames[field.name], *field.recorded, field.value)
+		}
+
+		*field.recorded = field.value
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err = state.db.SetFeedChannel(state.ctx, current); err != nil {
+		return wrapError(err, "Failed to record what feed %q says about itself", feed.Url)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+/** A response on the way to a feed, as reported by 'check'. */
+type checkedHop struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+/** What 'check' found out about a feed. */
+type feedCheck struct {
+	URL           string       `json:"url"`
+	Hops          []checkedHop `json:"hops"`
+	ContentType   string       `json:"content_type,omitempty"`
+	Format        string       `json:"format,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	Items         int          `json:"items"`
+	LastPublished *time.Time   `json:"last_published"`
+	Warnings      []string     `json:"warnings"`
+	Error         string       `json:"error,omitempty"`
+}
+
+/*
+  - Fetch a feed and report on it, without adding it: each HTTP
+    response on the way (redirects included), what kind of feed it
+    is, how many items it has and when the latest was published, and
+    anything in it which 'agg' would stumble over. This is the place
+    to start when a feed seems to have died.
+*/
+func handlerCheck(state state, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'check' command takes a single FEED-URL argument")
+	}
+
+	inspection := rss.Inspect(state.ctx, args[0])
+
+	check := feedCheck{
+		URL:         inspection.URL,
+		Hops:        make([]checkedHop, 0, len(inspection.Hops)),
+		ContentType: inspection.ContentType,
+		Format:      inspection.Format,
+		Warnings:    make([]string, 0),
+	}
+
+	for _, hop := range inspection.Hops {
+		check.Hops = append(check.Hops, checkedHop{URL: hop.URL, Status: hop.Status})
+	}
+
+	if inspection.Feed != nil {
+		check.Title = inspection.Feed.Channel.Title
+		check.Items = len(inspection.Feed.Channel.Item)
+		check.LastPublished, check.Warnings = checkItems(inspection.Feed)
+	}
+
+	if inspection.Err != nil {
+		check.Error = inspection.Err.Error()
+	}
+
+	if err := output.Print(os.Stdout, state.JSON, check, func(w io.Writer) error {
+		return printFeedCheck(w, check)
+	}); err != nil {
+		return err
+	}
+
+	if inspection.Err != nil {
+		return wrapError(inspection.Err, "Feed %s doesn't work", check.URL)
+	}
+
+	return nil
+}
+
+/*
+  - Look over a feed's items, returning when the latest of them was
+    published, along with warnings about those which gator can't make
+    full sense of.
+*/
+func checkItems(feed *rss.RSSFeed) (*time.Time, []string) {
+	var latest *time.Time
+
+	warnings := make([]string, 0)
+	noLink, noTitle, noDate, badDate := 0, 0, 0, 0
+	firstBadDate := ""
+	links := make(map[string]int)
+
+	if feed.Channel.Title == "" {
+		warnings = append(warnings, "The feed has no title")
+	}
+
+	if len(feed.Channel.Item) == 0 {
+		warnings = append(warnings, "The feed has no items")
+	}
+
+	for _, item := range feed.Channel.Item {
+		if item.Link == "" {
+			noLink++
+		} else {
+			links[item.Link]++
+		}
+
+		if item.Title == "" {
+			noTitle++
+		}
+
+		if item.PubDate == "" {
+			noDate++
+			continue
+		}
+
+		published, err := parseRawTime(item.PubDate)
+
+		if err != nil {
+			if badDate == 0 {
+				firstBadDate = item.PubDate
+			}
+
+			badDate++
+			continue
+		}
+
+		if latest == nil || published.After(*latest) {
+			latest = &published
+		}
+	}
+
+	duplicates := 0
+
+	for _, count := range links {
+		duplicates += count - 1
+	}
+
+	if noLink > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no link", noLink))
+	}
+
+	if duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items repeat another's link, and only the first is saved", duplicates))
+	}
+
+	if noTitle > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no title", noTitle))
+	}
+
+	if noDate > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no publication date, which stops 'agg' saving the feed's posts", noDate))
+	}
+
+	if badDate > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have a publication date which can't be parsed (such as %q), which stops 'agg' saving the feed's posts", badDate, firstBadDate))
+	}
+
+	return latest, warnings
+}
+
+func printFeedCheck(w io.Writer, check feedCheck) error {
+	fmt.Fprintf(w, "URL:            %s\n", check.URL)
+
+	for i, hop := range check.Hops {
+		label := "Redirected:"
+
+		if i == len(check.Hops)-1 {
+			label = "Response:"
+		}
+
+		fmt.Fprintf(w, "%-15s %d %s (%s)\n", label, hop.Status, http.StatusText(hop.Status), hop.URL)
+	}
+
+	if check.ContentType != "" {
+		fmt.Fprintf(w, "Content type:   %s\n", check.ContentType)
+	}
+
+	if check.Format != "" {
+		fmt.Fprintf(w, "Format:         %s\n", map[string]string{"rss": "RSS", "atom": "Atom", "rdf": "RSS 1.0 (RDF)"}[check.Format])
+	}
+
+	if check.Error != "" {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Title:          %s\n", check.Title)
+	fmt.Fprintf(w, "Items:          %d\n", check.Items)
+
+	if check.LastPublished != nil {
+		fmt.Fprintf(w, "Last published: %s\n", check.LastPublished.Local().Format(time.DateTime))
+	}
+
+	for _, warning := range check.Warnings {
+		fmt.Fprintf(w, "Warning:        %s\n", warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/links"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/progress"
+	"io"
+	"os"
+	"time"
+)
+
+/** A post's link, as reported by 'checklinks'. */
+type checkedLink struct {
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+/*
+  - Probe the links of the posts published recently in the current
+    user's feeds (or with '--starred', of the posts they've starred),
+    flagging those which have gone dead (404 or 410) or can't be
+    reached.
+
+    With '--archive', links which are still alive are also submitted to
+    the Wayback Machine, so that a copy survives them going dead later.
+*/
+func handlerCheckLinks(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("checklinks", flag.ContinueOnError)
+	sinceFlag := flagSet.String("since", "30d", "how far back to look (for example, 24h, 7d, or 2w)")
+	workers := flagSet.Int("workers", 8, "number of links to check concurrently")
+	archive := flagSet.Bool("archive", false, "submit live links to the Wayback Machine")
+	starred := flagSet.Bool("starred", false, "check the starred posts, however old, instead of recent ones")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'checklinks' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'checklinks' command takes no arguments")
+	}
+
+	var titles, urls []string
+
+	if *starred {
+		titles, urls, err = starredLinks(state, currentUser)
+	} else {
+		titles, urls, err = recentLinks(state, currentUser, *sinceFlag)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// The progress indicator goes to standard error, so as not to get
+	// mixed up with JSON output.
+	bar := progress.New(os.Stderr, "Checking", len(urls))
+
+	checks := links.CheckLinks(state.ctx, urls, *workers, func(_ links.Check) {
+		bar.Increment()
+	})
+
+	bar.Finish()
+
+	checked := make([]checkedLink, len(urls))
+
+	for i, check := range checks {
+		checked[i] = checkedLink{
+			Title:  titles[i],
+			URL:    check.URL,
+			Status: check.Status.String(),
+		}
+
+		if check.Err != nil {
+			checked[i].Error = check.Err.Error()
+		}
+
+		if *archive && check.Status == links.StatusAlive {
+			snapshot, err := archivePost(state, check.URL)
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				continue
+			}
+
+			checked[i].Snapshot = snapshot
+		}
+	}
+
+	return output.Print(os.Stdout, state.JSON, checked, func(w io.Writer) error {
+		counts := make(map[string]int)
+
+		for _, link := range checked {
+			counts[link.Status]++
+
+			if link.Status != links.StatusAlive.String() {
+				fmt.Fprintf(w, "%s: %q %s (%s)\n", link.Status, link.Title, link.URL, link.Error)
+			}
+		}
+
+		fmt.Fprintf(w, "Checked %d links (alive: %d, gone: %d, unreachable: %d)\n",
+			len(checked),
+			counts[links.StatusAlive.String()],
+			counts[links.StatusGone.String()],
+			counts[links.StatusUnreachable.String()])
+
+		return nil
+	})
+}
+
+/** The titles and links of the posts published within 'sinceFlag'. */
+func recentLinks(state state, currentUser database.User, sinceFlag string) ([]string, []string, error) {
+	since, err := parseLongDuration(sinceFlag)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := state.db.GetPostsForUserSince(state.ctx, database.GetPostsForUserSinceParams{
+		UserID:      currentUser.ID,
+		PublishedAt: time.Now().Add(-since),
+	})
+
+	if err != nil {
+		return nil, nil, wrapError(err, "Failed to fetch posts for user %q", currentUser.Name)
+	}
+
+	titles := make([]string, len(posts))
+	urls := make([]string, len(posts))
+
+	for i, post := range posts {
+		titles[i] = post.Title
+		urls[i] = post.Url
+	}
+
+	return titles, urls, nil
+}
+
+/** The titles and links of the posts the user has starred. */
+func starredLinks(state state, currentUser database.User) ([]string, []string, error) {
+	posts, err := state.db.GetStarredPosts(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return nil, nil, wrapError(err, "Failed to fetch the starred posts of user %q", currentUser.Name)
+	}
+
+	titles := make([]string, len(posts))
+	urls := make([]string, len(posts))
+
+	for i, post := range posts {
+		titles[i] = post.Title
+		urls[i] = post.Url
+	}
+
+	return titles, urls, nil
+}
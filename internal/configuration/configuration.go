@@ -6,21 +6,237 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/i18n"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/imap"
+	"github.com/BrandonIrizarry/gator/internal/jsonfeed"
+	"github.com/BrandonIrizarry/gator/internal/nitter"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/password"
+	"github.com/BrandonIrizarry/gator/internal/readtime"
+	"github.com/BrandonIrizarry/gator/internal/registry"
 	"github.com/BrandonIrizarry/gator/internal/rss"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
-	"github.com/michaljemala/pqerror"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 /** A struct for unmarshalling Gator's current JSON configuration. */
 type Config struct {
 	DbURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+
+	// A read-only replica of the database, for commands and requests
+	// which only read (see 'readOnlyCommands'.) Empty means everything
+	// goes to 'db_url'.
+	ReadDbURL string `json:"read_db_url,omitempty"`
+
+	// When the current user logged in. Absent when nobody is.
+	LoggedInAt *time.Time `json:"logged_in_at,omitempty"`
+
+	// The token of the current session, for users with a password.
+	SessionToken string `json:"session_token,omitempty"`
+
+	// The default per-command deadline, as a Go duration string
+	// (for example, "30s".)
+	Timeout string `json:"timeout,omitempty"`
+
+	// How many items 'agg' saves at a time, and at most per feed per
+	// fetch. Zero means use the defaults below.
+	IngestBatchSize int `json:"ingest_batch_size,omitempty"`
+	MaxItemsPerFeed int `json:"max_items_per_feed,omitempty"`
+
+	// How many goroutines 'agg' saves fetched posts with, apart from
+	// those fetching feeds, and how many batches may wait on them.
+	// Zero writers means each fetch saves its own posts; a zero queue
+	// size means use the default below.
+	DBWriters      int `json:"db_writers,omitempty"`
+	WriteQueueSize int `json:"write_queue_size,omitempty"`
+
+	// How many times in a row a feed may fail to fetch before 'agg'
+	// gives up on it. Zero means use the default below.
+	MaxFeedFailures int `json:"max_feed_failures,omitempty"`
+
+	// How many fetches from a host may fail in a row before the rest
+	// are failed straight away, and for how long (as a Go duration
+	// string.) Zero means use the defaults (see 'rss.Breaker'), and a
+	// negative number of failures turns the breaker off.
+	CircuitBreakerFailures int    `json:"circuit_breaker_failures,omitempty"`
+	CircuitBreakerCooldown string `json:"circuit_breaker_cooldown,omitempty"`
+
+	// How the IDs of new rows are made: "uuid" (random, the default)
+	// or "ulid" (ordered by time; see the 'ids' package.)
+	IDFormat string `json:"id_format,omitempty"`
+
+	// Whether 'agg' cleans up the titles and descriptions of new
+	// posts (see the 'normalize' package.)
+	NormalizeText bool `json:"normalize_text,omitempty"`
+
+	// Where 'agg' keeps copies of the images in new posts, so that
+	// they can be read offline. Empty means images aren't mirrored.
+	ImageMirrorDir string `json:"image_mirror_dir,omitempty"`
+
+	// Whether 'agg' fetches the page of each new post to learn its
+	// canonical URL (see the 'canonical' package.)
+	ResolveCanonicalURLs bool `json:"resolve_canonical_urls,omitempty"`
+
+	// The tags the categories of new posts become, keyed by category
+	// (in any case); an empty tag drops the category. Categories not
+	// listed become tags as they are (see 'categoryTags'.)
+	CategoryTags map[string]string `json:"category_tags,omitempty"`
+
+	// Glyphs (such as "📰" or "🎧") shown before the posts of the
+	// given feeds, keyed by feed name, so that kinds of content can be
+	// told apart at a glance.
+	FeedMarkers map[string]string `json:"feed_markers,omitempty"`
+
+	// Where 'serve' can be reached (for example,
+	// "https://gator.example.com"). When set, digests link to posts
+	// through its short links.
+	ServeURL string `json:"serve_url,omitempty"`
+
+	// The password Fever clients log in to 'serve --fever' with.
+	FeverPassword string `json:"fever_password,omitempty"`
+
+	// The password Google Reader clients log in to 'serve
+	// --google-reader' with.
+	GoogleReaderPassword string `json:"google_reader_password,omitempty"`
+
+	// The command, along with its arguments, run when gator is given
+	// none (for example, ["browse", "--authors"].)
+	DefaultCommand []string `json:"default_command,omitempty"`
+
+	// The accounts 'crosspost' shares posts to.
+	Crosspost *CrosspostConfig `json:"crosspost,omitempty"`
+
+	// How 'tts' reads posts aloud.
+	TTS *TTSConfig `json:"tts,omitempty"`
+
+	// Commands to fetch particular feeds with, instead of plain HTTP.
+	Fetchers []FetcherConfig `json:"fetchers,omitempty"`
+
+	// Pages to render with a headless browser and pick items out of,
+	// for publications with no usable feed.
+	Renderers []RendererConfig `json:"renderers,omitempty"`
+
+	// JSON APIs to make feeds of, for services offering no RSS.
+	JSONEndpoints []JSONEndpointConfig `json:"json_endpoints,omitempty"`
+
+	// Passwords for the mailboxes newsletters are read from, keyed by
+	// "USER@HOST" (see the 'imap' package.)
+	IMAPPasswords map[string]string `json:"imap_passwords,omitempty"`
+
+	// The Nitter instances X accounts are followed through, in order
+	// of preference (see the 'nitter' package.)
+	NitterInstances []string `json:"nitter_instances,omitempty"`
+}
+
+/*
+  - Have feeds whose URLs start with 'Prefix' fetched by running
+    'Command' (in which "{url}" stands for the feed's URL) and reading
+    its output, for example through a headless browser or over ssh.
+*/
+type FetcherConfig struct {
+	Prefix  string   `json:"prefix"`
+	Command []string `json:"command"`
+}
+
+/*
+  - Have feeds whose URLs start with 'Prefix' rendered in a headless
+    browser (the one at 'Browser', if given) whenever fetching them
+    normally yields no items, taking items from the rendered page with
+    the given selectors (see 'rss.Renderer'.)
+*/
+type RendererConfig struct {
+	Prefix  string `json:"prefix"`
+	Browser string `json:"browser,omitempty"`
+	Item    string `json:"item"`
+	Title   string `json:"title,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+/*
+  - Have feeds whose URLs start with 'Prefix' fetched as JSON, with
+    each value 'Items' leads to becoming a post, and its title, link,
+    date, and description picked out with the given paths or templates
+    (see 'jsonfeed.Fetcher'.) 'Headers' are sent along, for APIs
+    wanting a key.
+*/
+type JSONEndpointConfig struct {
+	Prefix      string            `json:"prefix"`
+	Items       string            `json:"items"`
+	Title       string            `json:"title"`
+	Link        string            `json:"link,omitempty"`
+	Date        string            `json:"date,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+/** Ingest limits used when the config doesn't set them. */
+const (
+	DefaultIngestBatchSize = 50
+	DefaultMaxItemsPerFeed = 500
+)
+
+/** How many batches of posts may wait on 'agg''s writers, by default. */
+const DefaultWriteQueueSize = 16
+
+/** How many failed fetches in a row mark a feed as broken, by default. */
+const DefaultMaxFeedFailures = 10
+
+/*
+  - The deadline used when neither the config nor the CLI sets one:
+    none at all, since commands which import or check whole lists of
+    feeds can take as long as those lists need.
+*/
+const DefaultTimeout time.Duration = 0
+
+/*
+  - Commands which are meant to run indefinitely, and so aren't
+    subject to an overall deadline. They're expected to apply
+    'state.Timeout' to each unit of work instead.
+*/
+var longRunningCommands = map[string]bool{
+	"agg":    true,
+	"serve":  true,
+	"worker": true,
+}
+
+/*
+  - Commands which only read, and can make do with a replica which is
+    a little behind the primary, so as to leave it to 'agg'.
+*/
+var readOnlyCommands = map[string]bool{
+	"browse":        true,
+	"reading-stats": true,
+	"search":        true,
+	"starred":       true,
+}
+
+/*
+  - Commands which don't use the database, and so run without it being
+    connected to (or brought up to date.) 'init' connects for itself.
+*/
+var databaseFreeCommands = map[string]bool{
+	"check": true,
+	"init":  true,
+}
+
+/** Whether the given command needs the database connected before it runs. */
+func NeedsDatabase(commandName string) bool {
+	return !databaseFreeCommands[commandName]
 }
 
 /** A struct for containing all necessary global state. */
@@ -33,6 +249,37 @@ type state struct {
 
 	// The interface to the database itself.
 	db *database.Queries
+
+	// Where read-only commands and requests send their queries: a
+	// replica, when 'read_db_url' is set, and otherwise the same
+	// database as 'db' (see 'readFromReplica'.)
+	replica *database.Queries
+
+	// The underlying connection pool, for the few statements sqlc
+	// can't express (such as DDL on computed table names.)
+	conn *sql.DB
+
+	// Translated messages and date formats for the user's locale.
+	catalog *i18n.Catalog
+
+	// The deadline applied to the current command's DB and HTTP
+	// work. Zero means no deadline.
+	Timeout time.Duration
+
+	// Whether commands should report their results as JSON rather
+	// than as human-readable text.
+	JSON bool
+
+	// The pool saving fetched posts, when 'agg' runs one; otherwise
+	// nil, and posts are saved as they're fetched.
+	writers *writerPool
+
+	// Which feeds 'agg' has found to be the same document, so as to
+	// download it only once per round of fetches; nil elsewhere.
+	feedAliases *rss.Aliases
+
+	// The context governing the current command.
+	ctx context.Context
 }
 
 /*
@@ -47,17 +294,19 @@ type StateType = state
 /** The command registry proper. */
 var commandRegistry = make(map[string]cliCommand)
 
-/** Helper to facilitate creating a new state. */
-func NewState(configBasename string, dbURL string) (state, error) {
-	// Get the user's home directory.
-	homeDir, err := os.UserHomeDir()
+/*
+  - Helper to facilitate creating a new state. The database isn't
+    connected to until the config file has been read (see 'Connect'.)
+*/
+func NewState() (state, error) {
+	configFile, err := ConfigPath()
 
 	if err != nil {
 		return state{}, err
 	}
 
-	// Open the database connection.
-	db, err := sql.Open("postgres", dbURL)
+	// Load the message catalog for the user's locale.
+	catalog, err := i18n.Detect()
 
 	if err != nil {
 		return state{}, err
@@ -65,480 +314,1448 @@ func NewState(configBasename string, dbURL string) (state, error) {
 
 	// With all the data in place, configure the state.
 	state := state{
-		ConfigFile: fmt.Sprintf("%s/%s", homeDir, configBasename),
+		ConfigFile: configFile,
 		Config:     &Config{},
-		db:         database.New(db),
+		catalog:    catalog,
+		ctx:        context.Background(),
 	}
 
 	return state, nil
 }
 
+/** The environment variable giving the database URL, when the config file doesn't. */
+const DbURLVariable = "GATOR_DB_URL"
+
 /*
-  - Read the contents of the given state struct's config file into the
-    'config' portion of the same struct.
+  - Return where the config file is: "gator/config.json" under
+    $XDG_CONFIG_HOME (or ~/.config, if that isn't set) if there's one
+    there, and otherwise ~/.gatorconfig.json, where it has always
+    been.
 */
-func Read(state state) error {
-	if state.ConfigFile == "" {
-		return fmt.Errorf("Unconfigured file path to JSON data")
-	}
-
-	file, err := os.Open(state.ConfigFile)
+func ConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	defer file.Close()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
 
-	decoder := json.NewDecoder(file)
+	// Relative paths are to be ignored, according to the spec.
+	if !filepath.IsAbs(configHome) {
+		configHome = filepath.Join(homeDir, ".config")
+	}
 
-	if err = decoder.Decode(&state.Config); err != nil {
-		return err
+	xdgPath := filepath.Join(configHome, "gator", "config.json")
+
+	if _, err := os.Stat(xdgPath); err == nil {
+		return xdgPath, nil
 	}
 
-	return nil
+	return filepath.Join(homeDir, ".gatorconfig.json"), nil
 }
 
-// Set the username in the configuration.
-func SetUser(state state, username string) error {
-	if state.ConfigFile == "" {
-		return fmt.Errorf("Unconfigured file path to JSON data")
+/*
+  - Connect to the database given by 'db_url' in the config file, or
+    failing that, by $GATOR_DB_URL. (Connections are only made once
+    there's something to do.)
+*/
+func Connect(state *state) error {
+	dbURL := state.Config.DbURL
+
+	if dbURL == "" {
+		dbURL = os.Getenv(DbURLVariable)
 	}
 
-	state.Config.CurrentUserName = username
-	buffer := new(bytes.Buffer)
+	if dbURL == "" {
+		return fmt.Errorf("No database to connect to: set 'db_url' in %s, or %s in the environment", state.ConfigFile, DbURLVariable)
+	}
 
-	encoder := json.NewEncoder(buffer)
+	db, err := sql.Open("postgres", dbURL)
 
-	if err := encoder.Encode(state.Config); err != nil {
+	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(state.ConfigFile, buffer.Bytes(), 0600); err != nil {
-		return err
-	}
+	state.db = database.New(db)
+	state.conn = db
+	state.replica = state.db
 
-	return nil
-}
+	if state.Config.ReadDbURL != "" {
+		replica, err := sql.Open("postgres", state.Config.ReadDbURL)
 
-func GetCommand(commandName string) (cliCommand, error) {
-	fn, ok := commandRegistry[commandName]
+		if err != nil {
+			return fmt.Errorf("The config's 'read_db_url': %v", err)
+		}
 
-	if !ok {
-		return nil, fmt.Errorf("Nonexistent command '%s'", commandName)
+		state.replica = database.New(replica)
 	}
 
-	return fn, nil
+	return nil
 }
 
 /*
-  - CLI commands rely on _handler functions_ for their underlying
-    implementation. These functions will have a name of the form
-    "handlerX", where the X denotes the functionality being
-    implemented, for example, "handlerLogin" is the function enabling
-    the 'gator login' command.
-
-    Note that the string elements of 'args' are not the original
-    command line arguments; rather, they are the intended arguments to
-    the command itself (_not_ including the command name).
+  - Send the queries of a command which only reads to the replica, if
+    there is one. Writes would fail there, so they'd better not be any.
 */
-func handlerLogin(state state, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("Missing username argument")
-	}
-
-	username := args[0]
-	ctx := context.Background()
+func readFromReplica(state state) state {
+	state.db = state.replica
 
-	// Note that, conversely to 'handlerRegister' (which see), we flag
-	// the _absence_ of the specified user.
-	if user, _ := state.db.GetUser(ctx, username); user.ID == [16]byte{} {
-		return fmt.Errorf("Nonexistent user '%s' (use 'register' to create a new user)", username)
-	}
+	return state
+}
 
-	if err := SetUser(state, username); err != nil {
-		return err
+/** Send the given command's queries to the replica, if it only reads. */
+func RouteReads(state *state, commandName string) {
+	if readOnlyCommands[commandName] {
+		*state = readFromReplica(*state)
 	}
-
-	fmt.Printf("The user has been set as '%s'\n", username)
-	return nil
 }
 
 /*
-  - Add (that is, register) the specified user to the 'users'
-    table.
+  - Read the contents of the given state struct's config file into the
+    'config' portion of the same struct.
 */
-func handlerRegister(state state, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("Missing username argument. Who are you registering?")
+func Read(state state) error {
+	if state.ConfigFile == "" {
+		return fmt.Errorf("Unconfigured file path to JSON data")
 	}
 
-	newname := args[0]
-	ctx := context.Background()
+	file, err := os.Open(state.ConfigFile)
 
-	// Note that, since uuid.UUID is an alias for [16]byte, its
-	// zero-value would be '[16]byte{}' (all zeroes). And so a freshly
-	// initialized 'CreateUserParams' struct would have an ID field
-	// set to this value.
-	//
-	// Conversely, an existing database row will have this set to
-	// something non-zero, which is what we check for here.
-	if user, _ := state.db.GetUser(ctx, newname); user.ID != [16]byte{} {
-		return fmt.Errorf("User '%s' is already registered", newname)
+	// Without a config file, everything is left at its default (the
+	// database might be given by the environment.) One is written out
+	// on logging in.
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
 	}
 
-	newuser, err := state.db.CreateUser(ctx, database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      args[0],
-	})
-
 	if err != nil {
 		return err
 	}
 
-	if err = SetUser(state, newname); err != nil {
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+
+	if err = decoder.Decode(&state.Config); err != nil {
 		return err
 	}
 
-	fmt.Printf("User '%s' has been created", newname)
-	fmt.Printf("%v\n", newuser)
-
 	return nil
 }
 
 /*
-  - Delete all records in the 'users' table. Used for testing purposes
-    only.
+  - Set the username in the configuration, starting a new session. An
+    empty username ends the current session.
 */
-func handlerReset(state state, args []string) error {
-	if len(args) > 0 {
-		return fmt.Errorf("The 'reset' command takes no arguments")
+func SetUser(state state, username string, token string) error {
+	if state.ConfigFile == "" {
+		return fmt.Errorf("Unconfigured file path to JSON data")
 	}
 
-	ctx := context.Background()
+	state.Config.CurrentUserName = username
+	state.Config.SessionToken = token
+	state.Config.LoggedInAt = nil
 
-	if err := state.db.Reset(ctx); err != nil {
-		return err
+	if username != "" {
+		now := time.Now()
+		state.Config.LoggedInAt = &now
 	}
 
-	return nil
+	return writeConfig(state)
 }
 
-func handlerUsers(state state, args []string) error {
-	if len(args) > 0 {
-		return fmt.Errorf("The 'users' command takes no arguments")
-	}
-
-	ctx := context.Background()
+/** Write the configuration out to the config file. */
+func writeConfig(state state) error {
+	buffer := new(bytes.Buffer)
 
-	users, err := state.db.GetUsers(ctx)
+	encoder := json.NewEncoder(buffer)
 
-	if err != nil {
+	if err := encoder.Encode(state.Config); err != nil {
 		return err
 	}
 
-	for _, user := range users {
-		maybeCurrent := ""
-
-		if state.Config.CurrentUserName == user.Name {
-			maybeCurrent = " (current)"
-		}
-
-		fmt.Printf("%s%s\n", user.Name, maybeCurrent)
-	}
-
-	return nil
+	return replaceFile(state.ConfigFile, buffer.Bytes())
 }
 
-func handlerAgg(state state, args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("The 'agg' command takes a single time-between-requests argument")
-	}
-
-	duration, err := time.ParseDuration(args[0])
+/*
+  - Replace the contents of the given file in one go, by writing them to
+    a temporary file alongside it and renaming that over it. Should two
+    'login's or 'register's run at once, the config file ends up as
+    one of them left it, rather than as a mixture of both (or half of
+    either.)
+*/
+func replaceFile(path string, contents []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.partial")
 
 	if err != nil {
-		return fmt.Errorf("Unable to parse %q as a duration", duration)
+		return err
 	}
 
-	fmt.Printf("Collecting first feed now; afterwards every %s\n\n", duration)
+	defer os.Remove(temp.Name())
 
-	if err = scrapeFeeds(state); err != nil {
-		return err
-	}
+	_, err = temp.Write(contents)
 
-	// Continuously scrape the most stale feed.
-	ticker := time.NewTicker(duration)
-	defer ticker.Stop()
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
 
-	for range ticker.C {
-		if err = scrapeFeeds(state); err != nil {
-			return err
-		}
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// Temporary files are only readable by their owner already, as the
+	// config file (which holds the session token) should be.
+	return os.Rename(temp.Name(), path)
 }
 
-func handlerAddFeed(state state, args []string, currentUser database.User) error {
-	if len(args) != 2 {
-		return fmt.Errorf("The 'addfeed' command takes a NAME and URL argument")
+/*
+  - Return the per-command deadline set in the config file, or
+    'DefaultTimeout' if there isn't one.
+*/
+func ConfiguredTimeout(state state) (time.Duration, error) {
+	if state.Config.Timeout == "" {
+		return DefaultTimeout, nil
 	}
 
-	feedName := args[0]
-	URL := args[1]
-
-	feed, err := state.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      feedName,
-		Url:       URL,
-		UserID:    currentUser.ID,
-	})
+	timeout, err := time.ParseDuration(state.Config.Timeout)
 
 	if err != nil {
-		return fmt.Errorf("'CreateFeed' failed for feed '%s', '%s'", feedName, URL)
+		return 0, fmt.Errorf("Unable to parse config timeout %q as a duration", state.Config.Timeout)
 	}
 
-	fmt.Println(feed)
+	return timeout, nil
+}
 
-	// Also create a feed-follow record for 'currentUser'.
-	if _, err = state.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    currentUser.ID,
-		FeedID:    feed.ID,
-	}); err != nil {
-		return fmt.Errorf("Failed to create follow record for:\n\tuser %v\n\tand feed %v\n", currentUser, feed)
+/** Make the IDs of new rows in the format the config file asks for. */
+func ConfigureIDs(state state) error {
+	if err := ids.SetFormat(state.Config.IDFormat); err != nil {
+		return fmt.Errorf("The config's 'id_format': %v", err)
 	}
 
 	return nil
 }
 
-func handlerFeeds(state state, args []string) error {
-	if len(args) > 0 {
-		return fmt.Errorf("The 'feeds' command takes no arguments")
-	}
-
-	ctx := context.Background()
-	feeds, err := state.db.GetFeeds(ctx)
-
-	if err != nil {
-		return fmt.Errorf("'GetField' failed")
+/** Put the fetchers and renderers named in the config file into effect. */
+func RegisterFetchers(state state) error {
+	if state.Config.CircuitBreakerFailures != 0 {
+		rss.DefaultBreaker.Threshold = state.Config.CircuitBreakerFailures
 	}
 
-	for _, feed := range feeds {
-		user, err := state.db.GetUserByID(ctx, feed.UserID)
+	if state.Config.CircuitBreakerCooldown != "" {
+		cooldown, err := time.ParseDuration(state.Config.CircuitBreakerCooldown)
 
 		if err != nil {
-			return fmt.Errorf("Couldn't get user associated with feed %v\n", feed)
+			return fmt.Errorf("Unable to parse config circuit breaker cooldown %q as a duration", state.Config.CircuitBreakerCooldown)
 		}
 
-		fmt.Printf("%q, added by user %s\n", feed.Name, user.Name)
+		rss.DefaultBreaker.Cooldown = cooldown
 	}
 
-	return nil
-}
+	for _, fetcher := range state.Config.Fetchers {
+		if fetcher.Prefix == "" || len(fetcher.Command) == 0 {
+			return fmt.Errorf("Each configured fetcher needs both a prefix and a command")
+		}
 
-func handlerFollow(state state, args []string, currentUser database.User) error {
-	if len(args) != 1 {
-		return fmt.Errorf("The 'follow' command takes a single URL argument")
+		rss.Register(fetcher.Prefix, rss.CommandFetcher{Command: fetcher.Command})
 	}
 
-	url := args[0]
-	feed, err := state.db.GetFeedByURL(context.Background(), url)
+	// Mailboxes are fetchable like any feed, so that newsletters can be
+	// followed.
+	mailboxes := imap.Fetcher{Passwords: state.Config.IMAPPasswords}
+	rss.Register("imap://", mailboxes)
+	rss.Register("imaps://", mailboxes)
 
-	if err != nil {
-		return fmt.Errorf("Failed to fetch feed inside 'handlerFollower'")
+	// As are container images, with a post for each tag.
+	rss.Register("docker://", registry.ImageFetcher{})
+
+	// And packages, with a post for each release.
+	for _, scheme := range registry.PackageSchemes() {
+		rss.Register(scheme+"://", registry.PackageFetcher{})
 	}
 
-	feedInfo, err := state.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    currentUser.ID,
-		FeedID:    feed.ID,
+	// As are X accounts, through Nitter.
+	rss.Register("nitter://", &nitter.Fetcher{
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		Instances: state.Config.NitterInstances,
 	})
 
-	if err != nil {
-		return fmt.Errorf("Failed to create follow record for:\n\tuser %v\n\tand feed %v\n", currentUser, feed)
-	}
-
-	fmt.Printf("Feed name: %q\nUser name: %q\n", feedInfo.Feedname, feedInfo.Username)
+	// Pages can be watched for changes, with a post for each.
+	watcher := rss.WatchFetcher{Client: &http.Client{Timeout: 10 * time.Second}}
+	rss.Register("watch+http://", watcher)
+	rss.Register("watch+https://", watcher)
 
-	return nil
-}
+	for _, endpoint := range state.Config.JSONEndpoints {
+		if endpoint.Prefix == "" || endpoint.Items == "" || endpoint.Title == "" {
+			return fmt.Errorf("Each configured JSON endpoint needs a prefix, an items path, and a title")
+		}
 
-func handlerFollowing(state state, args []string, currentUser database.User) error {
-	if len(args) > 0 {
-		return fmt.Errorf("The 'following' command takes no arguments")
-	}
+		fetcher := jsonfeed.Fetcher{
+			Client:      &http.Client{Timeout: 10 * time.Second},
+			Headers:     endpoint.Headers,
+			Items:       endpoint.Items,
+			Title:       endpoint.Title,
+			Link:        endpoint.Link,
+			Date:        endpoint.Date,
+			Description: endpoint.Description,
+		}
 
-	feedFollowsInfo, err := state.db.GetFeedFollowsForUser(context.Background(), currentUser.ID)
+		if err := fetcher.Validate(); err != nil {
+			return fmt.Errorf("The JSON endpoint for %q: %v", endpoint.Prefix, err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("Failed to fetch feed-follows info for user %v\n", currentUser)
+		rss.Register(endpoint.Prefix, fetcher)
 	}
 
-	for _, info := range feedFollowsInfo {
-		fmt.Println(info.Feedname)
+	for _, renderer := range state.Config.Renderers {
+		if renderer.Prefix == "" || renderer.Item == "" {
+			return fmt.Errorf("Each configured renderer needs a prefix and an item selector")
+		}
+
+		rss.RegisterRenderer(renderer.Prefix, rss.Renderer{
+			Browser: renderer.Browser,
+			Item:    renderer.Item,
+			Title:   renderer.Title,
+			Link:    renderer.Link,
+			Date:    renderer.Date,
+		})
 	}
 
 	return nil
 }
 
-func handlerUnfollow(state state, args []string, currentUser database.User) error {
-	if len(args) != 1 {
-		return fmt.Errorf("The command 'unfollow' takes a single URL  argument")
+/*
+  - Set up the context under which the given command will run,
+    applying 'state.Timeout' as its deadline, less any time spent
+    waiting on prompts. The returned function must be called once the
+    command finishes.
+*/
+func PrepareContext(state *state, commandName string) context.CancelFunc {
+	if state.Timeout <= 0 || longRunningCommands[commandName] {
+		var cancel context.CancelFunc
+		state.ctx, cancel = context.WithCancel(context.Background())
+
+		return cancel
 	}
 
-	url := args[0]
+	ctx, cancel := context.WithCancelCause(context.Background())
+	startDeadline(state.Timeout, cancel)
+	state.ctx = ctx
 
-	if numDeleted, err := state.db.DeleteFeedFollow(context.Background(), database.DeleteFeedFollowParams{
-		UserID: currentUser.ID,
-		Url:    url,
-	}); err != nil {
-		return fmt.Errorf("Failed to delete feed-follow with URL %q\n", url)
-	} else if numDeleted == 0 {
-		return fmt.Errorf("URL %q doesn't exist in the feed-follows record\n", url)
+	return func() {
+		stopDeadline()
+		cancel(context.Canceled)
 	}
-
-	return nil
 }
 
-func handlerBrowse(state state, args []string, currentUser database.User) error {
-	// The cast is required because it's being used as a LIMIT
-	// parameter for a query.
-	var err error
-	var limit64 int64 = 2
-
-	if len(args) == 1 {
-		limit64, err = strconv.ParseInt(args[0], 10, 32)
+func GetCommand(commandName string) (cliCommand, error) {
+	fn, ok := commandRegistry[commandName]
 
-		if err != nil {
-			return fmt.Errorf("Can't parse %q as an int\n", args[0])
-		}
-	} else if len(args) > 1 {
-		return fmt.Errorf("Too many args")
+	if !ok {
+		return nil, fmt.Errorf("Nonexistent command '%s'", commandName)
 	}
 
-	limit := int32(limit64)
+	return fn, nil
+}
 
-	posts, err := state.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: currentUser.ID,
-		Limit:  limit,
+/** Who's logged in, as reported by 'login', 'logout', and 'register'. */
+type session struct {
+	User     string `json:"user,omitempty"`
+	LoggedIn bool   `json:"logged_in"`
+}
+
+/*
+  - CLI commands rely on _handler functions_ for their underlying
+    implementation. These functions will have a name of the form
+    "handlerX", where the X denotes the functionality being
+    implemented, for example, "handlerLogin" is the function enabling
+    the 'gator login' command.
+
+    Note that the string elements of 'args' are not the original
+    command line arguments; rather, they are the intended arguments to
+    the command itself (_not_ including the command name).
+*/
+func handlerLogin(state state, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Missing username argument")
+	}
+
+	username := args[0]
+	ctx := state.ctx
+
+	user, err := state.db.GetUser(ctx, username)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "Nonexistent user '%s' (use 'register' to create a new user)", username)
+	} else if err != nil {
+		return wrapError(err, "Failed to look up user '%s'", username)
+	}
+
+	if err = checkPassword(user); err != nil {
+		return err
+	}
+
+	// Logging in ends whatever session came before.
+	if err = endSession(state, state.Config.SessionToken); err != nil {
+		return err
+	}
+
+	token, err := startSession(state, user)
+
+	if err != nil {
+		return err
+	}
+
+	if err = SetUser(state, username, token); err != nil {
+		return err
+	}
+
+	return output.Message(os.Stdout, state.JSON, session{User: username, LoggedIn: true}, "The user has been set as '%s'", username)
+}
+
+/** End the current session, so that no user is logged in. */
+func handlerLogout(state state, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("The 'logout' command takes no arguments")
+	}
+
+	if state.Config.CurrentUserName == "" {
+		return output.Message(os.Stdout, state.JSON, session{}, "Nobody is logged in")
+	}
+
+	username := state.Config.CurrentUserName
+
+	if err := endSession(state, state.Config.SessionToken); err != nil {
+		return err
+	}
+
+	if err := SetUser(state, "", ""); err != nil {
+		return err
+	}
+
+	return output.Message(os.Stdout, state.JSON, session{}, "User '%s' has been logged out", username)
+}
+
+/*
+  - Add (that is, register) the specified user to the 'users'
+    table.
+*/
+func handlerRegister(state state, args []string) error {
+	flagSet := flag.NewFlagSet("register", flag.ContinueOnError)
+	withPassword := flagSet.Bool("password", false, "protect the user with a password, read from standard input")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'register' command: %v", err)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("Missing username argument. Who are you registering?")
+	}
+
+	newuser, err := registerUser(state, args[0], *withPassword)
+
+	if err != nil {
+		return err
+	}
+
+	return output.Message(os.Stdout, state.JSON, session{User: newuser.Name, LoggedIn: true}, "User '%s' has been created", newuser.Name)
+}
+
+/*
+  - Register a new user, asking for their password first if they're to
+    have one, and log them in.
+*/
+func registerUser(state state, newname string, withPassword bool) (database.User, error) {
+	passwordHash := ""
+
+	if err := validateUsername(newname); err != nil {
+		return database.User{}, err
+	}
+
+	if withPassword {
+		given, err := readPassword(fmt.Sprintf("Password for '%s': ", newname))
+
+		if err != nil {
+			return database.User{}, err
+		}
+
+		if given == "" {
+			return database.User{}, fmt.Errorf("The 'register' command: the password can't be empty")
+		}
+
+		passwordHash, err = password.Hash(given)
+
+		if err != nil {
+			return database.User{}, wrapError(err, "Failed to hash the password of user '%s'", newname)
+		}
+	}
+
+	// The insertion does nothing if the name is already taken, in
+	// which case no row comes back. Checking this way (rather than
+	// looking the user up first) leaves no window for a concurrent
+	// 'register' to slip in between the check and the insert.
+	newuser, err := state.db.CreateUser(state.ctx, database.CreateUserParams{
+		ID:           ids.New(),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		Name:         newname,
+		PasswordHash: passwordHash,
+	})
+
+	if err == sql.ErrNoRows {
+		return database.User{}, wrapError(ErrAlreadyExists, "User '%s' is already registered", newname)
+	}
+
+	if err != nil {
+		return database.User{}, wrapError(err, "Failed to register user '%s'", newname)
+	}
+
+	if err = endSession(state, state.Config.SessionToken); err != nil {
+		return database.User{}, err
+	}
+
+	token, err := startSession(state, newuser)
+
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if err = SetUser(state, newname, token); err != nil {
+		return database.User{}, err
+	}
+
+	return newuser, nil
+}
+
+/** The longest a username may be, in characters. */
+const maxUsernameLength = 32
+
+/*
+  - Names nobody may register, since they'd read as something other
+    than a user (compared without regard to case.)
+*/
+var reservedUsernames = map[string]bool{
+	"admin":  true,
+	"all":    true,
+	"root":   true,
+	"system": true,
+}
+
+/*
+  - Check that a name is fit to register: letters, digits, '.', '_'
+    and '-' only, which keeps names unambiguous on the command line
+    and in URLs, and not one of the reserved names.
+*/
+func validateUsername(name string) error {
+	if name == "" {
+		return fmt.Errorf("A username can't be empty")
+	}
+
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("A username can't start or end with spaces (%q)", name)
+	}
+
+	if length := utf8.RuneCountInString(name); length > maxUsernameLength {
+		return fmt.Errorf("A username can be at most %d characters long (%q has %d)", maxUsernameLength, name, length)
+	}
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("._-", r) {
+			return fmt.Errorf("A username can only contain letters, digits, '.', '_', and '-' (%q contains %q)", name, r)
+		}
+	}
+
+	if reservedUsernames[strings.ToLower(name)] {
+		return fmt.Errorf("The username %q is reserved; please choose another", name)
+	}
+
+	return nil
+}
+
+/*
+  - Delete all records in the 'users' table. Used for testing purposes
+    only.
+*/
+func handlerReset(state state, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("The 'reset' command takes no arguments")
+	}
+
+	ctx := state.ctx
+
+	if err := state.db.Reset(ctx); err != nil {
+		return wrapError(err, "Failed to reset the database")
+	}
+
+	return nil
+}
+
+/** A user, as reported by 'users'. */
+type listedUser struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+func handlerUsers(state state, args []string) error {
+	flagSet := flag.NewFlagSet("users", flag.ContinueOnError)
+	paging := addListPaging(flagSet)
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'users' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'users' command takes no arguments")
+	}
+
+	ctx := state.ctx
+
+	users, err := state.db.GetUsers(ctx)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch users")
+	}
+
+	listed := make([]listedUser, 0, len(users))
+
+	for _, user := range users {
+		listed = append(listed, listedUser{
+			Name:    user.Name,
+			Current: state.Config.CurrentUserName == user.Name,
+		})
+	}
+
+	return printPage(state, paging, listed, func(w io.Writer, listed []listedUser) error {
+		for _, user := range listed {
+			maybeCurrent := ""
+
+			if user.Current {
+				maybeCurrent = " (current)"
+			}
+
+			fmt.Fprintf(w, "%s%s\n", user.Name, maybeCurrent)
+		}
+
+		return nil
+	})
+}
+
+func handlerAddFeed(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("addfeed", flag.ContinueOnError)
+	tagsFlag := flagSet.String("tags", "", "comma-separated tags for the feed")
+	suggestTags := flagSet.Bool("suggest-tags", false, "suggest tags from the feed's contents, for confirmation")
+	skipValidation := flagSet.Bool("skip-validation", false, "add the feed without fetching it first")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'addfeed' command: %v", err)
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("The 'addfeed' command takes a NAME and URL argument")
+	}
+
+	feedName := args[0]
+	URL := args[1]
+	tags := splitTags(*tagsFlag)
+
+	if !*skipValidation {
+		if URL, err = checkFeedURL(state, URL); err != nil {
+			return err
+		}
+	}
+
+	// Settle the tags before anything is saved, so that backing out
+	// of the confirmation leaves nothing behind.
+	if *suggestTags {
+		suggested := suggestFeedTags(state, URL)
+		resume := pauseDeadline()
+		tags = append(tags, confirmTags(os.Stdin, os.Stdout, suggested)...)
+		resume()
+	}
+
+	feed, err := state.db.CreateFeed(state.ctx, database.CreateFeedParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      feedName,
+		Url:       URL,
+		UserID:    uuid.NullUUID{UUID: currentUser.ID, Valid: true},
+	})
+
+	if database.IsUniqueViolation(err, database.FeedsURLKey) {
+		return wrapError(ErrAlreadyExists, "A feed with URL %q already exists (use 'follow' to follow it)", URL)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to add feed '%s', '%s'", feedName, URL)
+	}
+
+	// Also create a feed-follow record for 'currentUser'. If they
+	// somehow already follow it, there's nothing to do.
+	if _, err = state.db.CreateFeedFollow(state.ctx, database.CreateFeedFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    currentUser.ID,
+		FeedID:    feed.ID,
+	}); err != nil && err != sql.ErrNoRows {
+		return wrapError(err, "Failed to make user %q follow feed %q", currentUser.Name, feed.Name)
+	}
+
+	for _, tag := range tags {
+		if err = state.db.AddFeedTag(state.ctx, database.AddFeedTagParams{
+			FeedID: feed.ID,
+			Tag:    tag,
+		}); err != nil {
+			return wrapError(err, "Failed to tag feed %q with %q", feed.Name, tag)
+		}
+	}
+
+	added := addedFeed{
+		ID:   feed.ID,
+		Name: feed.Name,
+		URL:  feed.Url,
+		Tags: tags,
+	}
+
+	return output.Message(os.Stdout, state.JSON, added, "Added feed %q (%s)", feed.Name, feed.Url)
+}
+
+/*
+  - Fetch the given feed before it's added, refusing it if it turns
+    out to be something other than a feed, so that the mistake shows
+    up now rather than as a parse error in 'agg'. A web page linking
+    to feeds gives way to one of them (see 'chooseAlternate'), whose
+    URL is returned in its place. Failing to fetch it at all isn't
+    held against it, since it may only be out of reach for the moment.
+*/
+func checkFeedURL(state state, url string) (string, error) {
+	_, err := rss.FetchFeed(state.ctx, url)
+
+	var notAFeed *rss.NotAFeedError
+
+	if errors.As(err, &notAFeed) && len(notAFeed.Alternates) > 0 {
+		alternate, err := chooseAlternate(url, notAFeed.Alternates)
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s is a web page; adding the feed it links to, %s\n", url, alternate.URL)
+		url = alternate.URL
+
+		// The feed itself had better not be another page.
+		_, err = rss.FetchFeed(state.ctx, url)
+	}
+
+	if errors.As(err, &notAFeed) {
+		return "", wrapError(err, "%v (use --skip-validation to add it anyway)", err)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't fetch %s to check it (adding it anyway): %v\n", url, err)
+	}
+
+	return url, nil
+}
+
+/*
+  - Pick one of the feeds a web page links to: the only one, or,
+    given several, the one the user chooses when asked on a terminal,
+    and otherwise the first (which sites tend to make their main
+    feed.)
+*/
+func chooseAlternate(pageURL string, alternates []rss.Alternate) (rss.Alternate, error) {
+	if len(alternates) == 1 || !isTerminal(os.Stdin) {
+		return alternates[0], nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s links to %d feeds:\n", pageURL, len(alternates))
+
+	for i, alternate := range alternates {
+		if alternate.Title == "" {
+			fmt.Fprintf(os.Stderr, "  %d. %s\n", i+1, alternate.URL)
+		} else {
+			fmt.Fprintf(os.Stderr, "  %d. %s (%s)\n", i+1, alternate.Title, alternate.URL)
+		}
+	}
+
+	answer, err := promptLine("Feed to add", "1")
+
+	if err != nil {
+		return rss.Alternate{}, err
+	}
+
+	choice, err := strconv.Atoi(answer)
+
+	if err != nil || choice < 1 || choice > len(alternates) {
+		return rss.Alternate{}, fmt.Errorf("No feed numbered %q; expected a number from 1 to %d", answer, len(alternates))
+	}
+
+	return alternates[choice-1], nil
+}
+
+/** A feed, as reported by 'addfeed'. */
+type addedFeed struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	URL  string    `json:"url"`
+	Tags []string  `json:"tags"`
+}
+
+/*
+  - A feed, as reported by 'feeds'. 'AddedBy' is null when the user
+    who added the feed has since been deleted.
+*/
+type listedFeed struct {
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	AddedBy    *string    `json:"added_by"`
+	Followers  int64      `json:"followers"`
+	AddedAt    time.Time  `json:"added_at"`
+	LastPostAt *time.Time `json:"last_post_at"`
+	Tags       []string   `json:"tags"`
+	LastError  *string    `json:"last_error"`
+	Failures   int32      `json:"failures"`
+	Broken     bool       `json:"broken"`
+	Paused     bool       `json:"paused"`
+}
+
+func newListedFeed(state state, feed database.ListFeedsRow) listedFeed {
+	listed := listedFeed{
+		Name:      feed.Name,
+		URL:       feed.Url,
+		Followers: feed.Followers,
+		AddedAt:   feed.CreatedAt,
+		Tags:      feed.Tags,
+		Failures:  feed.FailureCount,
+		Broken:    feed.FailureCount >= maxFeedFailures(state),
+		Paused:    feed.PausedAt.Valid,
+	}
+
+	if feed.Username.Valid {
+		listed.AddedBy = &feed.Username.String
+	}
+
+	if feed.LastPostAt.Valid {
+		listed.LastPostAt = &feed.LastPostAt.Time
+	}
+
+	if feed.LastError.Valid {
+		listed.LastError = &feed.LastError.String
+	}
+
+	return listed
+}
+
+/** The orderings 'feeds --sort' accepts. */
+var feedSortOrders = map[string]bool{
+	"name":      true,
+	"followers": true,
+	"activity":  true,
+	"added":     true,
+}
+
+func handlerFeeds(state state, args []string) error {
+	flagSet := flag.NewFlagSet("feeds", flag.ContinueOnError)
+	mine := flagSet.Bool("mine", false, "only list feeds added by the current user")
+	tag := flagSet.String("tag", "", "only list feeds with this tag")
+	dead := flagSet.Bool("dead", false, "only list feeds whose last fetch failed")
+	paused := flagSet.Bool("paused", false, "only list paused feeds")
+	broken := flagSet.Bool("broken", false, "only list feeds 'agg' has given up on after repeated failures")
+	sortBy := flagSet.String("sort", "name", "order by name, followers, activity (latest post), or added (newest first)")
+	paging := addListPaging(flagSet)
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'feeds' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'feeds' command takes no arguments")
+	}
+
+	if !feedSortOrders[*sortBy] {
+		return fmt.Errorf("Can't sort feeds by %q (use name, followers, activity, or added)", *sortBy)
+	}
+
+	params := database.ListFeedsParams{
+		Tag: sql.NullString{
+			String: strings.ToLower(*tag),
+			Valid:  *tag != "",
+		},
+		DeadOnly:    *dead,
+		PausedOnly:  *paused,
+		BrokenOnly:  *broken,
+		MaxFailures: maxFeedFailures(state),
+		SortBy:      *sortBy,
+	}
+
+	if *mine {
+		currentUser, err := loggedInUser(state)
+
+		if err != nil {
+			return err
+		}
+
+		params.UserID = uuid.NullUUID{UUID: currentUser.ID, Valid: true}
+	}
+
+	// The adding users' names and the follower counts come back in
+	// the same query, so that this is a single round trip no matter
+	// how many feeds there are.
+	feeds, err := state.db.ListFeeds(state.ctx, params)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch feeds")
+	}
+
+	listed := make([]listedFeed, 0, len(feeds))
+
+	for _, feed := range feeds {
+		listed = append(listed, newListedFeed(state, feed))
+	}
+
+	return printPage(state, paging, listed, func(w io.Writer, listed []listedFeed) error {
+		for _, feed := range listed {
+			// The user who added a feed may since have been deleted.
+			addedBy := "a deleted user"
+
+			if feed.AddedBy != nil {
+				addedBy = fmt.Sprintf("user %s", *feed.AddedBy)
+			}
+
+			line := fmt.Sprintf("%q, added by %s (%d followers)", feed.Name, addedBy, feed.Followers)
+
+			for _, tag := range feed.Tags {
+				line += " #" + tag
+			}
+
+			if feed.Paused {
+				line += " [paused]"
+			}
+
+			if feed.Broken && feed.LastError != nil {
+				line += fmt.Sprintf(" [broken after %d failures: %s]", feed.Failures, *feed.LastError)
+			} else if feed.LastError != nil {
+				line += fmt.Sprintf(" [failing: %s]", *feed.LastError)
+			}
+
+			fmt.Fprintln(w, line)
+		}
+
+		return nil
 	})
+}
+
+/*
+  - Follow a feed by URL. A GitHub repository (such as "gh:org/repo")
+    stands for its releases feed, or with '--commits', its commits
+    feed, and shorthands such as "docker:IMAGE" stand for the feeds
+    gator makes of other sources (see 'expandShorthand'.) Such feeds
+    are added on the spot if nobody has yet.
+*/
+func handlerFollow(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("follow", flag.ContinueOnError)
+	releases := flagSet.Bool("releases", false, "follow a repository's releases (the default)")
+	commits := flagSet.Bool("commits", false, "follow a repository's commits")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'follow' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'follow' command takes a single URL argument")
+	}
+
+	if *releases && *commits {
+		return fmt.Errorf("The 'follow' command takes only one of --releases and --commits")
+	}
+
+	kind := repoReleases
+
+	if *commits {
+		kind = repoCommits
+	}
+
+	url, name, isSource, err := expandRepoSource(args[0], kind)
 
 	if err != nil {
 		return err
 	}
 
-	for _, post := range posts {
-		fmt.Println(post.PublishedAt)
-		fmt.Println(post.Title)
-		fmt.Println(post.Description)
-		fmt.Println()
+	if !isSource {
+		if *releases || *commits {
+			return fmt.Errorf("The --releases and --commits flags only apply to repositories (such as gh:org/repo)")
+		}
+
+		url, name, isSource = expandShorthand(args[0])
 	}
 
-	return nil
+	if strings.HasPrefix(url, "nitter://") && len(state.Config.NitterInstances) == 0 {
+		return fmt.Errorf("Following X accounts needs at least one Nitter instance under 'nitter_instances' in %s", state.ConfigFile)
+	}
+
+	if !isSource {
+		url = args[0]
+	}
+
+	feed, err := state.db.GetFeedByURL(state.ctx, url)
+
+	if err == sql.ErrNoRows && isSource {
+		feed, err = state.db.CreateFeed(state.ctx, database.CreateFeedParams{
+			ID:        ids.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+			Url:       url,
+			UserID:    uuid.NullUUID{UUID: currentUser.ID, Valid: true},
+		})
+
+		if err != nil {
+			return wrapError(err, "Failed to add feed '%s', '%s'", name, url)
+		}
+	}
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No feed with URL %q (use 'addfeed' to add it)", url)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", url)
+	}
+
+	feedInfo, err := state.db.CreateFeedFollow(state.ctx, database.CreateFeedFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    currentUser.ID,
+		FeedID:    feed.ID,
+	})
+
+	result := followResult{
+		User: currentUser.Name,
+		Feed: feed.Name,
+		URL:  feed.Url,
+	}
+
+	// The insertion does nothing if the follow already exists.
+	if err == sql.ErrNoRows {
+		result.AlreadyFollowing = true
+		return output.Message(os.Stdout, state.JSON, result, "User %q is already following %q", currentUser.Name, feed.Name)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to make user %q follow feed %q", currentUser.Name, feed.Name)
+	}
+
+	return output.Message(os.Stdout, state.JSON, result, "Feed name: %q\nUser name: %q", feedInfo.Feedname, feedInfo.Username)
+}
+
+/** The outcome of 'follow' and 'unfollow'. */
+type followResult struct {
+	User             string `json:"user"`
+	Feed             string `json:"feed,omitempty"`
+	URL              string `json:"url"`
+	AlreadyFollowing bool   `json:"already_following,omitempty"`
+}
+
+/** A followed feed, as reported by 'following'. */
+type followedFeed struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	Unread     int64      `json:"unread"`
+	LastPostAt *time.Time `json:"last_post_at"`
+	Folder     string     `json:"folder"`
+	Tags       []string   `json:"tags"`
+}
+
+func newFollowedFeed(info database.GetFeedFollowsForUserRow) followedFeed {
+	feed := followedFeed{
+		ID:     info.FeedID,
+		Name:   info.Feedname,
+		URL:    info.Feedurl,
+		Unread: info.Unread,
+		Folder: info.Folder.String,
+		Tags:   info.Tags,
+	}
+
+	if info.LastPostAt.Valid {
+		feed.LastPostAt = &info.LastPostAt.Time
+	}
+
+	return feed
 }
 
-func scrapeFeeds(state state) error {
-	feedsInfo, err := state.db.GetNextFeedToFetch(context.Background())
+func handlerFollowing(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("following", flag.ContinueOnError)
+	folderName := flagSet.String("folder", "", "only show the feeds in this folder")
+	paging := addListPaging(flagSet)
+
+	args, err := parseFlags(flagSet, args)
 
 	if err != nil {
-		// For us, the absence of a feed isn't an error.
-		if err == sql.ErrNoRows {
-			fmt.Println("<no feeds available at this time>")
-			return nil
-		} else {
-			return fmt.Errorf("Failed to fetch feed %v", feedsInfo)
+		return fmt.Errorf("The 'following' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'following' command takes no arguments")
+	}
+
+	folderID := uuid.NullUUID{}
+
+	if *folderName != "" {
+		folder, err := lookUpFolder(state, currentUser, *folderName)
+
+		if err != nil {
+			return err
+		}
+
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	// The unread counts come from a summary maintained by 'agg'; a
+	// feed followed since its last refresh shows zero for now.
+	feedFollowsInfo, err := state.db.GetFeedFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	followed := make([]followedFeed, 0, len(feedFollowsInfo))
+
+	for _, info := range feedFollowsInfo {
+		if folderID.Valid && info.FolderID != folderID {
+			continue
+		}
+
+		followed = append(followed, newFollowedFeed(info))
+	}
+
+	return printPage(state, paging, followed, func(w io.Writer, followed []followedFeed) error {
+		rows := make([][]string, 0, len(followed))
+
+		for _, feed := range followed {
+			lastPost := "never"
+
+			if feed.LastPostAt != nil {
+				lastPost = feed.LastPostAt.Format(time.DateOnly)
+			}
+
+			rows = append(rows, []string{feed.Name, feed.URL, feed.Folder, strings.Join(feed.Tags, ", "), strconv.FormatInt(feed.Unread, 10), lastPost})
 		}
+
+		return output.Table(w, []string{"FEED", "URL", "FOLDER", "TAGS", "UNREAD", "LAST POST"}, rows)
+	})
+}
+
+func handlerUnfollow(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("unfollow", flag.ContinueOnError)
+	feedID := flagSet.String("id", "", "unfollow the feed with this ID")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'unfollow' command: %v", err)
 	}
 
-	for _, info := range feedsInfo {
-		if err = state.db.MarkFeedFetched(context.Background(), info.ID); err != nil {
-			return fmt.Errorf("Failed to mark as fetched: feed %v", info)
+	var follow database.GetFeedFollowsForUserRow
+
+	switch {
+	case *feedID != "" && len(args) == 0:
+		id, err := uuid.Parse(*feedID)
+
+		if err != nil {
+			return fmt.Errorf("The 'unfollow' command: %q isn't a feed ID", *feedID)
 		}
 
-		rssFeed, err := rss.FetchFeed(context.Background(), info.Url)
+		follow, err = followByFeedID(state, currentUser, id)
 
 		if err != nil {
 			return err
 		}
+	case *feedID == "" && len(args) == 1:
+		follow, err = matchFollow(state, currentUser, args[0])
 
-		for _, rssItem := range rssFeed.Channel.Item {
-			// Parse the provided publication date into a Go time object.
-			pubDate, err := parseRawTime(rssItem.PubDate)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("The command 'unfollow' takes a single URL or name argument, or --id")
+	}
 
-			if err != nil {
-				return err
-			}
+	if numDeleted, err := state.db.DeleteFeedFollow(state.ctx, database.DeleteFeedFollowParams{
+		UserID: currentUser.ID,
+		Url:    follow.Feedurl,
+	}); err != nil {
+		return wrapError(err, "Failed to unfollow feed %q", follow.Feedurl)
+	} else if numDeleted == 0 {
+		return wrapError(ErrNotFound, "User %q isn't following a feed with URL %q", currentUser.Name, follow.Feedurl)
+	}
 
-			fmt.Println(rssItem.Link)
-
-			// Save the current rssItem to the 'posts' table.
-			post, err := state.db.CreatePost(context.Background(), database.CreatePostParams{
-				ID:          uuid.New(),
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
-				Title:       rssItem.Title,
-				Url:         rssItem.Link,
-				Description: rssItem.Description,
-				PublishedAt: pubDate,
-				FeedID:      info.FeedID,
-			})
-
-			if err == sql.ErrNoRows {
-				fmt.Printf("Added post %v\n", post)
-				continue
-			} else {
-				var pqErr *pq.Error
-
-				if errors.As(err, &pqErr) {
-					constraint := pqErr.Constraint
-
-					if !(pqErr.Code == pqerror.UniqueViolation && constraint == "posts_url_key") {
-						return err
-					}
-				}
-			}
+	unfollowed := followResult{User: currentUser.Name, Feed: follow.Feedname, URL: follow.Feedurl}
+
+	return output.Message(os.Stdout, state.JSON, unfollowed, "Unfollowed feed %q (%s)", follow.Feedname, follow.Feedurl)
+}
+
+func handlerBrowse(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("browse", flag.ContinueOnError)
+	groupBy := flagSet.String("group-by", "", "group posts under headings; the only grouping is 'feed'")
+	byAuthors := flagSet.Bool("authors", false, "show posts by followed authors, from any feed")
+	all := flagSet.Bool("all", false, "show read posts as well as unread ones")
+	page := flagSet.Int("page", 0, "show the given page of NUM-POSTS posts, counting from 1")
+	offset := flagSet.Int("offset", 0, "skip this many posts")
+	since := flagSet.Duration("since", 0, "only show posts from within this long ago (such as 72h)")
+	order := flagSet.String("order", "published", "order posts by when they were published, or added (saved by 'agg')")
+	noPaywall := flagSet.Bool("no-paywall", false, "leave out paywalled posts")
+	folderName := flagSet.String("folder", "", "only show posts from feeds in this folder")
+	tag := flagSet.String("tag", "", "only show posts given this tag with 'tag'")
+	maxReadTime := flagSet.Duration("max-read-time", 0, "only show posts taking at most this long to read (such as 5m)")
+	dedupe := flagSet.Bool("dedupe", true, "show only the earliest copy of a post syndicated by several feeds")
+	asOf := flagSet.String("as-of", "", "show posts as they stood at the start of this date (YYYY-MM-DD), read or not as of then")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'browse' command: %v", err)
+	}
+
+	if *groupBy != "" && *groupBy != "feed" {
+		return fmt.Errorf("Can't group posts by %q (the only grouping is 'feed')", *groupBy)
+	}
+
+	if *order != "published" && *order != "added" {
+		return fmt.Errorf("Can't order posts by %q (use published or added)", *order)
+	}
+
+	if *page < 0 || *offset < 0 || *since < 0 || *maxReadTime < 0 {
+		return fmt.Errorf("The 'browse' command's --page, --offset, --since, and --max-read-time can't be negative")
+	}
+
+	if *page > 0 && *offset > 0 {
+		return fmt.Errorf("The 'browse' command takes either --page or --offset, not both")
+	}
+
+	// The unread counts under each feed's heading are today's, so they
+	// can't be shown alongside an earlier river.
+	if *asOf != "" && *groupBy != "" {
+		return fmt.Errorf("The 'browse' command takes either --as-of or --group-by, not both")
+	}
+
+	// The cast is required because it's being used as a LIMIT
+	// parameter for a query.
+	var limit64 int64 = 2
+
+	if len(args) == 1 {
+		limit64, err = strconv.ParseInt(args[0], 10, 32)
+
+		if err != nil {
+			return fmt.Errorf("Can't parse %q as an int\n", args[0])
 		}
+	} else if len(args) > 1 {
+		return fmt.Errorf("Too many args")
 	}
 
-	return nil
+	params := database.GetPostsForUserParams{
+		UserID:        currentUser.ID,
+		UnreadOnly:    !*all,
+		HidePaywalled: *noPaywall,
+		OrderBy:       *order,
+		Dedupe:        *dedupe,
+		Limit:         int32(limit64),
+		Offset:        int32(*offset),
+	}
+
+	if *page > 0 {
+		params.Offset = int32(*page-1) * params.Limit
+	}
+
+	now := time.Now()
+
+	if *asOf != "" {
+		now, err = time.ParseInLocation(time.DateOnly, *asOf, time.Local)
+
+		if err != nil {
+			return fmt.Errorf("Can't parse %q as a date (use YYYY-MM-DD)", *asOf)
+		}
+
+		params.AsOf = sql.NullTime{Time: now, Valid: true}
+	}
+
+	if *since > 0 {
+		params.Since = sql.NullTime{Time: now.Add(-*since), Valid: true}
+	}
+
+	if *folderName != "" {
+		folder, err := lookUpFolder(state, currentUser, *folderName)
+
+		if err != nil {
+			return err
+		}
+
+		params.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	if *tag != "" {
+		params.Tag = sql.NullString{String: strings.ToLower(*tag), Valid: true}
+	}
+
+	if *maxReadTime > 0 {
+		params.MaxReadingSeconds = sql.NullInt32{Int32: int32(*maxReadTime / time.Second), Valid: true}
+	}
+
+	var posts []database.GetPostsForUserRow
+
+	if *byAuthors {
+		posts, err = postsByFollowedAuthors(state, params)
+	} else {
+		posts, err = state.db.GetPostsForUser(state.ctx, params)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch posts for user %q", currentUser.Name)
+	}
+
+	browsed := make([]browsedPost, 0, len(posts))
+
+	for _, post := range posts {
+		browsed = append(browsed, newBrowsedPost(post))
+	}
+
+	return output.Print(os.Stdout, state.JSON, browsed, func(w io.Writer) error {
+		if len(posts) == 0 && !*all {
+			fmt.Fprintln(w, state.catalog.T("browse.none_unread"))
+			return nil
+		}
+
+		if len(posts) == 0 {
+			fmt.Fprintln(w, state.catalog.T("browse.none"))
+			return nil
+		}
+
+		if *groupBy == "feed" {
+			return browseByFeed(w, state, currentUser, posts)
+		}
+
+		for _, post := range posts {
+			printPost(w, state, post)
+		}
+
+		return nil
+	})
+}
+
+/*
+  - A post, as reported by 'browse'. Grouping by feed only affects the
+    human-readable form, since each post names its feed anyway.
+*/
+type browsedPost struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Author      string    `json:"author"`
+	PublishedAt time.Time `json:"published_at"`
+	Feed        string    `json:"feed"`
+	Paywalled   bool      `json:"paywalled"`
+
+	// In seconds, as estimated from the post's content.
+	ReadingTime int `json:"reading_time"`
+}
+
+func newBrowsedPost(post database.GetPostsForUserRow) browsedPost {
+	return browsedPost{
+		ID:          post.ID,
+		Title:       post.Title,
+		URL:         post.Url,
+		Description: post.Description,
+		Author:      post.Author,
+		PublishedAt: post.PublishedAt,
+		Feed:        post.Feedname,
+		Paywalled:   post.Paywalled || post.FeedPaywalled,
+		ReadingTime: int(post.ReadingSeconds),
+	}
 }
 
 /*
-Attempt to parse every RFC layout in the time package.
-Return the first valid time.Time. If there are none, return an error.
+  - Print posts under a heading for each feed. Feeds are ordered by
+    their most recent post, and posts by date within each feed.
 */
-func parseRawTime(timeStr string) (time.Time, error) {
-	layouts := []string{
-		time.RFC822,
-		time.RFC822Z,
-		time.RFC850,
-		time.RFC1123,
-		time.RFC1123Z,
-		time.RFC3339,
-		time.RFC3339Nano,
+func browseByFeed(w io.Writer, state state, currentUser database.User, posts []database.GetPostsForUserRow) error {
+	unreadCounts, err := state.db.GetUnreadCountsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch unread counts for user %q", currentUser.Name)
+	}
+
+	unread := make(map[uuid.UUID]int64, len(unreadCounts))
+
+	for _, count := range unreadCounts {
+		unread[count.FeedID] = count.Unread
+	}
+
+	// Since the posts arrive newest first, the feeds are collected in
+	// order of their newest post.
+	feedIDs := make([]uuid.UUID, 0)
+	postsByFeed := make(map[uuid.UUID][]database.GetPostsForUserRow)
+
+	for _, post := range posts {
+		if _, ok := postsByFeed[post.FeedID]; !ok {
+			feedIDs = append(feedIDs, post.FeedID)
+		}
+
+		postsByFeed[post.FeedID] = append(postsByFeed[post.FeedID], post)
 	}
 
-	for _, layout := range layouts {
-		t, err := time.Parse(layout, timeStr)
+	for _, feedID := range feedIDs {
+		feedPosts := postsByFeed[feedID]
+
+		fmt.Fprintf(w, "== %s (%s) ==\n\n", feedPosts[0].Feedname, state.catalog.T("browse.unread", unread[feedID]))
 
-		if err == nil {
-			return t, nil
+		for _, post := range feedPosts {
+			printPost(w, state, post)
 		}
 	}
 
-	// Construct a zero-time, to return as a degenerate value.
-	var zero time.Time
-	return zero, fmt.Errorf("Can't get a valid time from %q; maybe add this layout?", timeStr)
+	return nil
+}
+
+func printPost(w io.Writer, state state, post database.GetPostsForUserRow) {
+	fmt.Fprintln(w, state.catalog.T("browse.published", state.catalog.FormatDate(post.PublishedAt)))
+
+	title := post.Title
+
+	if post.Paywalled || post.FeedPaywalled {
+		title = "🔒 " + title
+	}
+
+	if marker := state.Config.FeedMarkers[post.Feedname]; marker != "" {
+		fmt.Fprintf(w, "%s %s\n", marker, title)
+	} else {
+		fmt.Fprintln(w, title)
+	}
+
+	if post.Author != "" {
+		fmt.Fprintln(w, state.catalog.T("browse.author", post.Author))
+	}
+
+	if post.ReadingSeconds > 0 {
+		fmt.Fprintln(w, state.catalog.T("browse.reading_time", readtime.Minutes(time.Duration(post.ReadingSeconds)*time.Second)))
+	}
+
+	fmt.Fprintln(w, post.Description)
+	fmt.Fprintln(w, state.catalog.T("browse.id", post.ID))
+	fmt.Fprintln(w)
 }
 
 /*
@@ -546,37 +1763,107 @@ func parseRawTime(timeStr string) (time.Time, error) {
     with the currently logged-in user.
 
     Essentially, this function converts a given cliLoggedInCommand to
-    a cliCommand usable from the main package.
+    a cliCommand usable from the main package. The user is only looked
+    up when the command is actually invoked.
 */
-func middlewareWrapper(s state, command cliLoggedInCommand) cliCommand {
-	currentUser, err := s.db.GetUser(context.Background(), s.Config.CurrentUserName)
+func middlewareWrapper(command cliLoggedInCommand) cliCommand {
+	return func(s state, args []string) error {
+		currentUser, err := loggedInUser(s)
 
-	if err != nil {
-		// In case of error, the best we can do is return a dummy
-		// function which, when invoked, will return the actual error.
-		return func(_ state, _ []string) error {
-			return fmt.Errorf("Failed to get user inside middleware wrapper function")
+		if err != nil {
+			return err
 		}
-	}
 
-	return func(s state, args []string) error {
 		return command(s, args, currentUser)
 	}
 }
 
-func InitMiddleware(s state) {
+/*
+  - Return the user of the current session. Every way of not being
+    logged in yields an error wrapping ErrNotLoggedIn, suggesting what
+    to do about it.
+*/
+func loggedInUser(s state) (database.User, error) {
+	username := s.Config.CurrentUserName
+
+	if username == "" {
+		return database.User{}, wrapError(ErrNotLoggedIn, "Not logged in (use 'login USERNAME', or 'register USERNAME' to create a new user)")
+	}
+
+	user, err := s.db.GetUser(s.ctx, username)
+
+	if err == sql.ErrNoRows {
+		return database.User{}, wrapError(ErrNotLoggedIn, "Not logged in: user '%s' no longer exists (use 'login USERNAME' to switch users)", username)
+	}
+
+	if err != nil {
+		return database.User{}, wrapError(err, "Failed to look up the logged-in user '%s'", username)
+	}
+
+	if err = checkSession(s, user); err != nil {
+		return database.User{}, err
+	}
+
+	return user, nil
+}
+
+func InitMiddleware() {
 	commandRegistry["login"] = handlerLogin
+	commandRegistry["logout"] = handlerLogout
 	commandRegistry["register"] = handlerRegister
 	commandRegistry["reset"] = handlerReset
 	commandRegistry["users"] = handlerUsers
 	commandRegistry["agg"] = handlerAgg
 	commandRegistry["feeds"] = handlerFeeds
+	commandRegistry["lag"] = handlerLag
+	commandRegistry["fsck"] = handlerFsck
+	commandRegistry["partition"] = handlerPartition
+	commandRegistry["worker"] = handlerWorker
+	commandRegistry["migrate"] = handlerMigrate
+	commandRegistry["setup"] = handlerSetup
+	commandRegistry["init"] = handlerInit
+	commandRegistry["check"] = handlerCheck
 
 	// The following commands are defined in terms of post-login
 	// middleware wrapper calls.
-	commandRegistry["addfeed"] = middlewareWrapper(s, handlerAddFeed)
-	commandRegistry["follow"] = middlewareWrapper(s, handlerFollow)
-	commandRegistry["following"] = middlewareWrapper(s, handlerFollowing)
-	commandRegistry["unfollow"] = middlewareWrapper(s, handlerUnfollow)
-	commandRegistry["browse"] = middlewareWrapper(s, handlerBrowse)
+	commandRegistry["addfeed"] = middlewareWrapper(handlerAddFeed)
+	commandRegistry["follow"] = middlewareWrapper(handlerFollow)
+	commandRegistry["following"] = middlewareWrapper(handlerFollowing)
+	commandRegistry["unfollow"] = middlewareWrapper(handlerUnfollow)
+	commandRegistry["browse"] = middlewareWrapper(handlerBrowse)
+	commandRegistry["importopml"] = middlewareWrapper(handlerImportOPML)
+	commandRegistry["summary"] = middlewareWrapper(handlerSummary)
+	commandRegistry["calendar"] = handlerCalendar
+	commandRegistry["checklinks"] = middlewareWrapper(handlerCheckLinks)
+	commandRegistry["archive"] = middlewareWrapper(handlerArchive)
+	commandRegistry["related"] = middlewareWrapper(handlerRelated)
+	commandRegistry["diff"] = handlerDiff
+	commandRegistry["follow-author"] = middlewareWrapper(handlerFollowAuthor)
+	commandRegistry["unfollow-author"] = middlewareWrapper(handlerUnfollowAuthor)
+	commandRegistry["authors"] = middlewareWrapper(handlerAuthors)
+	commandRegistry["setlimit"] = handlerSetLimit
+	commandRegistry["export-state"] = middlewareWrapper(handlerExportState)
+	commandRegistry["import-state"] = middlewareWrapper(handlerImportState)
+	commandRegistry["mergefeeds"] = handlerMergeFeeds
+	commandRegistry["pause"] = handlerPause
+	commandRegistry["resume"] = handlerResume
+	commandRegistry["serve"] = handlerServe
+	commandRegistry["open"] = handlerOpen
+	commandRegistry["reading-stats"] = middlewareWrapper(handlerReadingStats)
+	commandRegistry["run"] = handlerRun
+	commandRegistry["bundle"] = middlewareWrapper(handlerBundle)
+	commandRegistry["crosspost"] = handlerCrosspost
+	commandRegistry["read"] = middlewareWrapper(handlerRead)
+	commandRegistry["unread"] = middlewareWrapper(handlerUnread)
+	commandRegistry["search"] = middlewareWrapper(handlerSearch)
+	commandRegistry["feedconfig"] = handlerFeedConfig
+	commandRegistry["paywall"] = handlerPaywall
+	commandRegistry["folder"] = middlewareWrapper(handlerFolder)
+	commandRegistry["tag"] = middlewareWrapper(handlerTag)
+	commandRegistry["tts"] = handlerTTS
+	commandRegistry["star"] = middlewareWrapper(handlerStar)
+	commandRegistry["unstar"] = middlewareWrapper(handlerUnstar)
+	commandRegistry["starred"] = middlewareWrapper(handlerStarred)
+	commandRegistry["changes"] = middlewareWrapper(handlerChanges)
+	commandRegistry["apikey"] = middlewareWrapper(handlerAPIKey)
 }
@@ -0,0 +1,119 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/crosspost"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"os"
+	"strings"
+)
+
+/** The message 'crosspost' sends when the config doesn't give one. */
+const defaultCrosspostTemplate = "Reading: {title} {url}"
+
+/** The accounts 'crosspost' can share posts to, and what it says. */
+type CrosspostConfig struct {
+	// The message to post, in which "{title}", "{url}", and
+	// "{author}" are replaced by those of the post being shared.
+	Template string `json:"template,omitempty"`
+
+	Mastodon *crosspost.MastodonAccount `json:"mastodon,omitempty"`
+	Bluesky  *crosspost.BlueskyAccount  `json:"bluesky,omitempty"`
+}
+
+/*
+  - Share the given post to Mastodon or Bluesky, using the account
+    configured under 'crosspost' in the config file.
+*/
+func handlerCrosspost(state state, args []string) error {
+	flagSet := flag.NewFlagSet("crosspost", flag.ContinueOnError)
+	to := flagSet.String("to", "", "where to share the post: mastodon or bluesky")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'crosspost' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'crosspost' command takes a single POST-ID argument")
+	}
+
+	postID, err := uuid.Parse(args[0])
+
+	if err != nil {
+		return fmt.Errorf("Can't parse %q as a post ID", args[0])
+	}
+
+	accounts := state.Config.Crosspost
+
+	if accounts == nil {
+		accounts = &CrosspostConfig{}
+	}
+
+	post, err := state.db.GetPostByID(state.ctx, postID)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No post with ID %s", postID)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up post %s", postID)
+	}
+
+	template := accounts.Template
+
+	if template == "" {
+		template = defaultCrosspostTemplate
+	}
+
+	link := postLink(state, post.ID, post.Url)
+	text := strings.NewReplacer(
+		"{title}", post.Title,
+		"{url}", link,
+		"{author}", post.Author,
+	).Replace(template)
+
+	var shared string
+
+	switch *to {
+	case "mastodon":
+		if accounts.Mastodon == nil {
+			return fmt.Errorf("No Mastodon account is configured (set 'crosspost.mastodon' in the config file)")
+		}
+
+		shared, err = crosspost.Mastodon(state.ctx, *accounts.Mastodon, text)
+	case "bluesky":
+		if accounts.Bluesky == nil {
+			return fmt.Errorf("No Bluesky account is configured (set 'crosspost.bluesky' in the config file)")
+		}
+
+		shared, err = crosspost.Bluesky(state.ctx, *accounts.Bluesky, text, link)
+	case "":
+		return fmt.Errorf("The 'crosspost' command requires --to mastodon or --to bluesky")
+	default:
+		return fmt.Errorf("Can't crosspost to %q (use mastodon or bluesky)", *to)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to share post %q to %s", post.Title, *to)
+	}
+
+	result := crosspostResult{
+		Title:  post.Title,
+		To:     *to,
+		Shared: shared,
+	}
+
+	return output.Message(os.Stdout, state.JSON, result, "Shared %q: %s", post.Title, shared)
+}
+
+/** The outcome of 'crosspost'. */
+type crosspostResult struct {
+	Title  string `json:"title"`
+	To     string `json:"to"`
+	Shared string `json:"shared"`
+}
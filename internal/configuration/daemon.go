@@ -0,0 +1,83 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+/*
+  - Return a context that's canceled on the first SIGINT or SIGTERM,
+    for long-running commands to stop taking on new work, while
+    'state.ctx' (and so whatever is in flight) carries on. A second
+    signal gets the default treatment, killing the process at once.
+    The returned function must be called once the command finishes.
+*/
+func notifyShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	shutdown, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			fmt.Fprintf(os.Stderr, "Received %s; finishing the work in flight (signal again to quit at once)\n", sig)
+			cancel()
+		case <-shutdown.Done():
+		}
+	}()
+
+	return shutdown, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+/*
+  - Write the current process's ID to the given file, refusing if it
+    names another process that's still running. A pidfile left behind
+    by a process that's since died is replaced. The returned function
+    removes the pidfile again.
+*/
+func writePidfile(path string) (func(), error) {
+	if contents, err := os.ReadFile(path); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+
+		if err == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("Pidfile %q names running process %d", path, pid)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, wrapError(err, "Failed to read pidfile %q", path)
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return nil, wrapError(err, "Failed to write pidfile %q", path)
+	}
+
+	return func() {
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove pidfile %q: %v\n", path, err)
+		}
+	}, nil
+}
+
+/** Whether a process with the given ID exists. */
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+
+	if err != nil {
+		return false
+	}
+
+	// Signal 0 checks for the process without disturbing it.
+	err = process.Signal(syscall.Signal(0))
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,117 @@
+package configuration
+
+import (
+	"context"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/i18n"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/*
+  - The environment variable giving a scratch database for the tests
+    and benchmarks which need one; without it, they're skipped. The
+    database is brought up to date, and what the tests add to it is
+    removed afterwards.
+*/
+const testDbURLVariable = "GATOR_TEST_DB_URL"
+
+/** A state connected to the scratch database, with a config file of its own. */
+func testState(tb testing.TB) state {
+	tb.Helper()
+
+	dbURL := os.Getenv(testDbURLVariable)
+
+	if dbURL == "" {
+		tb.Skipf("%s isn't set", testDbURLVariable)
+	}
+
+	catalog, err := i18n.Load("en")
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	state := state{
+		ConfigFile: filepath.Join(tb.TempDir(), "config.json"),
+		Config:     &Config{DbURL: dbURL},
+		catalog:    catalog,
+		ctx:        context.Background(),
+	}
+
+	if err = Connect(&state); err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() { state.conn.Close() })
+
+	if err = MigrateIfNeeded(state); err != nil {
+		tb.Fatal(err)
+	}
+
+	return state
+}
+
+/** A user of the scratch database, deleted when the test is over. */
+func testUser(tb testing.TB, state state) database.User {
+	tb.Helper()
+
+	user, err := state.db.CreateUser(state.ctx, database.CreateUserParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      "test-" + ids.New().String(),
+	})
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() { deleteTestRows(tb, state, "users", user.ID.String()) })
+
+	return user
+}
+
+/** A feed followed by 'user', deleted (with its posts) when the test is over. */
+func testFeed(tb testing.TB, state state, user database.User) database.Feed {
+	tb.Helper()
+
+	id := ids.New()
+
+	feed, err := state.db.CreateFeed(state.ctx, database.CreateFeedParams{
+		ID:        id,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name:      "Test feed " + id.String(),
+		Url:       "https://example.com/" + id.String() + "/feed.xml",
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() { deleteTestRows(tb, state, "feeds", feed.ID.String()) })
+
+	if _, err = state.db.CreateFeedFollow(state.ctx, database.CreateFeedFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	}); err != nil {
+		tb.Fatal(err)
+	}
+
+	return feed
+}
+
+func deleteTestRows(tb testing.TB, state state, table string, id string) {
+	if _, err := state.conn.ExecContext(context.Background(), "DELETE FROM "+table+" WHERE id = $1", id); err != nil {
+		tb.Errorf("Failed to clean up %s row %s: %v", table, id, err)
+	}
+}
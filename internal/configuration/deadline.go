@@ -0,0 +1,95 @@
+package configuration
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+  - The deadline of the running command, set up by 'PrepareContext'.
+    Unlike a context's own deadline, it stands still while the command
+    waits for its user to answer a prompt, so that however long they
+    take, the command still gets the whole of 'state.Timeout' for its
+    own work. Each process runs only the one command under it.
+*/
+var commandDeadline struct {
+	mu        sync.Mutex
+	timer     *time.Timer
+	expire    func()
+	remaining time.Duration
+	started   time.Time
+	expired   bool
+}
+
+/** Start the command's deadline, canceling its context once it passes. */
+func startDeadline(timeout time.Duration, cancel context.CancelCauseFunc) {
+	commandDeadline.mu.Lock()
+	defer commandDeadline.mu.Unlock()
+
+	commandDeadline.expire = func() {
+		commandDeadline.mu.Lock()
+		commandDeadline.expired = true
+		commandDeadline.mu.Unlock()
+
+		cancel(context.DeadlineExceeded)
+	}
+
+	commandDeadline.remaining = timeout
+	commandDeadline.started = time.Now()
+	commandDeadline.timer = time.AfterFunc(timeout, commandDeadline.expire)
+}
+
+/** Stop the command's deadline for good, once the command is done. */
+func stopDeadline() {
+	commandDeadline.mu.Lock()
+	defer commandDeadline.mu.Unlock()
+
+	if commandDeadline.timer != nil {
+		commandDeadline.timer.Stop()
+		commandDeadline.timer = nil
+	}
+}
+
+/*
+  - Stop the clock on the command's deadline while waiting for the
+    user, returning the function which starts it again with whatever
+    time was left. Without a deadline, there's nothing to stop.
+*/
+func pauseDeadline() func() {
+	commandDeadline.mu.Lock()
+	defer commandDeadline.mu.Unlock()
+
+	timer := commandDeadline.timer
+
+	if timer == nil || !timer.Stop() {
+		return func() {}
+	}
+
+	commandDeadline.remaining -= time.Since(commandDeadline.started)
+
+	return func() {
+		commandDeadline.mu.Lock()
+		defer commandDeadline.mu.Unlock()
+
+		// The command may have finished in the meantime.
+		if commandDeadline.timer != timer {
+			return
+		}
+
+		commandDeadline.started = time.Now()
+		commandDeadline.timer = time.AfterFunc(commandDeadline.remaining, commandDeadline.expire)
+	}
+}
+
+/*
+  - Whether the command's deadline passed. Work cut short by it
+    reports its context as canceled, rather than as having run out of
+    time, since the deadline isn't the context's own.
+*/
+func DeadlineExpired() bool {
+	commandDeadline.mu.Lock()
+	defer commandDeadline.mu.Unlock()
+
+	return commandDeadline.expired
+}
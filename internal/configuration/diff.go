@@ -0,0 +1,173 @@
+package configuration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"time"
+)
+
+/** A feed item, as recorded in a feed's snapshot. */
+type snapshotItem struct {
+	Title string `json:"title"`
+	Link  string `json:"link"`
+}
+
+/** A title change, as reported by 'diff'. */
+type retitledItem struct {
+	Link     string `json:"link"`
+	OldTitle string `json:"old_title"`
+	NewTitle string `json:"new_title"`
+}
+
+/** How a feed changed between its last two fetches. */
+type feedDiff struct {
+	Previous time.Time      `json:"previous_fetch"`
+	Latest   time.Time      `json:"latest_fetch"`
+	Added    []snapshotItem `json:"added"`
+	Removed  []snapshotItem `json:"removed"`
+	Retitled []retitledItem `json:"retitled"`
+}
+
+/*
+  - Show how the given feed changed between its last two fetches:
+    which items appeared, which disappeared, and which changed title.
+    This helps explain feeds which seem to "lose" posts.
+*/
+func handlerDiff(state state, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'diff' command takes a single FEED-URL argument")
+	}
+
+	feed, err := state.db.GetFeedByURL(state.ctx, args[0])
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No feed with URL %q (use 'addfeed' to add it)", args[0])
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", args[0])
+	}
+
+	snapshots, err := state.db.GetLatestFeedSnapshots(state.ctx, feed.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch snapshots of feed %q", feed.Name)
+	}
+
+	if len(snapshots) < 2 {
+		return fmt.Errorf("Feed %q needs to have been fetched at least twice to compare fetches", feed.Name)
+	}
+
+	// The snapshots come newest first.
+	var latest, previous []snapshotItem
+
+	if err = json.Unmarshal(snapshots[0].Items, &latest); err != nil {
+		return wrapError(err, "Corrupt snapshot of feed %q", feed.Name)
+	}
+
+	if err = json.Unmarshal(snapshots[1].Items, &previous); err != nil {
+		return wrapError(err, "Corrupt snapshot of feed %q", feed.Name)
+	}
+
+	diff := diffSnapshots(previous, latest)
+	diff.Previous = snapshots[1].FetchedAt
+	diff.Latest = snapshots[0].FetchedAt
+
+	return output.Print(os.Stdout, state.JSON, diff, func(w io.Writer) error {
+		fmt.Fprintf(w, "Changes in %q between %s and %s:\n",
+			feed.Name,
+			diff.Previous.Format(time.DateTime),
+			diff.Latest.Format(time.DateTime))
+
+		if len(diff.Added)+len(diff.Removed)+len(diff.Retitled) == 0 {
+			fmt.Fprintln(w, "No changes")
+			return nil
+		}
+
+		for _, item := range diff.Added {
+			fmt.Fprintf(w, "+ %q %s\n", item.Title, item.Link)
+		}
+
+		for _, item := range diff.Removed {
+			fmt.Fprintf(w, "- %q %s\n", item.Title, item.Link)
+		}
+
+		for _, item := range diff.Retitled {
+			fmt.Fprintf(w, "~ %q -> %q %s\n", item.OldTitle, item.NewTitle, item.Link)
+		}
+
+		return nil
+	})
+}
+
+/** Compare two snapshots of a feed, matching items by link. */
+func diffSnapshots(previous []snapshotItem, latest []snapshotItem) feedDiff {
+	diff := feedDiff{
+		Added:    make([]snapshotItem, 0),
+		Removed:  make([]snapshotItem, 0),
+		Retitled: make([]retitledItem, 0),
+	}
+
+	previousTitles := make(map[string]string, len(previous))
+
+	for _, item := range previous {
+		previousTitles[item.Link] = item.Title
+	}
+
+	latestLinks := make(map[string]bool, len(latest))
+
+	for _, item := range latest {
+		latestLinks[item.Link] = true
+
+		oldTitle, ok := previousTitles[item.Link]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, item)
+		case oldTitle != item.Title:
+			diff.Retitled = append(diff.Retitled, retitledItem{
+				Link:     item.Link,
+				OldTitle: oldTitle,
+				NewTitle: item.Title,
+			})
+		}
+	}
+
+	for _, item := range previous {
+		if !latestLinks[item.Link] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	return diff
+}
+
+/** Record a feed's items as of this fetch, keeping only the last two. */
+func saveSnapshot(state state, feed database.Feed, items []snapshotItem) error {
+	encoded, err := json.Marshal(items)
+
+	if err != nil {
+		return err
+	}
+
+	if err = state.db.CreateFeedSnapshot(state.ctx, database.CreateFeedSnapshotParams{
+		ID:        ids.New(),
+		FeedID:    feed.ID,
+		FetchedAt: time.Now(),
+		Items:     encoded,
+	}); err != nil {
+		return wrapError(err, "Failed to save snapshot of feed %q", feed.Url)
+	}
+
+	if err = state.db.PruneFeedSnapshots(state.ctx, feed.ID); err != nil {
+		return wrapError(err, "Failed to prune snapshots of feed %q", feed.Url)
+	}
+
+	return nil
+}
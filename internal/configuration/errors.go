@@ -0,0 +1,57 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+  - Sentinel errors, for callers that need to tell why a command failed
+    (test with errors.Is.)
+*/
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrNotLoggedIn   = errors.New("not logged in")
+	ErrWrongPassword = errors.New("wrong password")
+)
+
+/*
+  - An error carrying a user-facing message, along with the underlying
+    cause. Only the message is shown by default; the cause is kept
+    around for '--verbose' output and for errors.Is/errors.As.
+*/
+type commandError struct {
+	message string
+	cause   error
+}
+
+func (err *commandError) Error() string {
+	return err.message
+}
+
+func (err *commandError) Unwrap() error {
+	return err.cause
+}
+
+/** Return an error with the given message, wrapping 'cause'. */
+func wrapError(cause error, format string, args ...any) error {
+	return &commandError{
+		message: fmt.Sprintf(format, args...),
+		cause:   cause,
+	}
+}
+
+/*
+  - Return the chain of causes underlying 'err', outermost first, not
+    including 'err' itself.
+*/
+func Causes(err error) []error {
+	causes := make([]error, 0)
+
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		causes = append(causes, cause)
+	}
+
+	return causes
+}
@@ -0,0 +1,591 @@
+package configuration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/progress"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+/** The version of the format written by 'export-state'. */
+const stateVersion = 1
+
+/** How many kinds of state 'export-state' gathers, for its progress. */
+const exportedSections = 8
+
+/*
+  - A user's state, as written by 'export-state'. Everything is keyed
+    by URL or name rather than database ID, so that it can be imported
+    into a rebuilt database or another instance.
+*/
+type exportedState struct {
+	Version  int               `json:"version"`
+	User     string            `json:"user"`
+	Feeds    []exportedFeed    `json:"feeds"`
+	Authors  []string          `json:"authors"`
+	Archives []exportedArchive `json:"archives"`
+
+	// Absent from files written before there was read state.
+	Reads []exportedRead `json:"reads,omitempty"`
+
+	// Likewise for starred posts.
+	Starred []exportedStar `json:"starred,omitempty"`
+
+	// Likewise for folders, post tags, and posts sent in digests.
+	Folders  []string         `json:"folders,omitempty"`
+	Tags     []exportedTag    `json:"tags,omitempty"`
+	Digested []exportedDigest `json:"digested,omitempty"`
+}
+
+/*
+  - A followed feed. 'CategoryTags' is only given for feeds with
+    settings of their own, since otherwise it's on by default.
+*/
+type exportedFeed struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	Folder       string `json:"folder,omitempty"`
+	CategoryTags *bool  `json:"category_tags,omitempty"`
+}
+
+type exportedArchive struct {
+	URL        string    `json:"url"`
+	Snapshot   string    `json:"snapshot"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+type exportedRead struct {
+	URL    string    `json:"url"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+type exportedTag struct {
+	URL       string    `json:"url"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedDigest struct {
+	URL        string    `json:"url"`
+	DigestedAt time.Time `json:"digested_at"`
+}
+
+/** A starred post, kept whole, since the post itself may be long gone. */
+type exportedStar struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Author      string    `json:"author"`
+	Feed        string    `json:"feed"`
+	PublishedAt time.Time `json:"published_at"`
+	StarredAt   time.Time `json:"starred_at"`
+}
+
+/** Write the current user's state as a JSON document. */
+func handlerExportState(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("export-state", flag.ContinueOnError)
+	outputFlag := flagSet.String("output", "", "write the state to this file instead of standard output")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'export-state' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'export-state' command takes no arguments")
+	}
+
+	exported := exportedState{
+		Version:  stateVersion,
+		User:     currentUser.Name,
+		Feeds:    make([]exportedFeed, 0),
+		Authors:  make([]string, 0),
+		Archives: make([]exportedArchive, 0),
+		Reads:    make([]exportedRead, 0),
+		Starred:  make([]exportedStar, 0),
+		Folders:  make([]string, 0),
+		Tags:     make([]exportedTag, 0),
+		Digested: make([]exportedDigest, 0),
+	}
+
+	// The state itself may be going to standard output.
+	bar := progress.New(os.Stderr, "Exporting", exportedSections)
+	defer bar.Finish()
+
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	for _, follow := range follows {
+		feed := exportedFeed{Name: follow.Feedname, URL: follow.Feedurl, Folder: follow.Folder.String}
+		settings, err := state.db.GetFeedSettings(state.ctx, follow.FeedID)
+
+		if err == nil {
+			feed.CategoryTags = &settings.CategoryTags
+		} else if err != sql.ErrNoRows {
+			return wrapError(err, "Failed to fetch the settings of feed %q", follow.Feedname)
+		}
+
+		exported.Feeds = append(exported.Feeds, feed)
+	}
+
+	bar.Increment()
+
+	// Folders are listed separately, so that empty ones are kept too.
+	folders, err := state.db.ListFolders(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the folders of user %q", currentUser.Name)
+	}
+
+	for _, folder := range folders {
+		exported.Folders = append(exported.Folders, folder.Name)
+	}
+
+	bar.Increment()
+
+	authors, err := state.db.GetAuthorFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the authors followed by user %q", currentUser.Name)
+	}
+
+	for _, author := range authors {
+		exported.Authors = append(exported.Authors, author.Author)
+	}
+
+	bar.Increment()
+
+	archives, err := state.db.GetPostArchivesForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch archived posts for user %q", currentUser.Name)
+	}
+
+	for _, archive := range archives {
+		exported.Archives = append(exported.Archives, exportedArchive{
+			URL:        archive.Url,
+			Snapshot:   archive.SnapshotUrl,
+			ArchivedAt: archive.ArchivedAt,
+		})
+	}
+
+	bar.Increment()
+
+	reads, err := state.db.GetReadPostsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the posts read by user %q", currentUser.Name)
+	}
+
+	for _, read := range reads {
+		exported.Reads = append(exported.Reads, exportedRead{URL: read.Url, ReadAt: read.ReadAt})
+	}
+
+	bar.Increment()
+
+	starred, err := state.db.GetStarredPosts(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the starred posts of user %q", currentUser.Name)
+	}
+
+	for _, star := range starred {
+		exported.Starred = append(exported.Starred, exportedStar{
+			URL:         star.Url,
+			Title:       star.Title,
+			Description: star.Description,
+			Author:      star.Author,
+			Feed:        star.FeedName,
+			PublishedAt: star.PublishedAt,
+			StarredAt:   star.StarredAt,
+		})
+	}
+
+	bar.Increment()
+
+	tags, err := state.db.GetTaggedPostsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the post tags of user %q", currentUser.Name)
+	}
+
+	for _, tag := range tags {
+		exported.Tags = append(exported.Tags, exportedTag{URL: tag.Url, Tag: tag.Tag, CreatedAt: tag.CreatedAt})
+	}
+
+	bar.Increment()
+
+	digested, err := state.db.GetDigestedPostsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the posts sent in digests to user %q", currentUser.Name)
+	}
+
+	for _, entry := range digested {
+		exported.Digested = append(exported.Digested, exportedDigest{URL: entry.Url, DigestedAt: entry.DigestedAt})
+	}
+
+	bar.Increment()
+
+	var out io.Writer = os.Stdout
+
+	if *outputFlag != "" {
+		file, err := os.Create(*outputFlag)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	return output.JSON(out, exported)
+}
+
+/*
+  - Restore state written by 'export-state' for the current user.
+    Anything already present is left alone, so importing the same
+    file twice is harmless.
+*/
+func handlerImportState(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'import-state' command takes a single FILE argument")
+	}
+
+	file, err := os.Open(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var imported exportedState
+
+	if err = json.NewDecoder(file).Decode(&imported); err != nil {
+		return wrapError(err, "Can't parse %q as exported state", args[0])
+	}
+
+	if imported.Version != stateVersion {
+		return fmt.Errorf("Unsupported state version %d (expected %d)", imported.Version, stateVersion)
+	}
+
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	followed := make(map[uuid.UUID]bool, len(follows))
+
+	for _, follow := range follows {
+		followed[follow.FeedID] = true
+	}
+
+	for _, feed := range imported.Feeds {
+		if err = importFeed(state.ctx, state, feed.Name, feed.URL, currentUser, followed); err != nil {
+			return err
+		}
+	}
+
+	for _, author := range imported.Authors {
+		if _, err = state.db.CreateAuthorFollow(state.ctx, database.CreateAuthorFollowParams{
+			ID:        ids.New(),
+			CreatedAt: time.Now(),
+			UserID:    currentUser.ID,
+			Author:    author,
+		}); err != nil && err != sql.ErrNoRows {
+			return wrapError(err, "Failed to make user %q follow author %q", currentUser.Name, author)
+		}
+	}
+
+	for _, archive := range imported.Archives {
+		if _, err = state.db.SavePostArchive(state.ctx, database.SavePostArchiveParams{
+			Url:         archive.URL,
+			SnapshotUrl: archive.Snapshot,
+			ArchivedAt:  archive.ArchivedAt,
+		}); err != nil {
+			return wrapError(err, "Failed to record the snapshot of %q", archive.URL)
+		}
+	}
+
+	if err = importFolders(state, currentUser, imported, follows); err != nil {
+		return err
+	}
+
+	for _, feed := range imported.Feeds {
+		if feed.CategoryTags == nil {
+			continue
+		}
+
+		if err = importCategoryTags(state, feed.URL, *feed.CategoryTags); err != nil {
+			return err
+		}
+	}
+
+	// Keep the progress indicator out of the way of JSON output.
+	var progressOut io.Writer = os.Stdout
+
+	if state.JSON {
+		progressOut = os.Stderr
+	}
+
+	// Posts which haven't been fetched here yet can't be marked, so
+	// they're merely counted.
+	result := importedCounts{
+		Feeds:    len(imported.Feeds),
+		Folders:  len(imported.Folders),
+		Authors:  len(imported.Authors),
+		Archives: len(imported.Archives),
+		Reads:    len(imported.Reads),
+		Starred:  len(imported.Starred),
+		Tags:     len(imported.Tags),
+		Digested: len(imported.Digested),
+	}
+
+	bar := progress.New(progressOut, "Restoring posts", len(imported.Reads)+len(imported.Tags)+len(imported.Digested))
+
+	for _, read := range imported.Reads {
+		postID, err := lookUpImportedPost(state, read.URL)
+
+		if err == nil && postID.Valid {
+			err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+				UserID: currentUser.ID,
+				PostID: postID.UUID,
+				ReadAt: read.ReadAt,
+			})
+
+			result.Marked++
+		}
+
+		if err != nil {
+			bar.Finish()
+			return wrapError(err, "Failed to mark post %q as read", read.URL)
+		}
+
+		bar.Increment()
+	}
+
+	for _, tag := range imported.Tags {
+		postID, err := lookUpImportedPost(state, tag.URL)
+
+		if err == nil && postID.Valid {
+			err = state.db.AddPostTag(state.ctx, database.AddPostTagParams{
+				UserID:    currentUser.ID,
+				PostID:    postID.UUID,
+				Tag:       tag.Tag,
+				CreatedAt: tag.CreatedAt,
+			})
+
+			result.Tagged++
+		}
+
+		if err != nil {
+			bar.Finish()
+			return wrapError(err, "Failed to tag post %q as %q", tag.URL, tag.Tag)
+		}
+
+		bar.Increment()
+	}
+
+	for _, entry := range imported.Digested {
+		postID, err := lookUpImportedPost(state, entry.URL)
+
+		if err == nil && postID.Valid {
+			err = state.db.MarkPostsDigested(state.ctx, database.MarkPostsDigestedParams{
+				UserID:     currentUser.ID,
+				DigestedAt: entry.DigestedAt,
+				PostIds:    []uuid.UUID{postID.UUID},
+			})
+
+			result.MarkedDigested++
+		}
+
+		if err != nil {
+			bar.Finish()
+			return wrapError(err, "Failed to record post %q as sent in a digest", entry.URL)
+		}
+
+		bar.Increment()
+	}
+
+	bar.Finish()
+
+	// Unlike read state, a star doesn't need its post to be here.
+	for _, star := range imported.Starred {
+		postID, err := lookUpImportedPost(state, star.URL)
+
+		if err != nil {
+			return wrapError(err, "Failed to look up post %q", star.URL)
+		}
+
+		if _, err = state.db.StarPost(state.ctx, database.StarPostParams{
+			UserID:      currentUser.ID,
+			PostID:      postID,
+			Url:         star.URL,
+			Title:       star.Title,
+			Description: star.Description,
+			Author:      star.Author,
+			FeedName:    star.Feed,
+			PublishedAt: star.PublishedAt,
+			StarredAt:   star.StarredAt,
+		}); err != nil {
+			return wrapError(err, "Failed to star post %q", star.URL)
+		}
+	}
+
+	return output.Print(os.Stdout, state.JSON, result, func(w io.Writer) error {
+		fmt.Fprintf(w, "Imported %d feeds, %d folders, %d authors, %d archived posts, and %d starred posts\n", result.Feeds, result.Folders, result.Authors, result.Archives, result.Starred)
+
+		if result.Reads+result.Tags+result.Digested > 0 {
+			fmt.Fprintf(w, "Restored %d of %d read posts, %d of %d post tags, and %d of %d posts sent in digests (run 'agg' and import again for the rest)\n",
+				result.Marked, result.Reads, result.Tagged, result.Tags, result.MarkedDigested, result.Digested)
+		}
+
+		return nil
+	})
+}
+
+/*
+  - Create the imported folders the user doesn't have yet, and file
+    each imported feed under its folder, unless the user has already
+    filed it elsewhere. 'follows' are the user's follows from before
+    the import.
+*/
+func importFolders(state state, currentUser database.User, imported exportedState, follows []database.GetFeedFollowsForUserRow) error {
+	folderIDs := make(map[string]uuid.UUID)
+
+	// Files written by older versions may name folders only in feeds.
+	names := slices.Clone(imported.Folders)
+
+	for _, feed := range imported.Feeds {
+		if feed.Folder != "" {
+			names = append(names, feed.Folder)
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := folderIDs[name]; ok {
+			continue
+		}
+
+		folder, err := state.db.GetFolder(state.ctx, database.GetFolderParams{UserID: currentUser.ID, Name: name})
+
+		if err == sql.ErrNoRows {
+			folder, err = state.db.CreateFolder(state.ctx, database.CreateFolderParams{
+				ID:        ids.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				UserID:    currentUser.ID,
+				Name:      name,
+			})
+		}
+
+		if err != nil {
+			return wrapError(err, "Failed to create folder %q", name)
+		}
+
+		folderIDs[name] = folder.ID
+	}
+
+	filed := make(map[string]bool, len(follows))
+
+	for _, follow := range follows {
+		filed[follow.Feedurl] = follow.FolderID.Valid
+	}
+
+	for _, feed := range imported.Feeds {
+		if feed.Folder == "" || filed[feed.URL] {
+			continue
+		}
+
+		if _, err := state.db.AssignFeedToFolder(state.ctx, database.AssignFeedToFolderParams{
+			UserID:   currentUser.ID,
+			Url:      feed.URL,
+			FolderID: uuid.NullUUID{UUID: folderIDs[feed.Folder], Valid: true},
+		}); err != nil {
+			return wrapError(err, "Failed to file feed %q", feed.URL)
+		}
+	}
+
+	return nil
+}
+
+/*
+  - Restore whether the categories of a feed's posts become tags,
+    unless the feed already has settings here, which win.
+*/
+func importCategoryTags(state state, url string, categoryTags bool) error {
+	feed, err := state.db.GetFeedByURL(state.ctx, url)
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", url)
+	}
+
+	if _, err = state.db.GetFeedSettings(state.ctx, feed.ID); err != sql.ErrNoRows {
+		if err != nil {
+			return wrapError(err, "Failed to fetch the settings of feed %q", url)
+		}
+
+		return nil
+	}
+
+	if err = state.db.SetFeedCategoryTags(state.ctx, database.SetFeedCategoryTagsParams{
+		FeedID:       feed.ID,
+		UpdatedAt:    time.Now(),
+		CategoryTags: categoryTags,
+	}); err != nil {
+		return wrapError(err, "Failed to configure feed %q", url)
+	}
+
+	return nil
+}
+
+/** The ID of the imported post with this URL, if it has been fetched here. */
+func lookUpImportedPost(state state, url string) (uuid.NullUUID, error) {
+	post, err := state.db.GetPostByURL(state.ctx, url)
+
+	if err == sql.ErrNoRows {
+		return uuid.NullUUID{}, nil
+	}
+
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: post.ID, Valid: true}, nil
+}
+
+/*
+  - The outcome of 'import-state'. Of the 'Reads' read posts, only the
+    'Marked' ones already fetched here could be marked, and likewise
+    for post tags and posts sent in digests.
+*/
+type importedCounts struct {
+	Feeds          int `json:"feeds"`
+	Folders        int `json:"folders"`
+	Authors        int `json:"authors"`
+	Archives       int `json:"archives"`
+	Reads          int `json:"reads"`
+	Marked         int `json:"marked"`
+	Starred        int `json:"starred"`
+	Tags           int `json:"tags"`
+	Tagged         int `json:"tagged"`
+	Digested       int `json:"digested"`
+	MarkedDigested int `json:"marked_digested"`
+}
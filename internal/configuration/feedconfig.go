@@ -0,0 +1,178 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"time"
+)
+
+/** The shortest per-feed fetch interval, so that no server gets hammered. */
+const minFetchInterval = time.Minute
+
+/** A feed's settings, as reported by 'feedconfig'. */
+type feedConfig struct {
+	Name      string  `json:"name"`
+	URL       string  `json:"url"`
+	Interval  *string `json:"interval"`
+	MaxItems  *int32  `json:"max_items_per_fetch"`
+	Overflow  string  `json:"overflow_policy"`
+	Paused    bool    `json:"paused"`
+	Failures  int32   `json:"failures"`
+	Broken    bool    `json:"broken"`
+	Paywalled bool    `json:"paywalled"`
+
+	// Whether the categories of the feed's new posts become tags.
+	CategoryTags bool `json:"category_tags"`
+}
+
+/*
+  - Show or change how 'agg' treats the given feed. '--interval' sets
+    how often the feed is fetched, overriding the interval 'agg' was
+    started with; "default" removes the override. '--retry' forgets the
+    feed's failures, so that 'agg' fetches a broken feed again.
+    '--category-tags off' stops the categories the feed gives its posts
+    becoming tags (say, for a feed which files everything under
+    "Uncategorized"), and 'on' starts it again. Either way, the feed's
+    settings are then shown.
+*/
+func handlerFeedConfig(state state, args []string) error {
+	flagSet := flag.NewFlagSet("feedconfig", flag.ContinueOnError)
+	interval := flagSet.String("interval", "", "how often to fetch the feed (such as 30m or 6h), or \"default\"")
+	retry := flagSet.Bool("retry", false, "forget the feed's failures, so that a broken feed is fetched again")
+	categoryTags := flagSet.String("category-tags", "", "whether the categories of the feed's posts become tags: on or off")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'feedconfig' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'feedconfig' command takes a single FEED-URL argument")
+	}
+
+	feed, err := lookUpFeed(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	// Having changed anything, show the outcome.
+	if *interval != "" {
+		if err = setFetchInterval(state, feed, *interval); err != nil {
+			return err
+		}
+	}
+
+	if *categoryTags != "" {
+		if *categoryTags != "on" && *categoryTags != "off" {
+			return fmt.Errorf("The 'feedconfig' command's --category-tags is either on or off, not %q", *categoryTags)
+		}
+
+		if err = state.db.SetFeedCategoryTags(state.ctx, database.SetFeedCategoryTagsParams{
+			FeedID:       feed.ID,
+			UpdatedAt:    time.Now(),
+			CategoryTags: *categoryTags == "on",
+		}); err != nil {
+			return wrapError(err, "Failed to set whether feed %q has category tags", feed.Name)
+		}
+	}
+
+	if *retry {
+		if err = state.db.ResetFeedFailures(state.ctx, feed.ID); err != nil {
+			return wrapError(err, "Failed to reset the failures of feed %q", feed.Name)
+		}
+
+		feed.FailureCount = 0
+	}
+
+	settings, err := state.db.GetFeedSettings(state.ctx, feed.ID)
+
+	if err != nil && err != sql.ErrNoRows {
+		return wrapError(err, "Failed to fetch the settings of feed %q", feed.Url)
+	}
+
+	config := feedConfig{
+		Name:      feed.Name,
+		URL:       feed.Url,
+		Overflow:  settings.OverflowPolicy,
+		Paused:    settings.PausedAt.Valid,
+		Failures:  feed.FailureCount,
+		Broken:    feedBroken(state, feed),
+		Paywalled: settings.Paywalled,
+
+		// A feed without settings has its categories made into tags.
+		CategoryTags: err == sql.ErrNoRows || settings.CategoryTags,
+	}
+
+	if settings.FetchIntervalSeconds.Valid {
+		formatted := (time.Duration(settings.FetchIntervalSeconds.Int32) * time.Second).String()
+		config.Interval = &formatted
+	}
+
+	if settings.MaxItemsPerFetch.Valid {
+		config.MaxItems = &settings.MaxItemsPerFetch.Int32
+	}
+
+	return output.Print(os.Stdout, state.JSON, config, func(w io.Writer) error {
+		interval := "the 'agg' default"
+
+		if config.Interval != nil {
+			interval = *config.Interval
+		}
+
+		maxItems := "no limit"
+
+		if config.MaxItems != nil {
+			maxItems = fmt.Sprintf("%d (%s)", *config.MaxItems, config.Overflow)
+		}
+
+		fmt.Fprintf(w, "Feed:       %s (%s)\n", config.Name, config.URL)
+		fmt.Fprintf(w, "Interval:   %s\n", interval)
+		fmt.Fprintf(w, "Max items:  %s\n", maxItems)
+		fmt.Fprintf(w, "Paused:     %t\n", config.Paused)
+		fmt.Fprintf(w, "Paywalled:  %t\n", config.Paywalled)
+		fmt.Fprintf(w, "Categories: %s\n", map[bool]string{true: "made into tags", false: "ignored"}[config.CategoryTags])
+
+		if config.Broken {
+			fmt.Fprintf(w, "Failures:   %d in a row (broken; use --retry to fetch it again)\n", config.Failures)
+		} else {
+			fmt.Fprintf(w, "Failures:   %d in a row\n", config.Failures)
+		}
+
+		return nil
+	})
+}
+
+func setFetchInterval(state state, feed database.Feed, value string) error {
+	seconds := sql.NullInt32{}
+
+	if value != "default" {
+		interval, err := time.ParseDuration(value)
+
+		if err != nil {
+			return fmt.Errorf("Unable to parse %q as a duration", value)
+		}
+
+		if interval < minFetchInterval {
+			return fmt.Errorf("The fetch interval must be at least %s", minFetchInterval)
+		}
+
+		seconds = sql.NullInt32{Int32: int32(interval / time.Second), Valid: true}
+	}
+
+	if err := state.db.SetFeedFetchInterval(state.ctx, database.SetFeedFetchIntervalParams{
+		FeedID:               feed.ID,
+		UpdatedAt:            time.Now(),
+		FetchIntervalSeconds: seconds,
+	}); err != nil {
+		return wrapError(err, "Failed to set the fetch interval of feed %q", feed.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,422 @@
+package configuration
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** The version of the Fever API spoken. */
+const feverAPIVersion = 3
+
+/** The most items a Fever client gets per request, as the API has it. */
+const feverPageSize = 50
+
+/*
+  - Serve the Fever API under /fever/, so that readers which speak it
+    (such as Reeder and Unread) can sync with gator: they see the
+    given user's feeds, grouped by folder, along with their posts and
+    which are read and starred (which Fever calls "saved"), and can
+    mark posts read, unread, saved, or unsaved.
+
+    Clients log in with the user's name as the email address, and
+    'fever_password' from the config file as the password. Fever
+    clients send the MD5 of "EMAIL:PASSWORD" with each request, so
+    the password goes over the wire as good as in the clear: serve
+    this over HTTPS.
+*/
+func registerFever(state state, mux *http.ServeMux, user database.User) error {
+	if state.Config.FeverPassword == "" {
+		return fmt.Errorf("The Fever API needs 'fever_password' set in %s", state.ConfigFile)
+	}
+
+	sum := md5.Sum([]byte(user.Name + ":" + state.Config.FeverPassword))
+	apiKey := hex.EncodeToString(sum[:])
+
+	mux.HandleFunc("/fever/", func(w http.ResponseWriter, r *http.Request) {
+		state, cancel := requestState(state, r)
+		defer cancel()
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		response := map[string]any{
+			"api_version": feverAPIVersion,
+			"auth":        0,
+		}
+
+		given := strings.ToLower(r.Form.Get("api_key"))
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(apiKey)) == 1 {
+			response["auth"] = 1
+
+			if err := serveFever(state, r.Form, user, response); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := output.JSON(w, response); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write response: %v\n", err)
+		}
+	})
+
+	return nil
+}
+
+/*
+  - Carry out an authenticated Fever request, adding what it asks for
+    to 'response'. Marking comes first, so that the rest of the
+    response reflects it.
+*/
+func serveFever(state state, form url.Values, user database.User, response map[string]any) error {
+	if form.Has("mark") {
+		if err := feverMark(state, form, user); err != nil {
+			return err
+		}
+
+		// Clients expect the state they've changed back, whether or not
+		// they asked for it.
+		if form.Get("as") == "saved" || form.Get("as") == "unsaved" {
+			form.Set("saved_item_ids", "")
+		} else {
+			form.Set("unread_item_ids", "")
+		}
+	}
+
+	feeds, err := state.db.GetFeverFeeds(state.ctx, user.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", user.Name)
+	}
+
+	var lastRefreshed time.Time
+
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(lastRefreshed) {
+			lastRefreshed = feed.LastFetchedAt.Time
+		}
+	}
+
+	response["last_refreshed_on_time"] = unixTime(lastRefreshed)
+
+	if form.Has("groups") {
+		folders, err := state.db.ListFolders(state.ctx, user.ID)
+
+		if err != nil {
+			return wrapError(err, "Failed to fetch the folders of user %q", user.Name)
+		}
+
+		groups := make([]map[string]any, 0, len(folders))
+
+		for _, folder := range folders {
+			groups = append(groups, map[string]any{
+				"id":    folder.Seq,
+				"title": folder.Name,
+			})
+		}
+
+		response["groups"] = groups
+		response["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	if form.Has("feeds") {
+		listed := make([]map[string]any, 0, len(feeds))
+
+		for _, feed := range feeds {
+			listed = append(listed, map[string]any{
+				"id":                   feed.Seq,
+				"favicon_id":           0,
+				"title":                feed.Name,
+				"url":                  feed.Url,
+				"site_url":             feed.Url,
+				"is_spark":             0,
+				"last_updated_on_time": unixTime(feed.LastFetchedAt.Time),
+			})
+		}
+
+		response["feeds"] = listed
+		response["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	// Gator keeps no favicons, and Fever's "links" come from its own
+	// ranking of what's being linked to, which gator doesn't do.
+	if form.Has("favicons") {
+		response["favicons"] = make([]any, 0)
+	}
+
+	if form.Has("links") {
+		response["links"] = make([]any, 0)
+	}
+
+	if form.Has("items") {
+		if err = feverItems(state, form, user, response); err != nil {
+			return err
+		}
+	}
+
+	if form.Has("unread_item_ids") {
+		seqs, err := state.db.GetUnreadPostSeqs(state.ctx, user.ID)
+
+		if err != nil {
+			return wrapError(err, "Failed to fetch the unread posts of user %q", user.Name)
+		}
+
+		response["unread_item_ids"] = joinSeqs(seqs)
+	}
+
+	if form.Has("saved_item_ids") {
+		seqs, err := state.db.GetStarredPostSeqs(state.ctx, user.ID)
+
+		if err != nil {
+			return wrapError(err, "Failed to fetch the starred posts of user %q", user.Name)
+		}
+
+		response["saved_item_ids"] = joinSeqs(seqs)
+	}
+
+	return nil
+}
+
+/*
+  - Add the items asked for to 'response': those after 'since_id',
+    those before 'max_id' (newest first), or those listed in
+    'with_ids', up to 50 at a time.
+*/
+func feverItems(state state, form url.Values, user database.User, response map[string]any) error {
+	params := database.GetFeverItemsParams{
+		UserID: user.ID,
+		Limit:  feverPageSize,
+	}
+
+	if form.Has("since_id") {
+		since, err := strconv.ParseInt(form.Get("since_id"), 10, 64)
+
+		if err != nil {
+			return wrapError(errBadRequest, "Can't parse since_id %q as a number", form.Get("since_id"))
+		}
+
+		params.SinceSeq = sql.NullInt64{Int64: since, Valid: true}
+	}
+
+	if form.Has("max_id") {
+		max, err := strconv.ParseInt(form.Get("max_id"), 10, 64)
+
+		if err != nil {
+			return wrapError(errBadRequest, "Can't parse max_id %q as a number", form.Get("max_id"))
+		}
+
+		params.MaxSeq = sql.NullInt64{Int64: max, Valid: true}
+	}
+
+	if form.Has("with_ids") {
+		params.Seqs = make([]int64, 0)
+
+		for _, field := range strings.Split(form.Get("with_ids"), ",") {
+			seq, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+
+			if err != nil {
+				return wrapError(errBadRequest, "Can't parse with_ids %q as numbers", form.Get("with_ids"))
+			}
+
+			params.Seqs = append(params.Seqs, seq)
+		}
+	}
+
+	posts, err := state.db.GetFeverItems(state.ctx, params)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch posts for user %q", user.Name)
+	}
+
+	total, err := state.db.CountFeverItems(state.ctx, user.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to count posts for user %q", user.Name)
+	}
+
+	items := make([]map[string]any, 0, len(posts))
+
+	for _, post := range posts {
+		items = append(items, map[string]any{
+			"id":              post.Seq,
+			"feed_id":         post.FeedSeq,
+			"title":           post.Title,
+			"author":          post.Author,
+			"html":            post.Description,
+			"url":             post.Url,
+			"is_saved":        feverBool(post.IsSaved),
+			"is_read":         feverBool(post.IsRead),
+			"created_on_time": unixTime(post.PublishedAt),
+		})
+	}
+
+	response["items"] = items
+	response["total_items"] = total
+
+	return nil
+}
+
+/*
+  - Mark an item read, unread, saved, or unsaved, or mark a whole feed
+    or group (folder) read. Feeds and groups are marked read only as
+    far as the posts added before 'before', so that posts the client
+    hasn't seen yet stay unread. Group 0 stands for every feed.
+*/
+func feverMark(state state, form url.Values, user database.User) error {
+	id, err := strconv.ParseInt(form.Get("id"), 10, 64)
+
+	if err != nil {
+		return wrapError(errBadRequest, "Can't parse id %q as a number", form.Get("id"))
+	}
+
+	mark, as := form.Get("mark"), form.Get("as")
+
+	if mark == "item" {
+		post, err := state.db.GetPostBySeq(state.ctx, id)
+
+		if err == sql.ErrNoRows {
+			return wrapError(ErrNotFound, "No item with ID %d", id)
+		}
+
+		if err != nil {
+			return wrapError(err, "Failed to look up item %d", id)
+		}
+
+		switch as {
+		case "read":
+			err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+				ReadAt: time.Now(),
+			})
+		case "unread":
+			_, err = state.db.MarkPostUnread(state.ctx, database.MarkPostUnreadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+			})
+		case "saved":
+			_, _, err = starPost(state, user, post)
+		case "unsaved":
+			_, err = state.db.UnstarPost(state.ctx, database.UnstarPostParams{
+				UserID:  user.ID,
+				IDOrUrl: post.ID.String(),
+			})
+		default:
+			return wrapError(errBadRequest, "Can't mark an item as %q", as)
+		}
+
+		if err != nil {
+			return wrapError(err, "Failed to mark post %q as %s", post.Title, as)
+		}
+
+		return nil
+	}
+
+	if as != "read" {
+		return wrapError(errBadRequest, "Can't mark a %s as %q", mark, as)
+	}
+
+	before, err := strconv.ParseInt(form.Get("before"), 10, 64)
+
+	if err != nil {
+		return wrapError(errBadRequest, "Can't parse before %q as a time", form.Get("before"))
+	}
+
+	params := database.MarkPostsReadBeforeParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+		Before: time.Unix(before, 0),
+	}
+
+	switch {
+	case mark == "feed":
+		params.FeedSeq = sql.NullInt64{Int64: id, Valid: true}
+	case mark == "group" && id > 0:
+		params.FolderSeq = sql.NullInt64{Int64: id, Valid: true}
+	case mark == "group" && id == 0:
+		// Everything.
+	case mark == "group":
+		// Fever's "sparks" group, which gator has no counterpart of.
+		return nil
+	default:
+		return wrapError(errBadRequest, "Can't mark a %q", mark)
+	}
+
+	if err = state.db.MarkPostsReadBefore(state.ctx, params); err != nil {
+		return wrapError(err, "Failed to mark %s %d as read", mark, id)
+	}
+
+	return nil
+}
+
+/** Which feeds are in each group, as Fever has it: IDs joined by commas. */
+func feverFeedsGroups(feeds []database.GetFeverFeedsRow) []map[string]any {
+	groupFeeds := make(map[int64][]int64)
+	groups := make([]int64, 0)
+
+	for _, feed := range feeds {
+		if !feed.FolderSeq.Valid {
+			continue
+		}
+
+		if _, ok := groupFeeds[feed.FolderSeq.Int64]; !ok {
+			groups = append(groups, feed.FolderSeq.Int64)
+		}
+
+		groupFeeds[feed.FolderSeq.Int64] = append(groupFeeds[feed.FolderSeq.Int64], feed.Seq)
+	}
+
+	feedsGroups := make([]map[string]any, 0, len(groups))
+
+	for _, group := range groups {
+		feedsGroups = append(feedsGroups, map[string]any{
+			"group_id": group,
+			"feed_ids": joinSeqs(groupFeeds[group]),
+		})
+	}
+
+	return feedsGroups
+}
+
+func joinSeqs(seqs []int64) string {
+	fields := make([]string, len(seqs))
+
+	for i, seq := range seqs {
+		fields[i] = strconv.FormatInt(seq, 10)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+/** Fever's booleans are numbers. */
+func feverBool(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+/** A time as Unix seconds, or 0 for the zero time. */
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
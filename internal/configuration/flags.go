@@ -0,0 +1,35 @@
+package configuration
+
+import (
+	"flag"
+	"io"
+)
+
+/*
+  - Parse 'args' against the given flag set, allowing flags and
+    positional arguments to be freely interleaved (the standard
+    library parser stops at the first positional argument.)
+
+    The positional arguments are returned in their original order.
+*/
+func parseFlags(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	flagSet.SetOutput(io.Discard)
+	positional := make([]string, 0, len(args))
+
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flagSet.Args()
+
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	return positional, nil
+}
@@ -0,0 +1,165 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+/*
+  - Organize the feeds the user follows into folders, so that 'browse'
+    and 'following' can show one folder at a time.
+
+    The subcommands are:
+
+    create: create a folder
+    assign: file a followed feed under a folder, or under none
+    list:   show the user's folders
+*/
+func handlerFolder(state state, args []string, currentUser database.User) error {
+	if len(args) == 0 {
+		return fmt.Errorf("The 'folder' command takes a subcommand: create, assign, or list")
+	}
+
+	subcommand, args := args[0], args[1:]
+
+	switch subcommand {
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("The 'folder create' command takes a single FOLDER argument")
+		}
+
+		return createFolder(state, currentUser, args[0])
+	case "assign":
+		if len(args) != 1 && len(args) != 2 {
+			return fmt.Errorf("The 'folder assign' command takes a FEED-URL argument, and optionally a FOLDER")
+		}
+
+		folder := ""
+
+		if len(args) == 2 {
+			folder = args[1]
+		}
+
+		return assignFolder(state, currentUser, args[0], folder)
+	case "list":
+		if len(args) > 0 {
+			return fmt.Errorf("The 'folder list' command takes no arguments")
+		}
+
+		return listFolders(state, currentUser)
+	default:
+		return fmt.Errorf("Unknown 'folder' subcommand %q", subcommand)
+	}
+}
+
+func createFolder(state state, currentUser database.User, name string) error {
+	if name == "" {
+		return fmt.Errorf("A folder's name can't be empty")
+	}
+
+	folder, err := state.db.CreateFolder(state.ctx, database.CreateFolderParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    currentUser.ID,
+		Name:      name,
+	})
+
+	if database.IsUniqueViolation(err, database.FoldersUserIDNameKey) {
+		return wrapError(ErrAlreadyExists, "User %q already has a folder named %q", currentUser.Name, name)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to create folder %q", name)
+	}
+
+	return output.Print(os.Stdout, state.JSON, listedFolder{Name: folder.Name}, output.Nothing)
+}
+
+func assignFolder(state state, currentUser database.User, url string, name string) error {
+	folderID := uuid.NullUUID{}
+
+	if name != "" {
+		folder, err := lookUpFolder(state, currentUser, name)
+
+		if err != nil {
+			return err
+		}
+
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	if numUpdated, err := state.db.AssignFeedToFolder(state.ctx, database.AssignFeedToFolderParams{
+		UserID:   currentUser.ID,
+		Url:      url,
+		FolderID: folderID,
+	}); err != nil {
+		return wrapError(err, "Failed to file feed %q", url)
+	} else if numUpdated == 0 {
+		return wrapError(ErrNotFound, "User %q isn't following a feed with URL %q", currentUser.Name, url)
+	}
+
+	return output.Print(os.Stdout, state.JSON, folderAssignment{URL: url, Folder: name}, output.Nothing)
+}
+
+func listFolders(state state, currentUser database.User) error {
+	rows, err := state.db.ListFolders(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the folders of user %q", currentUser.Name)
+	}
+
+	folders := make([]listedFolder, 0, len(rows))
+
+	for _, row := range rows {
+		folders = append(folders, listedFolder{Name: row.Name, Feeds: row.Feeds})
+	}
+
+	return output.Print(os.Stdout, state.JSON, folders, func(w io.Writer) error {
+		table := make([][]string, 0, len(folders))
+
+		for _, folder := range folders {
+			table = append(table, []string{folder.Name, strconv.FormatInt(folder.Feeds, 10)})
+		}
+
+		return output.Table(w, []string{"FOLDER", "FEEDS"}, table)
+	})
+}
+
+/** Look up one of the user's folders by name. */
+func lookUpFolder(state state, currentUser database.User, name string) (database.Folder, error) {
+	folder, err := state.db.GetFolder(state.ctx, database.GetFolderParams{
+		UserID: currentUser.ID,
+		Name:   name,
+	})
+
+	if err == sql.ErrNoRows {
+		return database.Folder{}, wrapError(ErrNotFound, "User %q has no folder named %q (use 'folder create' first)", currentUser.Name, name)
+	}
+
+	if err != nil {
+		return database.Folder{}, wrapError(err, "Failed to look up folder %q", name)
+	}
+
+	return folder, nil
+}
+
+/** A folder, as reported by 'folder create' and 'folder list'. */
+type listedFolder struct {
+	Name  string `json:"name"`
+	Feeds int64  `json:"feeds"`
+}
+
+/** A feed filed under a folder, or under none, by 'folder assign'. */
+type folderAssignment struct {
+	URL    string `json:"url"`
+	Folder string `json:"folder"`
+}
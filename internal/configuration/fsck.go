@@ -0,0 +1,109 @@
+package configuration
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+)
+
+/** A single kind of integrity problem 'fsck' knows how to detect and repair. */
+type fsckCheck struct {
+	description string
+	count       func(context.Context) (int64, error)
+	repair      func(context.Context) (int64, error)
+}
+
+/*
+  - Detect rows left dangling by a missing parent (for example, in a
+    database created before the current foreign keys were in place),
+    and with '--repair', fix them: orphaned rows are deleted, feeds
+    whose adding user is gone are kept but disowned, and stars of a
+    missing post keep their copy of it but lose the link. Read marks,
+    tags, opens and digest entries have no foreign key to 'posts' (which
+    may be partitioned), so they can only be cleaned up here.
+*/
+func handlerFsck(state state, args []string) error {
+	flagSet := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	repair := flagSet.Bool("repair", false, "fix any problems found")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'fsck' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'fsck' command takes no arguments")
+	}
+
+	checks := []fsckCheck{
+		{"feed follows with a missing user or feed", state.db.CountOrphanedFeedFollows, state.db.DeleteOrphanedFeedFollows},
+		{"posts with a missing feed", state.db.CountOrphanedPosts, state.db.DeleteOrphanedPosts},
+		{"fetch jobs with a missing feed", state.db.CountOrphanedFetchJobs, state.db.DeleteOrphanedFetchJobs},
+		{"feeds added by a missing user", state.db.CountFeedsWithMissingUser, state.db.ClearFeedsWithMissingUser},
+		{"read marks of a missing post", state.db.CountOrphanedPostReads, state.db.DeleteOrphanedPostReads},
+		{"post tags of a missing post", state.db.CountOrphanedPostTags, state.db.DeleteOrphanedPostTags},
+		{"opens of a missing post", state.db.CountOrphanedPostOpens, state.db.DeleteOrphanedPostOpens},
+		{"digest entries of a missing post", state.db.CountOrphanedDigestedPosts, state.db.DeleteOrphanedDigestedPosts},
+		{"stars linked to a missing post", state.db.CountStarsWithMissingPost, state.db.ClearStarsWithMissingPost},
+	}
+
+	problems := int64(0)
+	found := make([]fsckProblem, 0)
+
+	for _, check := range checks {
+		count, err := check.count(state.ctx)
+
+		if err != nil {
+			return wrapError(err, "Failed to count %s", check.description)
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		problems += count
+		problem := fsckProblem{Description: check.description, Count: count}
+
+		if *repair {
+			repaired, err := check.repair(state.ctx)
+
+			if err != nil {
+				return wrapError(err, "Failed to repair %s", check.description)
+			}
+
+			problem.Repaired = &repaired
+		}
+
+		found = append(found, problem)
+	}
+
+	return output.Print(os.Stdout, state.JSON, found, func(w io.Writer) error {
+		for _, problem := range found {
+			if problem.Repaired == nil {
+				fmt.Fprintf(w, "%d %s\n", problem.Count, problem.Description)
+			} else {
+				fmt.Fprintf(w, "%d %s (repaired %d)\n", problem.Count, problem.Description, *problem.Repaired)
+			}
+		}
+
+		switch {
+		case problems == 0:
+			fmt.Fprintln(w, "No problems found")
+		case !*repair:
+			fmt.Fprintln(w, "Run 'fsck --repair' to fix these problems")
+		}
+
+		return nil
+	})
+}
+
+/** A problem found by 'fsck'; 'Repaired' is only set with '--repair'. */
+type fsckProblem struct {
+	Description string `json:"description"`
+	Count       int64  `json:"count"`
+	Repaired    *int64 `json:"repaired,omitempty"`
+}
@@ -0,0 +1,733 @@
+package configuration
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/password"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** How many items a Google Reader client gets per request, unless it asks otherwise, and at most. */
+const (
+	defaultReaderPageSize = 20
+	maxReaderPageSize     = 1000
+)
+
+/** The streams and tags Google Reader has built in. */
+const (
+	readerReadingList = "user/-/state/com.google/reading-list"
+	readerRead        = "user/-/state/com.google/read"
+	readerKeptUnread  = "user/-/state/com.google/kept-unread"
+	readerStarred     = "user/-/state/com.google/starred"
+	readerLabelPrefix = "user/-/label/"
+	readerFeedPrefix  = "feed/"
+)
+
+/** The long form of an item ID, which clients may use instead of the number itself. */
+const readerItemPrefix = "tag:google.com,2005:reader/item/"
+
+/** Where stream contents are served, with the stream's ID following. */
+const readerStreamContents = "/reader/api/0/stream/contents/"
+
+/*
+  - Serve the Google Reader API, as extended by the services which
+    kept it going (such as FreshRSS and Miniflux), so that readers
+    which sync through it (such as NetNewsWire and FeedMe) can sync
+    with gator. They see the given user's feeds as subscriptions,
+    labelled with their folders, along with their posts, and can mark
+    posts read, unread, starred, or unstarred.
+
+    Clients log in at /accounts/ClientLogin with the user's name and
+    'google_reader_password' from the config file (or failing that,
+    the user's own password, if they have one), and are given a new,
+    random token to send with each request thereafter. Like those of
+    'login', only a hash of it is kept, among the user's sessions.
+    Items are identified by the same numbers as in the Fever API.
+
+    The returned handler is to be served in place of 'mux': stream IDs
+    (such as "feed/https://example.com/feed") go in the path of
+    stream contents requests, whose double slashes the mux would
+    otherwise clean away.
+*/
+func registerGoogleReader(state state, mux *http.ServeMux, user database.User) (http.Handler, error) {
+	readerPassword := state.Config.GoogleReaderPassword
+
+	if readerPassword == "" && user.PasswordHash == "" {
+		return nil, fmt.Errorf("The Google Reader API needs 'google_reader_password' set in %s, or a user registered with --password", state.ConfigFile)
+	}
+
+	mux.HandleFunc("/accounts/ClientLogin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Error=BadRequest", http.StatusBadRequest)
+			return
+		}
+
+		validName := subtle.ConstantTimeCompare([]byte(r.Form.Get("Email")), []byte(user.Name))
+		validPassword := 0
+
+		if readerPassword != "" {
+			validPassword = subtle.ConstantTimeCompare([]byte(r.Form.Get("Passwd")), []byte(readerPassword))
+		} else if password.Verify(user.PasswordHash, r.Form.Get("Passwd")) {
+			validPassword = 1
+		}
+
+		if validName&validPassword != 1 {
+			http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+			return
+		}
+
+		state, cancel := requestState(state, r)
+		defer cancel()
+
+		token, err := startReaderSession(state, user)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			http.Error(w, "Error=Unknown", http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "SID=%s\nLSID=null\nAuth=%s\n", token, token)
+	})
+
+	serve := func(handler apiHandler) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			state, cancel := requestState(state, r)
+			defer cancel()
+
+			userID, err := state.db.GetSessionUserID(state.ctx, tokenHash(readerAuthToken(r)))
+
+			if err == sql.ErrNoRows || (err == nil && userID != user.ID) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if err != nil {
+				writeAPIError(w, wrapError(err, "Failed to look up a Google Reader session"))
+				return
+			}
+
+			if err = r.ParseForm(); err != nil {
+				writeAPIError(w, wrapError(errBadRequest, "Can't parse the request: %v", err))
+				return
+			}
+
+			if r.Method == http.MethodGet {
+				state = readFromReplica(state)
+			}
+
+			result, err := handler(state, r, user)
+
+			if err != nil {
+				writeAPIError(w, err)
+				return
+			}
+
+			// Some answers are plain text, such as the "OK" of a
+			// successful edit.
+			if text, ok := result.(string); ok {
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprint(w, text)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+
+			if err = output.JSON(w, result); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write response: %v\n", err)
+			}
+		}
+	}
+
+	mux.Handle("GET /reader/api/0/token", serve(readerToken))
+	mux.Handle("GET /reader/api/0/user-info", serve(readerUserInfo))
+	mux.Handle("GET /reader/api/0/subscription/list", serve(readerSubscriptions))
+	mux.Handle("GET /reader/api/0/tag/list", serve(readerTags))
+	mux.Handle("GET /reader/api/0/unread-count", serve(readerUnreadCounts))
+	mux.Handle("GET /reader/api/0/stream/items/ids", serve(readerItemIDs))
+	mux.Handle("/reader/api/0/stream/items/contents", serve(readerItemContents))
+	mux.Handle("POST /reader/api/0/edit-tag", serve(readerEditTag))
+	mux.Handle("POST /reader/api/0/mark-all-as-read", serve(readerMarkAllRead))
+
+	streamContents := serve(readerStreamContentsHandler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stream, ok := strings.CutPrefix(r.URL.EscapedPath(), readerStreamContents); ok && r.Method == http.MethodGet {
+			unescaped, err := url.PathUnescape(stream)
+
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+
+			r.SetPathValue("stream", unescaped)
+			streamContents(w, r)
+
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+/*
+  - Write requests are meant to carry the token got from here, to guard
+    against cross-site requests; the token in their Authorization
+    header does as much already, so it's simply handed back.
+*/
+func readerToken(state state, r *http.Request, user database.User) (any, error) {
+	return readerAuthToken(r), nil
+}
+
+/** The token a client was given at login, as it sends it back. */
+func readerAuthToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+
+	return token
+}
+
+/*
+  - Start a session for a Google Reader client which has logged in,
+    returning its token. Unlike 'startSession', this makes one even
+    for a user without a password, who logged in with
+    'google_reader_password' instead.
+*/
+func startReaderSession(state state, user database.User) (string, error) {
+	token, err := newToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = state.db.CreateSession(state.ctx, database.CreateSessionParams{
+		TokenHash: tokenHash(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return "", wrapError(err, "Failed to start a Google Reader session for user '%s'", user.Name)
+	}
+
+	return token, nil
+}
+
+func readerUserInfo(state state, r *http.Request, user database.User) (any, error) {
+	return map[string]any{
+		"userId":        user.ID.String(),
+		"userName":      user.Name,
+		"userProfileId": user.ID.String(),
+		"userEmail":     user.Name,
+	}, nil
+}
+
+func readerSubscriptions(state state, r *http.Request, user database.User) (any, error) {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, user.ID)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch the feeds followed by user %q", user.Name)
+	}
+
+	subscriptions := make([]map[string]any, 0, len(follows))
+
+	for _, follow := range follows {
+		categories := make([]map[string]any, 0, 1)
+
+		if follow.Folder.Valid {
+			categories = append(categories, map[string]any{
+				"id":    readerLabelPrefix + follow.Folder.String,
+				"label": follow.Folder.String,
+			})
+		}
+
+		subscriptions = append(subscriptions, map[string]any{
+			"id":         readerFeedPrefix + follow.Feedurl,
+			"title":      follow.Feedname,
+			"categories": categories,
+			"url":        follow.Feedurl,
+			"htmlUrl":    follow.Feedurl,
+			"iconUrl":    "",
+		})
+	}
+
+	return map[string]any{"subscriptions": subscriptions}, nil
+}
+
+/** The tags there are: starred, along with a label for each folder. */
+func readerTags(state state, r *http.Request, user database.User) (any, error) {
+	folders, err := state.db.ListFolders(state.ctx, user.ID)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch the folders of user %q", user.Name)
+	}
+
+	tags := []map[string]any{{"id": readerStarred}}
+
+	for _, folder := range folders {
+		tags = append(tags, map[string]any{
+			"id":   readerLabelPrefix + folder.Name,
+			"type": "folder",
+		})
+	}
+
+	return map[string]any{"tags": tags}, nil
+}
+
+/** How many posts are unread in each feed, each folder, and overall. */
+func readerUnreadCounts(state state, r *http.Request, user database.User) (any, error) {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, user.ID)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch the feeds followed by user %q", user.Name)
+	}
+
+	counts := make([]map[string]any, 0, len(follows))
+	folderCounts := make(map[string]int64)
+	folderNewest := make(map[string]time.Time)
+	folders := make([]string, 0)
+
+	var total int64
+	var newest time.Time
+
+	for _, follow := range follows {
+		counts = append(counts, map[string]any{
+			"id":                      readerFeedPrefix + follow.Feedurl,
+			"count":                   follow.Unread,
+			"newestItemTimestampUsec": readerUsec(follow.LastPostAt.Time),
+		})
+
+		total += follow.Unread
+
+		if follow.LastPostAt.Time.After(newest) {
+			newest = follow.LastPostAt.Time
+		}
+
+		if !follow.Folder.Valid {
+			continue
+		}
+
+		if _, ok := folderCounts[follow.Folder.String]; !ok {
+			folders = append(folders, follow.Folder.String)
+		}
+
+		folderCounts[follow.Folder.String] += follow.Unread
+
+		if follow.LastPostAt.Time.After(folderNewest[follow.Folder.String]) {
+			folderNewest[follow.Folder.String] = follow.LastPostAt.Time
+		}
+	}
+
+	for _, folder := range folders {
+		counts = append(counts, map[string]any{
+			"id":                      readerLabelPrefix + folder,
+			"count":                   folderCounts[folder],
+			"newestItemTimestampUsec": readerUsec(folderNewest[folder]),
+		})
+	}
+
+	counts = append(counts, map[string]any{
+		"id":                      readerReadingList,
+		"count":                   total,
+		"newestItemTimestampUsec": readerUsec(newest),
+	})
+
+	return map[string]any{
+		"max":          total,
+		"unreadcounts": counts,
+	}, nil
+}
+
+/** The IDs of the items in the stream given by 's', for fetching with 'readerItemContents'. */
+func readerItemIDs(state state, r *http.Request, user database.User) (any, error) {
+	items, continuation, err := readerStreamItems(state, r.Form.Get("s"), r.Form, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]map[string]any, 0, len(items))
+
+	for _, item := range items {
+		refs = append(refs, map[string]any{
+			"id":              strconv.FormatInt(item.Seq, 10),
+			"directStreamIds": []string{readerFeedPrefix + item.FeedUrl},
+			"timestampUsec":   readerUsec(item.CreatedAt),
+		})
+	}
+
+	response := map[string]any{"itemRefs": refs}
+
+	if continuation != "" {
+		response["continuation"] = continuation
+	}
+
+	return response, nil
+}
+
+/** The items with the IDs given by 'i', in full. */
+func readerItemContents(state state, r *http.Request, user database.User) (any, error) {
+	seqs := make([]int64, 0, len(r.Form["i"]))
+
+	for _, id := range r.Form["i"] {
+		seq, err := parseReaderItemID(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		seqs = append(seqs, seq)
+	}
+
+	items, err := state.db.GetReaderItems(state.ctx, database.GetReaderItemsParams{
+		UserID: user.ID,
+		Seqs:   seqs,
+		Limit:  int32(len(seqs)),
+	})
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to fetch posts for user %q", user.Name)
+	}
+
+	return readerStream(readerReadingList, items, ""), nil
+}
+
+/** The items in the stream given in the path, in full. */
+func readerStreamContentsHandler(state state, r *http.Request, user database.User) (any, error) {
+	stream := r.PathValue("stream")
+	items, continuation, err := readerStreamItems(state, stream, r.Form, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return readerStream(stream, items, continuation), nil
+}
+
+/*
+  - Fetch the items in the given stream, as narrowed down by the
+    request's parameters: 'n' (how many), 'r' ("o" for oldest first),
+    'xt' (a state to exclude, which can only be read), 'ot' and 'nt'
+    (the Unix times items must have been added since and before), and
+    'c' (the continuation from the previous page). Along with the
+    items comes the continuation for the next page, if there may be
+    one.
+*/
+func readerStreamItems(state state, stream string, form url.Values, user database.User) ([]database.GetReaderItemsRow, string, error) {
+	count, err := queryInt(form.Get("n"), defaultReaderPageSize)
+
+	if err != nil {
+		return nil, "", wrapError(errBadRequest, "Expected 'n' to be a non-negative number")
+	}
+
+	params := database.GetReaderItemsParams{
+		UserID:      user.ID,
+		OldestFirst: form.Get("r") == "o",
+		Limit:       int32(min(count, maxReaderPageSize)),
+	}
+
+	switch stream = readerStreamID(stream); {
+	case stream == "" || stream == readerReadingList:
+		// Everything.
+	case stream == readerStarred:
+		params.StarredOnly = true
+	case stream == readerRead:
+		params.ReadOnly = true
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		params.Folder = sql.NullString{String: strings.TrimPrefix(stream, readerLabelPrefix), Valid: true}
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		params.FeedUrl = sql.NullString{String: strings.TrimPrefix(stream, readerFeedPrefix), Valid: true}
+	default:
+		return nil, "", wrapError(ErrNotFound, "No stream %q", stream)
+	}
+
+	for _, excluded := range form["xt"] {
+		if readerStreamID(excluded) == readerRead {
+			params.UnreadOnly = true
+		}
+	}
+
+	for name, bound := range map[string]*sql.NullTime{"ot": &params.NewerThan, "nt": &params.OlderThan} {
+		if !form.Has(name) {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(form.Get(name), 10, 64)
+
+		if err != nil {
+			return nil, "", wrapError(errBadRequest, "Can't parse %s %q as a time", name, form.Get(name))
+		}
+
+		*bound = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+	}
+
+	if form.Get("c") != "" {
+		seq, err := strconv.ParseInt(form.Get("c"), 10, 64)
+
+		if err != nil {
+			return nil, "", wrapError(errBadRequest, "Can't parse continuation %q", form.Get("c"))
+		}
+
+		params.Continuation = sql.NullInt64{Int64: seq, Valid: true}
+	}
+
+	items, err := state.db.GetReaderItems(state.ctx, params)
+
+	if err != nil {
+		return nil, "", wrapError(err, "Failed to fetch posts for user %q", user.Name)
+	}
+
+	continuation := ""
+
+	if len(items) > 0 && len(items) == int(params.Limit) {
+		continuation = strconv.FormatInt(items[len(items)-1].Seq, 10)
+	}
+
+	return items, continuation, nil
+}
+
+/** A stream of items, as the Google Reader API presents it. */
+func readerStream(stream string, items []database.GetReaderItemsRow, continuation string) map[string]any {
+	listed := make([]map[string]any, 0, len(items))
+
+	for _, item := range items {
+		link := item.Url
+
+		if item.CanonicalUrl != "" {
+			link = item.CanonicalUrl
+		}
+
+		categories := []string{readerReadingList}
+
+		if item.Folder.Valid {
+			categories = append(categories, readerLabelPrefix+item.Folder.String)
+		}
+
+		if item.IsRead {
+			categories = append(categories, readerRead)
+		}
+
+		if item.IsStarred {
+			categories = append(categories, readerStarred)
+		}
+
+		listed = append(listed, map[string]any{
+			"id":            fmt.Sprintf("%s%016x", readerItemPrefix, item.Seq),
+			"crawlTimeMsec": strconv.FormatInt(item.CreatedAt.UnixMilli(), 10),
+			"timestampUsec": readerUsec(item.CreatedAt),
+			"published":     unixTime(item.PublishedAt),
+			"updated":       unixTime(item.PublishedAt),
+			"title":         item.Title,
+			"author":        item.Author,
+			"canonical":     []map[string]string{{"href": link}},
+			"alternate":     []map[string]string{{"href": link, "type": "text/html"}},
+			"summary":       map[string]string{"direction": "ltr", "content": item.Description},
+			"categories":    categories,
+			"origin": map[string]string{
+				"streamId": readerFeedPrefix + item.FeedUrl,
+				"title":    item.FeedName,
+				"htmlUrl":  item.FeedUrl,
+			},
+		})
+	}
+
+	response := map[string]any{
+		"direction": "ltr",
+		"id":        stream,
+		"updated":   time.Now().Unix(),
+		"items":     listed,
+	}
+
+	if continuation != "" {
+		response["continuation"] = continuation
+	}
+
+	return response
+}
+
+/*
+  - Add the tags given by 'a' to the items given by 'i', and remove
+    those given by 'r'. The tags that mean anything to gator are read
+    (along with kept-unread, its opposite) and starred; others are
+    ignored.
+*/
+func readerEditTag(state state, r *http.Request, user database.User) (any, error) {
+	for _, id := range r.Form["i"] {
+		seq, err := parseReaderItemID(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		post, err := state.db.GetPostBySeq(state.ctx, seq)
+
+		if err == sql.ErrNoRows {
+			return nil, wrapError(ErrNotFound, "No item with ID %q", id)
+		}
+
+		if err != nil {
+			return nil, wrapError(err, "Failed to look up item %q", id)
+		}
+
+		for _, tag := range r.Form["a"] {
+			if err = readerTag(state, user, post, readerStreamID(tag), true); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, tag := range r.Form["r"] {
+			if err = readerTag(state, user, post, readerStreamID(tag), false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return "OK", nil
+}
+
+/** Add the given tag to a post, or remove it. */
+func readerTag(state state, user database.User, post database.Post, tag string, add bool) error {
+	var err error
+
+	switch {
+	case (tag == readerRead && add) || (tag == readerKeptUnread && !add):
+		err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			ReadAt: time.Now(),
+		})
+	case tag == readerRead || tag == readerKeptUnread:
+		_, err = state.db.MarkPostUnread(state.ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+	case tag == readerStarred && add:
+		_, _, err = starPost(state, user, post)
+	case tag == readerStarred:
+		_, err = state.db.UnstarPost(state.ctx, database.UnstarPostParams{
+			UserID:  user.ID,
+			IDOrUrl: post.ID.String(),
+		})
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to tag post %q as %s", post.Title, tag)
+	}
+
+	return nil
+}
+
+/*
+  - Mark everything in the stream given by 's' as read, as far as the
+    posts added before 'ts' (in microseconds), so that posts the
+    client hasn't seen yet stay unread.
+*/
+func readerMarkAllRead(state state, r *http.Request, user database.User) (any, error) {
+	params := database.MarkPostsReadBeforeParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+		Before: time.Now(),
+	}
+
+	if r.Form.Get("ts") != "" {
+		usec, err := strconv.ParseInt(r.Form.Get("ts"), 10, 64)
+
+		if err != nil {
+			return nil, wrapError(errBadRequest, "Can't parse ts %q as a time", r.Form.Get("ts"))
+		}
+
+		params.Before = time.UnixMicro(usec)
+	}
+
+	switch stream := readerStreamID(r.Form.Get("s")); {
+	case stream == readerReadingList:
+		// Everything.
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		folder, err := lookUpFolder(state, user, strings.TrimPrefix(stream, readerLabelPrefix))
+
+		if err != nil {
+			return nil, err
+		}
+
+		params.FolderSeq = sql.NullInt64{Int64: folder.Seq, Valid: true}
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		feedURL := strings.TrimPrefix(stream, readerFeedPrefix)
+		feed, err := state.db.GetFeedByURL(state.ctx, feedURL)
+
+		if err == sql.ErrNoRows {
+			return nil, wrapError(ErrNotFound, "No feed with URL %q", feedURL)
+		}
+
+		if err != nil {
+			return nil, wrapError(err, "Failed to look up feed %q", feedURL)
+		}
+
+		params.FeedSeq = sql.NullInt64{Int64: feed.Seq, Valid: true}
+	default:
+		return nil, wrapError(errBadRequest, "Can't mark stream %q as read", stream)
+	}
+
+	if err := state.db.MarkPostsReadBefore(state.ctx, params); err != nil {
+		return nil, wrapError(err, "Failed to mark stream %q as read", r.Form.Get("s"))
+	}
+
+	return "OK", nil
+}
+
+/*
+  - Put a stream or tag ID in the form used here: clients may name the
+    user by their ID (as in "user/1234/state/com.google/read") rather
+    than as "-".
+*/
+func readerStreamID(stream string) string {
+	rest, ok := strings.CutPrefix(stream, "user/")
+
+	if !ok {
+		return stream
+	}
+
+	if _, after, found := strings.Cut(rest, "/"); found {
+		return "user/-/" + after
+	}
+
+	return stream
+}
+
+/** Parse an item ID, given either as a number or in its long form (in hexadecimal). */
+func parseReaderItemID(id string) (int64, error) {
+	if hexID, ok := strings.CutPrefix(id, readerItemPrefix); ok {
+		seq, err := strconv.ParseUint(hexID, 16, 64)
+
+		if err != nil {
+			return 0, wrapError(errBadRequest, "Can't parse item ID %q", id)
+		}
+
+		return int64(seq), nil
+	}
+
+	seq, err := strconv.ParseInt(id, 10, 64)
+
+	if err != nil {
+		return 0, wrapError(errBadRequest, "Can't parse item ID %q", id)
+	}
+
+	return seq, nil
+}
+
+/** A time as Unix microseconds, in a string as Google Reader has it, or "0" for the zero time. */
+func readerUsec(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
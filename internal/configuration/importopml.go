@@ -0,0 +1,244 @@
+package configuration
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/opml"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/progress"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+  - Import every feed listed in an OPML file, making the current user
+    follow each of them.
+
+    Unless '--skip-validation' is given, every feed is first fetched
+    (with at most '--workers' requests in flight) and classified as ok,
+    redirected, or dead. Dead feeds are reported and skipped;
+    redirected feeds are saved under their final URL.
+*/
+func handlerImportOPML(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("importopml", flag.ContinueOnError)
+	skipValidation := flagSet.Bool("skip-validation", false, "import feeds without fetching them first")
+	workers := flagSet.Int("workers", 8, "number of feeds to validate concurrently")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'importopml' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'importopml' command takes a single OPML FILE argument")
+	}
+
+	file, err := os.Open(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+
+	if err != nil {
+		return wrapError(err, "Can't parse %q as OPML", args[0])
+	}
+
+	outlines := doc.Feeds()
+
+	if len(outlines) == 0 {
+		return output.Message(os.Stdout, state.JSON, importResult{Problems: make([]importProblem, 0)}, "No feeds found in %q", args[0])
+	}
+
+	return importOutlines(state, outlines, *skipValidation, *workers, currentUser)
+}
+
+/*
+  - Save the feeds pointed to by the given outlines, making the current
+    user follow each of them, validating them first unless
+    'skipValidation' is set. This is the shared core of 'importopml'
+    and 'bundle install'.
+*/
+func importOutlines(state state, outlines []opml.Outline, skipValidation bool, workers int, currentUser database.User) error {
+	feedURLs := make([]string, len(outlines))
+
+	for i, outline := range outlines {
+		feedURLs[i] = outline.XMLURL
+	}
+
+	ctx := state.ctx
+
+	// Keep the progress indicators out of the way of JSON output.
+	var progressOut io.Writer = os.Stdout
+
+	if state.JSON {
+		progressOut = os.Stderr
+	}
+
+	// Classify every feed before anything is written to the database.
+	var validations []rss.Validation
+
+	if skipValidation {
+		validations = make([]rss.Validation, len(feedURLs))
+
+		for i, feedURL := range feedURLs {
+			validations[i] = rss.Validation{
+				URL:      feedURL,
+				FinalURL: feedURL,
+				Status:   rss.StatusOK,
+			}
+		}
+	} else {
+		bar := progress.New(progressOut, "Validating", len(feedURLs))
+
+		validations = rss.ValidateFeeds(ctx, feedURLs, workers, func(_ rss.Validation) {
+			bar.Increment()
+		})
+
+		bar.Finish()
+	}
+
+	// Build a set of the feeds the current user already follows, so
+	// that re-importing the same file is harmless.
+	follows, err := state.db.GetFeedFollowsForUser(ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	followed := make(map[uuid.UUID]bool, len(follows))
+
+	for _, follow := range follows {
+		followed[follow.FeedID] = true
+	}
+
+	counts := make(map[rss.ValidationStatus]int)
+	bar := progress.New(progressOut, "Importing", len(outlines))
+
+	for i, outline := range outlines {
+		validation := validations[i]
+		counts[validation.Status]++
+
+		if validation.Status != rss.StatusDead {
+			if err = importFeed(ctx, state, outline.Name(), validation.FinalURL, currentUser, followed); err != nil {
+				bar.Finish()
+				return err
+			}
+		}
+
+		bar.Increment()
+	}
+
+	bar.Finish()
+
+	result := importResult{
+		Imported:   counts[rss.StatusOK] + counts[rss.StatusRedirected],
+		OK:         counts[rss.StatusOK],
+		Redirected: counts[rss.StatusRedirected],
+		Dead:       counts[rss.StatusDead],
+		Problems:   make([]importProblem, 0),
+	}
+
+	for _, validation := range validations {
+		switch validation.Status {
+		case rss.StatusDead:
+			result.Problems = append(result.Problems, importProblem{
+				URL:    validation.URL,
+				Status: "dead",
+				Error:  fmt.Sprint(validation.Err),
+			})
+		case rss.StatusRedirected:
+			result.Problems = append(result.Problems, importProblem{
+				URL:      validation.URL,
+				Status:   "redirected",
+				FinalURL: validation.FinalURL,
+			})
+		}
+	}
+
+	// Report the feeds needing attention only after the progress
+	// indicator is done, so as not to garble it.
+	return output.Print(os.Stdout, state.JSON, result, func(w io.Writer) error {
+		for _, problem := range result.Problems {
+			if problem.Status == "dead" {
+				fmt.Fprintf(w, "dead: %s (%s)\n", problem.URL, problem.Error)
+			} else {
+				fmt.Fprintf(w, "redirected: %s -> %s\n", problem.URL, problem.FinalURL)
+			}
+		}
+
+		fmt.Fprintf(w, "Imported %d feeds (ok: %d, redirected: %d, dead: %d)\n",
+			result.Imported, result.OK, result.Redirected, result.Dead)
+
+		return nil
+	})
+}
+
+/** The outcome of 'importopml' and 'bundle install'. */
+type importResult struct {
+	Imported   int             `json:"imported"`
+	OK         int             `json:"ok"`
+	Redirected int             `json:"redirected"`
+	Dead       int             `json:"dead"`
+	Problems   []importProblem `json:"problems"`
+}
+
+/** A feed that was dead, or found elsewhere, when imported. */
+type importProblem struct {
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	FinalURL string `json:"final_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+/*
+  - Save a single feed (unless it already exists), and make
+    'currentUser' follow it (unless they already do.)
+*/
+func importFeed(ctx context.Context, state state, name string, URL string, currentUser database.User, followed map[uuid.UUID]bool) error {
+	feed, err := state.db.GetFeedByURL(ctx, URL)
+
+	if err == sql.ErrNoRows {
+		feed, err = state.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        ids.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+			Url:       URL,
+			UserID:    uuid.NullUUID{UUID: currentUser.ID, Valid: true},
+		})
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to save feed '%s', '%s'", name, URL)
+	}
+
+	if followed[feed.ID] {
+		return nil
+	}
+
+	if _, err = state.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    currentUser.ID,
+		FeedID:    feed.ID,
+	}); err != nil && err != sql.ErrNoRows {
+		return wrapError(err, "Failed to make user %q follow feed %q", currentUser.Name, feed.Name)
+	}
+
+	followed[feed.ID] = true
+
+	return nil
+}
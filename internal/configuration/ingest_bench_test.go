@@ -0,0 +1,63 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+/** How many items each benchmarked batch saves, as 'agg' does by default. */
+const benchBatchSize = DefaultIngestBatchSize
+
+/*
+  - Save batches of new posts, as 'agg' does for each batch it reads
+    from a feed, so that the database half of the scraper pipeline can
+    be measured. Every batch is new, so each one is inserted in full.
+*/
+func BenchmarkSavePosts(b *testing.B) {
+	state := testState(b)
+	feed := testFeed(b, state, testUser(b, state))
+
+	// The URL of each saved post is printed, which would drown out the
+	// benchmark's results.
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	b.Cleanup(func() { os.Stdout = stdout })
+
+	published := time.Now().UTC()
+	next := 0
+
+	b.ResetTimer()
+
+	for range b.N {
+		b.StopTimer()
+		items := make([]rss.RSSItem, 0, benchBatchSize)
+
+		for range benchBatchSize {
+			items = append(items, rss.RSSItem{
+				Title:       fmt.Sprintf("Post number %d", next),
+				Link:        fmt.Sprintf("%s#post-%d", feed.Url, next),
+				Description: strings.Repeat("Lorem ipsum dolor sit amet. ", 40),
+				PubDate:     published.Add(-time.Duration(next) * time.Minute).Format(time.RFC1123Z),
+				Creator:     "Author",
+			})
+
+			next++
+		}
+
+		b.StartTimer()
+
+		added, err := savePosts(state, feed, items, false)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if added != benchBatchSize {
+			b.Fatalf("Saved %d new posts, expected %d", added, benchBatchSize)
+		}
+	}
+}
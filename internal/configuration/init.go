@@ -0,0 +1,178 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"strings"
+)
+
+/** What 'init' did, as reported with --json. */
+type initReport struct {
+	ConfigFile string `json:"config_file"`
+	Migrated   bool   `json:"migrated"`
+	User       string `json:"user,omitempty"`
+}
+
+/*
+  - Write a config file pointing at a working database, so that nobody
+    has to put it together by hand. Whatever isn't given by a flag is
+    asked for on standard input, when that's a terminal: the database
+    URL, whether to apply the migrations now, and the name of a first
+    user to register.
+*/
+func handlerInit(state state, args []string) error {
+	flagSet := flag.NewFlagSet("init", flag.ContinueOnError)
+	dbURL := flagSet.String("db-url", "", "the database's connection string")
+	applyMigrations := flagSet.Bool("migrate", false, "apply the database migrations straight away")
+	username := flagSet.String("register", "", "register (and log in) a first user with this name")
+	withPassword := flagSet.Bool("password", false, "protect the first user with a password")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'init' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'init' command takes no arguments, only flags")
+	}
+
+	interactive := isTerminal(os.Stdin)
+	given := make(map[string]bool)
+
+	flagSet.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	// Offer whatever is already configured as the default.
+	current := state.Config.DbURL
+
+	if current == "" {
+		current = os.Getenv(DbURLVariable)
+	}
+
+	if !given["db-url"] {
+		*dbURL = current
+
+		if interactive {
+			if *dbURL, err = promptLine("Database URL", current); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *dbURL == "" {
+		return fmt.Errorf("The 'init' command needs a database URL: pass --db-url, or run it from a terminal")
+	}
+
+	state.Config.DbURL = *dbURL
+
+	if err = Connect(&state); err != nil {
+		return err
+	}
+
+	if err = state.conn.PingContext(state.ctx); err != nil {
+		return wrapError(err, "Can't reach the database at the given URL")
+	}
+
+	if err = writeConfig(state); err != nil {
+		return wrapError(err, "Failed to write the config file %s", state.ConfigFile)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", state.ConfigFile)
+
+	if !given["register"] && interactive {
+		if *username, err = promptLine("First user to register (blank to skip)", ""); err != nil {
+			return err
+		}
+
+		if *username != "" && !given["password"] {
+			answer, err := promptLine("Protect them with a password? (y/n)", "n")
+
+			if err != nil {
+				return err
+			}
+
+			*withPassword = strings.HasPrefix(strings.ToLower(answer), "y")
+		}
+	}
+
+	if !given["migrate"] && interactive && *username == "" {
+		answer, err := promptLine("Apply the database migrations now? (y/n)", "y")
+
+		if err != nil {
+			return err
+		}
+
+		*applyMigrations = strings.HasPrefix(strings.ToLower(answer), "y")
+	}
+
+	report := initReport{ConfigFile: state.ConfigFile}
+
+	// Registering a user needs the tables to be there.
+	if *applyMigrations || *username != "" {
+		if err = MigrateIfNeeded(state); err != nil {
+			return err
+		}
+
+		report.Migrated = true
+	}
+
+	if *username != "" {
+		if _, err = registerUser(state, *username, *withPassword); err != nil {
+			return err
+		}
+
+		report.User = *username
+	}
+
+	return output.Print(os.Stdout, state.JSON, report, func(w io.Writer) error {
+		if report.User == "" {
+			fmt.Fprintln(w, "gator is set up; register a user with 'register USERNAME'")
+		} else {
+			fmt.Fprintf(w, "gator is set up, and '%s' is logged in; try 'bundle list' next\n", report.User)
+		}
+
+		return nil
+	})
+}
+
+/*
+  - Ask a question on standard error, reading the answer from standard
+    input. A blank answer (or none at all, at the end of the input)
+    means 'fallback', which is shown alongside the question when there
+    is one.
+*/
+func promptLine(question string, fallback string) (string, error) {
+	if fallback != "" {
+		question = fmt.Sprintf("%s [%s]", question, fallback)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+
+	resume := pauseDeadline()
+	line, err := stdin.ReadString('\n')
+	resume()
+
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("Failed to read an answer: %v", err)
+	}
+
+	answer := strings.TrimSpace(line)
+
+	if answer == "" {
+		return fallback, nil
+	}
+
+	return answer, nil
+}
+
+/** Whether the given file is a terminal, rather than, say, a pipe. */
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
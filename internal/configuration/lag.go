@@ -0,0 +1,120 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+  - Report, for each followed feed, how overdue its next fetch is
+    given the fetching interval 'agg' is running with, along with the
+    worst-case staleness across all feeds. This tells whether 'agg' is
+    keeping up.
+*/
+func handlerLag(state state, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'lag' command takes a single FETCHING-INTERVAL argument (the one given to 'agg')")
+	}
+
+	interval, err := time.ParseDuration(args[0])
+
+	if err != nil {
+		return fmt.Errorf("Unable to parse %q as a duration", args[0])
+	}
+
+	feeds, err := state.db.GetFollowedFeeds(state.ctx)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch followed feeds")
+	}
+
+	now := time.Now()
+	report := lagReport{
+		IntervalSeconds: interval.Seconds(),
+		Feeds:           make([]feedLag, 0, len(feeds)),
+	}
+
+	for _, feed := range feeds {
+		// 'agg' no longer fetches broken feeds, so they can't lag.
+		if feedBroken(state, feed) {
+			continue
+		}
+
+		// A feed which was never fetched has been stale ever since it
+		// was added.
+		entry := feedLag{Name: feed.Name}
+		since := feed.CreatedAt
+
+		if feed.LastFetchedAt.Valid {
+			since = feed.LastFetchedAt.Time
+			entry.LastFetchedAt = &since
+		}
+
+		staleness := now.Sub(since)
+		entry.StalenessSeconds = staleness.Seconds()
+
+		if staleness.Seconds() > report.WorstStalenessSeconds {
+			report.WorstStalenessSeconds = staleness.Seconds()
+			report.WorstFeed = feed.Name
+		}
+
+		report.Feeds = append(report.Feeds, entry)
+	}
+
+	return output.Print(os.Stdout, state.JSON, report, func(w io.Writer) error {
+		if len(report.Feeds) == 0 {
+			fmt.Fprintln(w, "<no followed feeds>")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(report.Feeds))
+
+		for _, feed := range report.Feeds {
+			lastFetched := "never"
+
+			if feed.LastFetchedAt != nil {
+				lastFetched = feed.LastFetchedAt.Format(time.DateTime)
+			}
+
+			staleness := seconds(feed.StalenessSeconds)
+			status := fmt.Sprintf("due in %s", (interval - staleness).Round(time.Second))
+
+			if staleness > interval {
+				status = fmt.Sprintf("overdue by %s", (staleness - interval).Round(time.Second))
+			}
+
+			rows = append(rows, []string{feed.Name, lastFetched, status})
+		}
+
+		if err := output.Table(w, []string{"FEED", "LAST FETCHED", "STATUS"}, rows); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "\nWorst-case staleness: %s (%q)\n", seconds(report.WorstStalenessSeconds).Round(time.Second), report.WorstFeed)
+
+		return nil
+	})
+}
+
+/** How far behind 'agg' is, as reported by 'lag'. */
+type lagReport struct {
+	IntervalSeconds       float64   `json:"interval_seconds"`
+	Feeds                 []feedLag `json:"feeds"`
+	WorstStalenessSeconds float64   `json:"worst_staleness_seconds"`
+	WorstFeed             string    `json:"worst_feed"`
+}
+
+/** A single feed's entry in a 'lagReport'. */
+type feedLag struct {
+	Name             string     `json:"name"`
+	LastFetchedAt    *time.Time `json:"last_fetched_at"`
+	StalenessSeconds float64    `json:"staleness_seconds"`
+}
+
+/** Convert a number of seconds back into a duration. */
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
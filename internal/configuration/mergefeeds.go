@@ -0,0 +1,130 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"os"
+)
+
+/*
+  - Merge two rows for the same publication (say, its http and https
+    URLs) into one. The source feed's followers and posts move to the
+    destination feed, posts which the destination already has are
+    dropped, and the source feed is deleted.
+*/
+func handlerMergeFeeds(state state, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("The 'mergefeeds' command takes a SRC-URL and DST-URL argument")
+	}
+
+	src, err := lookUpFeed(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	dst, err := lookUpFeed(state, args[1])
+
+	if err != nil {
+		return err
+	}
+
+	if src.ID == dst.ID {
+		return fmt.Errorf("Can't merge feed %q into itself", src.Url)
+	}
+
+	tx, err := state.conn.BeginTx(state.ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	queries := state.db.WithTx(tx)
+
+	// Followers of both feeds keep their existing follow of the
+	// destination; their follow of the source goes with it.
+	movedFollows, err := queries.MoveFeedFollows(state.ctx, database.MoveFeedFollowsParams{
+		DstFeedID: dst.ID,
+		SrcFeedID: src.ID,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to move the followers of feed %q", src.Url)
+	}
+
+	duplicates, err := queries.DeleteDuplicatePosts(state.ctx, database.DeleteDuplicatePostsParams{
+		SrcFeedID: src.ID,
+		DstFeedID: dst.ID,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to drop the duplicate posts of feed %q", src.Url)
+	}
+
+	movedPosts, err := queries.MovePosts(state.ctx, database.MovePostsParams{
+		DstFeedID: dst.ID,
+		SrcFeedID: src.ID,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to move the posts of feed %q", src.Url)
+	}
+
+	if err = queries.CopyFeedTags(state.ctx, database.CopyFeedTagsParams{
+		DstFeedID: dst.ID,
+		SrcFeedID: src.ID,
+	}); err != nil {
+		return wrapError(err, "Failed to copy the tags of feed %q", src.Url)
+	}
+
+	// Anything else belonging to the source feed (its settings,
+	// snapshots, and pending fetch job) is deleted along with it.
+	if err = queries.DeleteFeed(state.ctx, src.ID); err != nil {
+		return wrapError(err, "Failed to delete feed %q", src.Url)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	refreshUnreadCounts(state)
+
+	merged := mergeResult{
+		From:       src.Url,
+		Into:       dst.Url,
+		Followers:  movedFollows,
+		Posts:      movedPosts,
+		Duplicates: duplicates,
+	}
+
+	return output.Message(os.Stdout, state.JSON, merged, "Merged %q into %q: moved %d followers and %d posts, dropped %d duplicate posts",
+		src.Url, dst.Url, movedFollows, movedPosts, duplicates)
+}
+
+/** The outcome of 'mergefeeds'. */
+type mergeResult struct {
+	From       string `json:"from"`
+	Into       string `json:"into"`
+	Followers  int64  `json:"followers_moved"`
+	Posts      int64  `json:"posts_moved"`
+	Duplicates int64  `json:"duplicates_dropped"`
+}
+
+/** Look up a feed by URL, reporting a missing one as ErrNotFound. */
+func lookUpFeed(state state, url string) (database.Feed, error) {
+	feed, err := state.db.GetFeedByURL(state.ctx, url)
+
+	if err == sql.ErrNoRows {
+		return feed, wrapError(ErrNotFound, "No feed with URL %q (use 'addfeed' to add it)", url)
+	}
+
+	if err != nil {
+		return feed, wrapError(err, "Failed to look up feed %q", url)
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,154 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/migrate"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/sql/schema"
+	"io"
+	"os"
+	"time"
+)
+
+/** A migration, as reported by 'migrate'. */
+type listedMigration struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+/*
+  - Manage the database schema, which is built into gator (see the
+    'migrate' package.)
+
+    The subcommands are:
+
+    up:     apply every migration not yet applied (the default)
+    down:   undo the most recently applied migration
+    status: list every migration, and when it was applied
+*/
+func handlerMigrate(state state, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("The 'migrate' command takes at most a single subcommand: up, down, or status")
+	}
+
+	subcommand := "up"
+
+	if len(args) == 1 {
+		subcommand = args[0]
+	}
+
+	migrations, err := migrate.Load(schema.Migrations)
+
+	if err != nil {
+		return err
+	}
+
+	switch subcommand {
+	case "up":
+		applied, err := migrate.Up(state.ctx, state.conn, migrations)
+
+		if err != nil {
+			return err
+		}
+
+		listed := make([]listedMigration, 0, len(applied))
+
+		for _, migration := range applied {
+			listed = append(listed, listedMigration{Version: migration.Version, Name: migration.Name})
+		}
+
+		return output.Print(os.Stdout, state.JSON, listed, func(w io.Writer) error {
+			if len(listed) == 0 {
+				fmt.Fprintln(w, "The database is already up to date")
+			}
+
+			for _, migration := range listed {
+				fmt.Fprintf(w, "Applied %s\n", migration.Name)
+			}
+
+			return nil
+		})
+	case "down":
+		undone, err := migrate.Down(state.ctx, state.conn, migrations)
+
+		if err != nil {
+			return err
+		}
+
+		if undone == nil {
+			return output.Message(os.Stdout, state.JSON, make([]listedMigration, 0), "No migrations have been applied")
+		}
+
+		return output.Message(os.Stdout, state.JSON, []listedMigration{{Version: undone.Version, Name: undone.Name}}, "Undid %s", undone.Name)
+	case "status":
+		statuses, err := migrate.List(state.ctx, state.conn, migrations)
+
+		if err != nil {
+			return err
+		}
+
+		listed := make([]listedMigration, 0, len(statuses))
+
+		for _, status := range statuses {
+			listed = append(listed, listedMigration{
+				Version:   status.Version,
+				Name:      status.Name,
+				AppliedAt: status.AppliedAt,
+			})
+		}
+
+		return output.Print(os.Stdout, state.JSON, listed, func(w io.Writer) error {
+			table := make([][]string, 0, len(listed))
+
+			for _, migration := range listed {
+				appliedAt := "pending"
+
+				if migration.AppliedAt != nil {
+					appliedAt = migration.AppliedAt.Format(time.DateTime)
+				}
+
+				table = append(table, []string{migration.Name, appliedAt})
+			}
+
+			return output.Table(w, []string{"MIGRATION", "APPLIED"}, table)
+		})
+	default:
+		return fmt.Errorf("Unknown 'migrate' subcommand %q", subcommand)
+	}
+}
+
+/*
+  - Bring the database up to date before running a command, so that
+    a fresh database, or one left behind by an upgrade, just works.
+    Only when there's something to do is the migration lock taken.
+*/
+func MigrateIfNeeded(state state) error {
+	migrations, err := migrate.Load(schema.Migrations)
+
+	if err != nil {
+		return err
+	}
+
+	pending, err := migrate.Pending(state.ctx, state.conn, migrations)
+
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	applied, err := migrate.Up(state.ctx, state.conn, migrations)
+
+	if err != nil {
+		return err
+	}
+
+	if len(applied) > 0 {
+		fmt.Fprintf(os.Stderr, "Applied %d database migrations (through %s)\n", len(applied), applied[len(applied)-1].Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/** A feed's engagement, as reported by 'reading-stats'. */
+type feedEngagement struct {
+	Name   string  `json:"name"`
+	URL    string  `json:"url"`
+	Posts  int64   `json:"posts"`
+	Opened int64   `json:"opened"`
+	Rate   float64 `json:"rate"`
+}
+
+/*
+  - Open the given post in the web browser, recording that it was
+    opened for 'reading-stats', and marking it as read.
+*/
+func handlerOpen(state state, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'open' command takes a single POST-ID argument")
+	}
+
+	postID, err := uuid.Parse(args[0])
+
+	if err != nil {
+		return fmt.Errorf("Can't parse %q as a post ID", args[0])
+	}
+
+	post, err := state.db.GetPostByID(state.ctx, postID)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No post with ID %s", postID)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up post %s", postID)
+	}
+
+	if err = state.db.RecordPostOpen(state.ctx, database.RecordPostOpenParams{
+		ID:       ids.New(),
+		PostID:   post.ID,
+		FeedID:   post.FeedID,
+		OpenedAt: time.Now(),
+		Via:      "cli",
+	}); err != nil {
+		return wrapError(err, "Failed to record the opening of post %s", post.ID)
+	}
+
+	markReadByCurrentUser(state, post.ID)
+
+	url := articleURL(post)
+
+	opened := openedPost{
+		ID:     post.ID,
+		Title:  post.Title,
+		URL:    url,
+		Opened: openInBrowser(url) == nil,
+	}
+
+	return output.Print(os.Stdout, state.JSON, opened, func(w io.Writer) error {
+		// Without a browser to hand the post to, at least show where
+		// it is.
+		if !opened.Opened {
+			fmt.Fprintln(w, url)
+		}
+
+		return nil
+	})
+}
+
+/*
+  - Where to go to read the given post: its canonical URL, if 'agg'
+    found one (see 'resolve_canonical_urls'), or else its link.
+*/
+func articleURL(post database.Post) string {
+	if post.CanonicalUrl != "" {
+		return post.CanonicalUrl
+	}
+
+	return post.Url
+}
+
+/** A post, as reported by 'open'; 'Opened' is whether a browser took it. */
+type openedPost struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	URL    string    `json:"url"`
+	Opened bool      `json:"opened"`
+}
+
+/** Hand the given URL to the desktop's web browser. */
+func openInBrowser(url string) error {
+	var command *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		command = exec.Command("open", url)
+	case "windows":
+		command = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		command = exec.Command("xdg-open", url)
+	}
+
+	return command.Start()
+}
+
+/*
+  - Report, for each feed the current user follows, what share of its
+    posts have been opened (with 'open', or through a short link), to
+    help decide what to unfollow.
+*/
+func handlerReadingStats(state state, args []string, currentUser database.User) error {
+	if len(args) > 0 {
+		return fmt.Errorf("The 'reading-stats' command takes no arguments")
+	}
+
+	rows, err := state.db.GetFeedEngagementForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch reading stats for user %q", currentUser.Name)
+	}
+
+	engagement := make([]feedEngagement, 0, len(rows))
+
+	for _, row := range rows {
+		feed := feedEngagement{
+			Name:   row.Name,
+			URL:    row.Url,
+			Posts:  row.Posts,
+			Opened: row.Opened,
+		}
+
+		if row.Posts > 0 {
+			feed.Rate = float64(row.Opened) / float64(row.Posts)
+		}
+
+		engagement = append(engagement, feed)
+	}
+
+	// The most read feeds first, so that candidates for unfollowing
+	// collect at the bottom.
+	sort.SliceStable(engagement, func(i, j int) bool {
+		return engagement[i].Rate > engagement[j].Rate
+	})
+
+	return output.Print(os.Stdout, state.JSON, engagement, func(w io.Writer) error {
+		rows := make([][]string, 0, len(engagement))
+
+		for _, feed := range engagement {
+			rows = append(rows, []string{
+				feed.Name,
+				strconv.FormatInt(feed.Posts, 10),
+				strconv.FormatInt(feed.Opened, 10),
+				fmt.Sprintf("%.0f%%", feed.Rate*100),
+			})
+		}
+
+		return output.Table(w, []string{"FEED", "POSTS", "OPENED", "RATE"}, rows)
+	})
+}
@@ -0,0 +1,104 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"io"
+	"os"
+	"strconv"
+)
+
+/** How many items list commands show at a time, unless given --all. */
+const listPageSize = 50
+
+/** The --all and --page flags of commands listing feeds, users, and so on. */
+type listPaging struct {
+	all  *bool
+	page *int
+}
+
+func addListPaging(flagSet *flag.FlagSet) listPaging {
+	return listPaging{
+		all:  flagSet.Bool("all", false, "list everything, rather than a page at a time"),
+		page: flagSet.Int("page", 0, fmt.Sprintf("show the given page of %d, counting from 1", listPageSize)),
+	}
+}
+
+/*
+  - Print a page of the given items, as 'output.Print' would. Text
+    output is cut down to the first page unless another is asked for
+    with --page, or everything with --all, and how much is left out is
+    noted on standard error. JSON output, being for scripts, has
+    everything unless a page is asked for.
+*/
+func printPage[T any](state state, paging listPaging, items []T, text func(w io.Writer, items []T) error) error {
+	if *paging.page < 0 {
+		return fmt.Errorf("--page can't be negative")
+	}
+
+	if *paging.all && *paging.page > 0 {
+		return fmt.Errorf("Either --all or --page can be given, not both")
+	}
+
+	page := *paging.page
+
+	if page == 0 && !*paging.all && !state.JSON {
+		page = 1
+	}
+
+	shown := items
+	note := ""
+
+	if page > 0 {
+		start := min((page-1)*listPageSize, len(items))
+		end := min(start+listPageSize, len(items))
+		shown = items[start:end]
+
+		switch {
+		case start == end && len(items) > 0:
+			note = fmt.Sprintf("Page %d is past the end (there are %s in all)", page, countPages(len(items)))
+		case start > 0 || end < len(items):
+			note = fmt.Sprintf("Showing %s–%s of %s", groupThousands(start+1), groupThousands(end), groupThousands(len(items)))
+
+			if end < len(items) {
+				note += fmt.Sprintf(" (use --all, or --page %d for more)", page+1)
+			}
+		}
+	}
+
+	if err := output.Print(os.Stdout, state.JSON, shown, func(w io.Writer) error {
+		return text(w, shown)
+	}); err != nil {
+		return err
+	}
+
+	if note != "" {
+		fmt.Fprintln(os.Stderr, note)
+	}
+
+	return nil
+}
+
+func countPages(items int) string {
+	pages := (items + listPageSize - 1) / listPageSize
+
+	if pages == 1 {
+		return "1 page"
+	}
+
+	return groupThousands(pages) + " pages"
+}
+
+/** Write a count with commas between groups of thousands, as in "3,214". */
+func groupThousands(n int) string {
+	digits := strconv.Itoa(n)
+	grouped := ""
+
+	for len(digits) > 3 {
+		grouped = "," + digits[len(digits)-3:] + grouped
+		digits = digits[:len(digits)-3]
+	}
+
+	return digits + grouped
+}
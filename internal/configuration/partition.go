@@ -0,0 +1,537 @@
+package configuration
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/progress"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  - Monthly partitions are named after the month they hold, for
+    example "posts_y2025m01". Posts falling outside every monthly
+    partition land in "posts_default".
+*/
+const (
+	partitionNameLayout  = "posts_y2006m01"
+	defaultPartitionName = "posts_default"
+)
+
+/*
+  - How many months ahead partitions are created for, by default by
+    'partition', and always while 'agg' or 'worker' runs.
+*/
+const defaultPartitionsAhead = 3
+
+/*
+  - Manage optional monthly partitioning of the 'posts' table, so that
+    pruning an old month is a cheap DROP of its partition rather than
+    a massive DELETE.
+
+    The subcommands are:
+
+    enable: convert 'posts' into a partitioned table
+    ensure: create the partitions for upcoming months
+    list:   show the existing partitions
+    drop:   drop every monthly partition before a given month
+*/
+func handlerPartition(state state, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("The 'partition' command takes a subcommand: enable, ensure, list, or drop")
+	}
+
+	flagSet := flag.NewFlagSet("partition", flag.ContinueOnError)
+	ahead := flagSet.Int("ahead", defaultPartitionsAhead, "how many months ahead to create partitions for")
+	before := flagSet.String("before", "", "drop partitions for months before this one (YYYY-MM)")
+
+	subcommand := args[0]
+	args, err := parseFlags(flagSet, args[1:])
+
+	if err != nil {
+		return fmt.Errorf("The 'partition' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'partition %s' command takes no arguments", subcommand)
+	}
+
+	partitioned, err := postsArePartitioned(state.ctx, state.conn)
+
+	if err != nil {
+		return err
+	}
+
+	if subcommand == "enable" {
+		if partitioned {
+			return output.Message(os.Stdout, state.JSON, partitionResult{Partitions: make([]string, 0)}, "The posts table is already partitioned")
+		}
+
+		return enablePartitioning(state, *ahead)
+	}
+
+	if !partitioned {
+		return fmt.Errorf("The posts table isn't partitioned (use 'partition enable' first)")
+	}
+
+	switch subcommand {
+	case "ensure":
+		return ensurePartitions(state.ctx, state.conn, time.Now(), *ahead)
+	case "list":
+		return listPartitions(state)
+	case "drop":
+		if *before == "" {
+			return fmt.Errorf("The 'partition drop' command requires --before YYYY-MM")
+		}
+
+		cutoff, err := time.Parse("2006-01", *before)
+
+		if err != nil {
+			return fmt.Errorf("Can't parse %q as a YYYY-MM month", *before)
+		}
+
+		return dropPartitions(state, cutoff)
+	default:
+		return fmt.Errorf("Unknown 'partition' subcommand %q", subcommand)
+	}
+}
+
+func postsArePartitioned(ctx context.Context, conn *sql.DB) (bool, error) {
+	var partitioned bool
+
+	err := conn.QueryRowContext(ctx, `
+SELECT EXISTS (
+       SELECT 1 FROM pg_partitioned_table
+       WHERE partrelid = 'posts'::regclass
+)`).Scan(&partitioned)
+
+	if err != nil {
+		return false, wrapError(err, "Failed to check whether posts are partitioned")
+	}
+
+	return partitioned, nil
+}
+
+/** The partition covering the month containing 't', and its bounds. */
+func monthPartition(t time.Time) (string, time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return start.Format(partitionNameLayout), start, start.AddDate(0, 1, 0)
+}
+
+/** Anything satisfied by both *sql.DB and *sql.Tx. */
+type execer interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+/*
+  - Create the monthly partitions from the month containing 'from'
+    through 'ahead' months later, skipping those that already exist.
+*/
+func ensurePartitions(ctx context.Context, conn execer, from time.Time, ahead int) error {
+	for i := 0; i <= ahead; i++ {
+		name, start, end := monthPartition(from.AddDate(0, i, 0))
+
+		if err := createPartition(ctx, conn, name, start, end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+  - Create the partition of posts for the given month, unless it
+    already exists. Posts of that month saved before then are in the
+    default partition, which Postgres won't let the new partition
+    take over from while it's attached, so it's detached until
+    they've been moved across. Being a single statement, this all
+    happens in one transaction, and posts are locked throughout, so
+    that other processes doing the same wait and then find it done.
+*/
+func createPartition(ctx context.Context, conn execer, name string, start time.Time, end time.Time) error {
+	statement := fmt.Sprintf(`
+DO $$
+BEGIN
+       IF to_regclass('%[1]s') IS NULL THEN
+              LOCK TABLE posts IN ACCESS EXCLUSIVE MODE;
+
+              IF to_regclass('%[1]s') IS NULL THEN
+                     ALTER TABLE posts DETACH PARTITION %[2]s;
+                     CREATE TABLE %[1]s PARTITION OF posts FOR VALUES FROM ('%[3]s') TO ('%[4]s');
+                     WITH moved AS (
+                            DELETE FROM %[2]s
+                            WHERE published_at >= '%[3]s' AND published_at < '%[4]s'
+                            RETURNING *
+                     )
+                     INSERT INTO %[1]s SELECT * FROM moved;
+                     ALTER TABLE posts ATTACH PARTITION %[2]s DEFAULT;
+              END IF;
+       END IF;
+END
+$$`, name, defaultPartitionName, start.Format(time.DateOnly), end.Format(time.DateOnly))
+
+	if _, err := conn.ExecContext(ctx, statement); err != nil {
+		return wrapError(err, "Failed to create partition %s", name)
+	}
+
+	return nil
+}
+
+/*
+  - While 'agg' or 'worker' runs, create partitions ahead of time as
+    'partition ensure' would: once when it starts, and then daily
+    until 'ctx' is done, so that new months' posts don't pile up in
+    the default partition. Does nothing unless posts are partitioned.
+*/
+func keepPartitionsAhead(ctx context.Context, state state) {
+	go func() {
+		for {
+			partitioned, err := postsArePartitioned(state.ctx, state.conn)
+
+			if err == nil && partitioned {
+				err = ensurePartitions(state.ctx, state.conn, time.Now(), defaultPartitionsAhead)
+			}
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create upcoming partitions: %v\n", err)
+			}
+
+			sleepUntil(ctx, time.Now().Add(24*time.Hour))
+
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+/*
+  - Convert 'posts' into a table partitioned by month of publication,
+    all inside a single transaction.
+
+    Note that Postgres requires the partitioning column to be part of
+    every unique constraint, so that once partitioned, posts are
+    constrained on (url, published_at) rather than on url alone. The
+    scraper (see 'createPosts') looks for each URL itself instead,
+    under a lock on it, so it still saves each URL only once.
+    Likewise, 'seq' is indexed rather than unique, though its sequence
+    keeps it unique all the same.
+*/
+func enablePartitioning(state state, ahead int) error {
+	// Tables referencing posts(id) would need it to stay unique on
+	// its own, which a partitioned table can't guarantee.
+	var referencing int
+
+	if err := state.conn.QueryRowContext(state.ctx, `
+SELECT COUNT(*) FROM pg_constraint
+WHERE confrelid = 'posts'::regclass`).Scan(&referencing); err != nil {
+		return wrapError(err, "Failed to look up references to posts")
+	}
+
+	if referencing > 0 {
+		return fmt.Errorf("Can't partition posts while other tables reference them by ID")
+	}
+
+	tx, err := state.conn.BeginTx(state.ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// Materialized views over posts have to be recreated on top of
+	// the new table, so save their definitions (and indexes) first.
+	recreate, err := dependentViewStatements(state.ctx, tx)
+
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		"ALTER TABLE posts RENAME TO posts_unpartitioned",
+		`CREATE TABLE posts (
+       LIKE posts_unpartitioned INCLUDING DEFAULTS,
+       FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE
+) PARTITION BY RANGE (published_at)`,
+		fmt.Sprintf("CREATE TABLE %s PARTITION OF posts DEFAULT", defaultPartitionName),
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(state.ctx, statement); err != nil {
+			return wrapError(err, "Failed to partition posts")
+		}
+	}
+
+	// Every month with existing posts needs its partition before the
+	// posts are copied over.
+	rows, err := tx.QueryContext(state.ctx, `
+SELECT DISTINCT date_trunc('month', published_at) FROM posts_unpartitioned`)
+
+	if err != nil {
+		return wrapError(err, "Failed to find the months of existing posts")
+	}
+
+	months := make([]time.Time, 0)
+
+	for rows.Next() {
+		var month time.Time
+
+		if err = rows.Scan(&month); err != nil {
+			rows.Close()
+			return err
+		}
+
+		months = append(months, month)
+	}
+
+	rows.Close()
+
+	for _, month := range months {
+		name, start, end := monthPartition(month)
+
+		if err = createPartition(state.ctx, tx, name, start, end); err != nil {
+			return err
+		}
+	}
+
+	if err = ensurePartitions(state.ctx, tx, time.Now(), ahead); err != nil {
+		return err
+	}
+
+	statements = []string{
+		"INSERT INTO posts SELECT * FROM posts_unpartitioned",
+	}
+
+	statements = append(statements, recreate.drop...)
+	statements = append(statements,
+		// The new table's 'seq' draws on the old one's sequence, which
+		// would otherwise go with it.
+		"ALTER SEQUENCE posts_seq_seq OWNED BY posts.seq",
+		"DROP TABLE posts_unpartitioned",
+		"ALTER TABLE posts ADD PRIMARY KEY (id, published_at)",
+		"ALTER TABLE posts ADD CONSTRAINT posts_url_key UNIQUE (url, published_at)",
+		"CREATE INDEX posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC)",
+		"CREATE INDEX posts_seq_idx ON posts (seq)",
+		"CREATE INDEX posts_lower_author_idx ON posts (lower(author))",
+		"CREATE INDEX posts_dedupe_key_idx ON posts (dedupe_key)",
+		"CREATE INDEX posts_search_idx ON posts USING GIN (to_tsvector('english', title || ' ' || description))",
+	)
+
+	statements = append(statements, recreate.create...)
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(state.ctx, statement); err != nil {
+			return wrapError(err, "Failed to partition posts")
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	created := partitionResult{Partitions: make([]string, 0, len(months))}
+
+	for _, month := range months {
+		name, _, _ := monthPartition(month)
+		created.Partitions = append(created.Partitions, name)
+	}
+
+	return output.Message(os.Stdout, state.JSON, created, "Partitioned posts into %d monthly partitions", len(months))
+}
+
+/** The partitions created by 'partition enable', or dropped by 'partition drop'. */
+type partitionResult struct {
+	Partitions []string `json:"partitions"`
+}
+
+/** A partition, as reported by 'partition list'. */
+type listedPartition struct {
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+/** Statements for dropping and then recreating materialized views. */
+type viewStatements struct {
+	drop   []string
+	create []string
+}
+
+func dependentViewStatements(ctx context.Context, tx *sql.Tx) (viewStatements, error) {
+	statements := viewStatements{}
+
+	rows, err := tx.QueryContext(ctx, `
+SELECT matviewname, definition FROM pg_matviews
+WHERE schemaname = current_schema()`)
+
+	if err != nil {
+		return statements, wrapError(err, "Failed to look up materialized views")
+	}
+
+	defer rows.Close()
+
+	names := make([]string, 0)
+
+	for rows.Next() {
+		var name, definition string
+
+		if err = rows.Scan(&name, &definition); err != nil {
+			return statements, err
+		}
+
+		names = append(names, name)
+		statements.drop = append(statements.drop, fmt.Sprintf("DROP MATERIALIZED VIEW %s", name))
+		statements.create = append(statements.create, fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS %s", name, definition))
+	}
+
+	if err = rows.Close(); err != nil {
+		return statements, err
+	}
+
+	for _, name := range names {
+		indexRows, err := tx.QueryContext(ctx, `
+SELECT indexdef FROM pg_indexes
+WHERE schemaname = current_schema() AND tablename = $1`, name)
+
+		if err != nil {
+			return statements, wrapError(err, "Failed to look up indexes of %s", name)
+		}
+
+		for indexRows.Next() {
+			var indexdef string
+
+			if err = indexRows.Scan(&indexdef); err != nil {
+				indexRows.Close()
+				return statements, err
+			}
+
+			statements.create = append(statements.create, indexdef)
+		}
+
+		indexRows.Close()
+	}
+
+	return statements, nil
+}
+
+/** The partitions of 'posts', in name (and so chronological) order. */
+func partitionNames(ctx context.Context, conn *sql.DB) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, `
+SELECT pg_class.relname FROM pg_inherits
+INNER JOIN pg_class
+ON pg_class.oid = pg_inherits.inhrelid
+WHERE pg_inherits.inhparent = 'posts'::regclass
+ORDER BY pg_class.relname`)
+
+	if err != nil {
+		return nil, wrapError(err, "Failed to list partitions")
+	}
+
+	defer rows.Close()
+
+	names := make([]string, 0)
+
+	for rows.Next() {
+		var name string
+
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func listPartitions(state state) error {
+	names, err := partitionNames(state.ctx, state.conn)
+
+	if err != nil {
+		return err
+	}
+
+	partitions := make([]listedPartition, 0, len(names))
+
+	for _, name := range names {
+		var count int64
+
+		if err = state.conn.QueryRowContext(state.ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", name)).Scan(&count); err != nil {
+			return wrapError(err, "Failed to count posts in %s", name)
+		}
+
+		partitions = append(partitions, listedPartition{Name: name, Posts: count})
+	}
+
+	return output.Print(os.Stdout, state.JSON, partitions, func(w io.Writer) error {
+		rows := make([][]string, 0, len(partitions))
+
+		for _, partition := range partitions {
+			rows = append(rows, []string{partition.Name, strconv.FormatInt(partition.Posts, 10)})
+		}
+
+		return output.Table(w, []string{"PARTITION", "POSTS"}, rows)
+	})
+}
+
+/** Drop every monthly partition holding months before 'cutoff'. */
+func dropPartitions(state state, cutoff time.Time) error {
+	names, err := partitionNames(state.ctx, state.conn)
+
+	if err != nil {
+		return err
+	}
+
+	doomed := make([]string, 0)
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, "posts_y") {
+			continue
+		}
+
+		month, err := time.Parse(partitionNameLayout, name)
+
+		if err == nil && month.Before(cutoff) {
+			doomed = append(doomed, name)
+		}
+	}
+
+	// Keep the progress indicator out of the way of JSON output.
+	var progressOut io.Writer = os.Stdout
+
+	if state.JSON {
+		progressOut = os.Stderr
+	}
+
+	dropped := partitionResult{Partitions: make([]string, 0, len(doomed))}
+	bar := progress.New(progressOut, "Dropping", len(doomed))
+
+	for _, name := range doomed {
+		if _, err = state.conn.ExecContext(state.ctx, fmt.Sprintf("DROP TABLE %s", name)); err != nil {
+			bar.Finish()
+			return wrapError(err, "Failed to drop partition %s", name)
+		}
+
+		dropped.Partitions = append(dropped.Partitions, name)
+		bar.Increment()
+	}
+
+	bar.Finish()
+
+	return output.Print(os.Stdout, state.JSON, dropped, func(w io.Writer) error {
+		for _, name := range dropped.Partitions {
+			fmt.Fprintf(w, "Dropped %s\n", name)
+		}
+
+		fmt.Fprintf(w, "Dropped %d partitions\n", len(dropped.Partitions))
+
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"os"
+	"time"
+)
+
+/** Stop 'agg' from fetching the given feed until it's resumed. */
+func handlerPause(state state, args []string) error {
+	return setPaused(state, "pause", args, true)
+}
+
+func handlerResume(state state, args []string) error {
+	return setPaused(state, "resume", args, false)
+}
+
+func setPaused(state state, commandName string, args []string, paused bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The '%s' command takes a single FEED-URL argument", commandName)
+	}
+
+	feed, err := lookUpFeed(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if err = state.db.SetFeedPaused(state.ctx, database.SetFeedPausedParams{
+		FeedID:    feed.ID,
+		UpdatedAt: time.Now(),
+		PausedAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: paused,
+		},
+	}); err != nil {
+		return wrapError(err, "Failed to %s feed %q", commandName, feed.Name)
+	}
+
+	return output.Print(os.Stdout, state.JSON, pausedFeed{Name: feed.Name, URL: feed.Url, Paused: paused}, output.Nothing)
+}
+
+/** A feed, as reported by 'pause' and 'resume'. */
+type pausedFeed struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Paused bool   `json:"paused"`
+}
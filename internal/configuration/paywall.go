@@ -0,0 +1,53 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"os"
+	"time"
+)
+
+/*
+  - Flag the given feed as paywalled (or with '--clear', no longer
+    so), so that 'browse' marks all of its posts, rather than only
+    those whose content gives them away.
+*/
+func handlerPaywall(state state, args []string) error {
+	flagSet := flag.NewFlagSet("paywall", flag.ContinueOnError)
+	clear := flagSet.Bool("clear", false, "no longer treat the feed as paywalled")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'paywall' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'paywall' command takes a single FEED-URL argument")
+	}
+
+	feed, err := lookUpFeed(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if err = state.db.SetFeedPaywalled(state.ctx, database.SetFeedPaywalledParams{
+		FeedID:    feed.ID,
+		UpdatedAt: time.Now(),
+		Paywalled: !*clear,
+	}); err != nil {
+		return wrapError(err, "Failed to flag feed %q", feed.Name)
+	}
+
+	return output.Print(os.Stdout, state.JSON, paywalledFeed{Name: feed.Name, URL: feed.Url, Paywalled: !*clear}, output.Nothing)
+}
+
+/** A feed, as reported by 'paywall'. */
+type paywalledFeed struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Paywalled bool   `json:"paywalled"`
+}
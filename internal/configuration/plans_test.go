@@ -0,0 +1,139 @@
+package configuration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"strings"
+	"testing"
+)
+
+/** Returned in place of a query's rows once its plan has been recorded. */
+var errExplained = errors.New("query explained rather than run")
+
+/*
+  - A database handle which, instead of running the queries given to it,
+    records their plans, so that the plans of the real queries (with
+    their real parameters) can be checked.
+*/
+type explainer struct {
+	tx   *sql.Tx
+	plan []string
+}
+
+func (e *explainer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.tx.ExecContext(ctx, query, args...)
+}
+
+func (e *explainer) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return e.tx.PrepareContext(ctx, query)
+}
+
+func (e *explainer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := e.tx.QueryContext(ctx, "EXPLAIN "+query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var line string
+
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+
+		e.plan = append(e.plan, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errExplained
+}
+
+func (e *explainer) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return e.tx.QueryRowContext(ctx, query, args...)
+}
+
+/*
+  - Plan a query through 'run', with sequential scans and sorts made as
+    expensive as possible, so that the planner picks an index whenever
+    one applies, however little data the scratch database holds.
+*/
+func explain(t *testing.T, state state, run func(*database.Queries) error) string {
+	t.Helper()
+
+	tx, err := state.conn.BeginTx(state.ctx, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	for _, setting := range []string{"SET LOCAL enable_seqscan = off", "SET LOCAL enable_sort = off"} {
+		if _, err = tx.ExecContext(state.ctx, setting); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := &explainer{tx: tx}
+
+	if err = run(database.New(e)); !errors.Is(err, errExplained) {
+		t.Fatalf("Failed to explain the query: %v", err)
+	}
+
+	return strings.Join(e.plan, "\n")
+}
+
+/*
+  - Check that 'browse' and fetch scheduling are planned with the
+    indexes added for them, rather than by scanning whole tables. On a
+    partitioned 'posts' table the index of each partition is named
+    after the partition, so only the ends of the names are matched.
+*/
+func TestReadPathPlans(t *testing.T) {
+	state := testState(t)
+	user := testUser(t, state)
+	testFeed(t, state, user)
+
+	t.Run("browse", func(t *testing.T) {
+		plan := explain(t, state, func(q *database.Queries) error {
+			_, err := q.GetPostsForUser(state.ctx, database.GetPostsForUserParams{
+				UserID:     user.ID,
+				UnreadOnly: true,
+				OrderBy:    "published",
+				Limit:      20,
+			})
+
+			return err
+		})
+
+		if !strings.Contains(plan, "feed_id_published_at_idx") {
+			t.Errorf("The browse query doesn't use posts_feed_id_published_at_idx:\n%s", plan)
+		}
+	})
+
+	t.Run("scheduling", func(t *testing.T) {
+		plan := explain(t, state, func(q *database.Queries) error {
+			_, err := q.GetNextNFeedsToFetch(state.ctx, database.GetNextNFeedsToFetchParams{
+				MaxFailures:     maxFeedFailures(state),
+				IntervalSeconds: 60,
+				Limit:           4,
+			})
+
+			return err
+		})
+
+		for _, index := range []string{"feeds_last_fetched_at_idx", "feed_follows_feed_id_idx"} {
+			if !strings.Contains(plan, index) {
+				t.Errorf("The scheduling query doesn't use %s:\n%s", index, plan)
+			}
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+  - Tag the given post (by ID or URL), for building reading lists such
+    as "toread" that 'browse --tag' then shows. With '--remove', the
+    tag is taken off instead, and without a tag, the post's tags are
+    listed.
+*/
+func handlerTag(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("tag", flag.ContinueOnError)
+	remove := flagSet.Bool("remove", false, "take the tag off the post")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'tag' command: %v", err)
+	}
+
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("The 'tag' command takes a POST-ID or POST-URL argument, and optionally a TAG")
+	}
+
+	post, err := lookUpPost(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if *remove {
+			return fmt.Errorf("The 'tag --remove' command needs the TAG to remove")
+		}
+
+		return listPostTags(state, currentUser, post)
+	}
+
+	tag := strings.ToLower(strings.TrimSpace(args[1]))
+
+	if tag == "" {
+		return fmt.Errorf("A tag can't be empty")
+	}
+
+	result := postTag{ID: post.ID, Title: post.Title, Tag: tag, Tagged: !*remove}
+
+	if *remove {
+		count, err := state.db.RemovePostTag(state.ctx, database.RemovePostTagParams{
+			UserID: currentUser.ID,
+			PostID: post.ID,
+			Tag:    tag,
+		})
+
+		if err != nil {
+			return wrapError(err, "Failed to untag post %q", post.Title)
+		}
+
+		if count == 0 {
+			return output.Message(os.Stdout, state.JSON, result, "%q wasn't tagged %q", post.Title, tag)
+		}
+
+		return output.Message(os.Stdout, state.JSON, result, "Untagged %q as %q", post.Title, tag)
+	}
+
+	if err = state.db.AddPostTag(state.ctx, database.AddPostTagParams{
+		UserID:    currentUser.ID,
+		PostID:    post.ID,
+		Tag:       tag,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return wrapError(err, "Failed to tag post %q", post.Title)
+	}
+
+	return output.Message(os.Stdout, state.JSON, result, "Tagged %q as %q", post.Title, tag)
+}
+
+/** A post's tag, as reported by 'tag'. */
+type postTag struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Tag    string    `json:"tag"`
+	Tagged bool      `json:"tagged"`
+}
+
+func listPostTags(state state, currentUser database.User, post database.Post) error {
+	tags, err := state.db.GetPostTags(state.ctx, database.GetPostTagsParams{
+		UserID: currentUser.ID,
+		PostID: post.ID,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the tags of post %q", post.Title)
+	}
+
+	if tags == nil {
+		tags = make([]string, 0)
+	}
+
+	return output.Print(os.Stdout, state.JSON, tags, func(w io.Writer) error {
+		for _, tag := range tags {
+			fmt.Fprintln(w, tag)
+		}
+
+		return nil
+	})
+}
+
+/** Put a category or tag into the form tags are kept in. */
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+/*
+  - Turn the categories a feed gives one of its items into tags, as
+    'category_tags' in the config file says: each category is looked
+    up there (in any case) and replaced by the tag it names, or
+    dropped if that's empty. Categories not listed are used as they
+    are.
+*/
+func categoryTags(state state, categories []string) []string {
+	renames := make(map[string]string, len(state.Config.CategoryTags))
+
+	for category, tag := range state.Config.CategoryTags {
+		renames[normalizeTag(category)] = normalizeTag(tag)
+	}
+
+	tags := make([]string, 0, len(categories))
+
+	for _, category := range categories {
+		tag := normalizeTag(category)
+
+		if renamed, ok := renames[tag]; ok {
+			tag = renamed
+		}
+
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,24 @@
+package configuration
+
+import (
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+)
+
+/*
+  - Serve the runtime profiling endpoints (under /debug/pprof/) at the
+    given address, for example ":6060". This runs in the background
+    for the lifetime of the process; a failure to listen is reported
+    but isn't fatal to the command being profiled.
+*/
+func startProfiler(addr string) {
+	go func() {
+		fmt.Fprintf(os.Stderr, "Serving pprof endpoints at %s/debug/pprof/\n", addr)
+
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "pprof server stopped: %v\n", err)
+		}
+	}()
+}
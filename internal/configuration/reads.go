@@ -0,0 +1,115 @@
+package configuration
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"os"
+	"time"
+)
+
+/** Mark the given post (by ID or URL) as read, hiding it from 'browse'. */
+func handlerRead(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'read' command takes a single POST-ID or POST-URL argument")
+	}
+
+	post, err := lookUpPost(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+		UserID: currentUser.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	}); err != nil {
+		return wrapError(err, "Failed to mark post %q as read", post.Title)
+	}
+
+	return output.Message(os.Stdout, state.JSON, readState{ID: post.ID, Title: post.Title, Read: true}, "Marked %q as read", post.Title)
+}
+
+/** A post's read state, as reported by 'read' and 'unread'. */
+type readState struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Read  bool      `json:"read"`
+}
+
+/** Mark the given post (by ID or URL) as unread again. */
+func handlerUnread(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'unread' command takes a single POST-ID or POST-URL argument")
+	}
+
+	post, err := lookUpPost(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	count, err := state.db.MarkPostUnread(state.ctx, database.MarkPostUnreadParams{
+		UserID: currentUser.ID,
+		PostID: post.ID,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to mark post %q as unread", post.Title)
+	}
+
+	result := readState{ID: post.ID, Title: post.Title, Read: false}
+
+	if count == 0 {
+		return output.Message(os.Stdout, state.JSON, result, "%q wasn't marked as read", post.Title)
+	}
+
+	return output.Message(os.Stdout, state.JSON, result, "Marked %q as unread", post.Title)
+}
+
+/** Look up a post given either its ID (as shown by 'browse') or its URL. */
+func lookUpPost(state state, idOrURL string) (database.Post, error) {
+	var post database.Post
+
+	postID, err := uuid.Parse(idOrURL)
+
+	if err == nil {
+		post, err = state.db.GetPostByID(state.ctx, postID)
+	} else {
+		post, err = state.db.GetPostByURL(state.ctx, idOrURL)
+	}
+
+	if err == sql.ErrNoRows {
+		return database.Post{}, wrapError(ErrNotFound, "No post with ID or URL %q", idOrURL)
+	}
+
+	if err != nil {
+		return database.Post{}, wrapError(err, "Failed to look up post %q", idOrURL)
+	}
+
+	return post, nil
+}
+
+/*
+  - Mark the given post as read by the current user, if anyone is
+    logged in. This accompanies opening a post, and isn't worth
+    failing over, so problems are only reported.
+*/
+func markReadByCurrentUser(state state, postID uuid.UUID) {
+	currentUser, err := loggedInUser(state)
+
+	if err != nil {
+		return
+	}
+
+	if err = state.db.MarkPostRead(state.ctx, database.MarkPostReadParams{
+		UserID: currentUser.ID,
+		PostID: postID,
+		ReadAt: time.Now(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to mark post %s as read: %v\n", postID, err)
+	}
+}
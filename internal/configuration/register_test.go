@@ -0,0 +1,148 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+/** How many commands the tests below run at once. */
+const racers = 16
+
+/*
+  - A copy of 'state' with a config of its own, as each process running
+    a command would have, but sharing the config file.
+*/
+func racingState(state state) state {
+	config := *state.Config
+	state.Config = &config
+
+	return state
+}
+
+/*
+  - Write the config file from many goroutines at once, as concurrent
+    'login's would, and check that it's left whole, as one of them
+    wrote it, with none of the temporary files left behind.
+*/
+func TestConcurrentConfigWrites(t *testing.T) {
+	base := state{
+		ConfigFile: filepath.Join(t.TempDir(), "config.json"),
+		Config:     &Config{DbURL: "postgres://localhost/gator"},
+	}
+
+	names := make(map[string]bool, racers)
+	var wg sync.WaitGroup
+
+	for i := range racers {
+		name := fmt.Sprintf("user-%d", i)
+		names[name] = true
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := SetUser(racingState(base), name, ""); err != nil {
+				t.Errorf("Failed to write the config as %q: %v", name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	read := state{ConfigFile: base.ConfigFile, Config: &Config{}}
+
+	if err := Read(read); err != nil {
+		t.Fatalf("The config file doesn't parse: %v", err)
+	}
+
+	if !names[read.Config.CurrentUserName] {
+		t.Errorf("The config file names %q, which no writer wrote", read.Config.CurrentUserName)
+	}
+
+	if read.Config.DbURL != base.Config.DbURL {
+		t.Errorf("The config file's db_url is %q, expected %q", read.Config.DbURL, base.Config.DbURL)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(base.ConfigFile))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("Expected only the config file, found %d files", len(entries))
+	}
+}
+
+/*
+  - Register the same name from many goroutines at once, and check that
+    exactly one of them gets it, that the rest are told it's taken,
+    and that the config file they all write is left whole.
+*/
+func TestConcurrentRegisters(t *testing.T) {
+	base := testState(t)
+	name := "race-" + ids.New().String()[:8]
+
+	t.Cleanup(func() {
+		if user, err := base.db.GetUser(base.ctx, name); err == nil {
+			deleteTestRows(t, base, "users", user.ID.String())
+		}
+	})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	registered, taken := 0, 0
+
+	for range racers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := registerUser(racingState(base), name, false)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				registered++
+			case errors.Is(err, ErrAlreadyExists):
+				taken++
+			default:
+				t.Errorf("Failed to register %q: %v", name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if registered != 1 || taken != racers-1 {
+		t.Errorf("%d registers succeeded and %d found the name taken, expected 1 and %d", registered, taken, racers-1)
+	}
+
+	var rows int
+
+	if err := base.conn.QueryRowContext(base.ctx, "SELECT COUNT(*) FROM users WHERE name = $1", name).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if rows != 1 {
+		t.Errorf("Found %d users named %q, expected 1", rows, name)
+	}
+
+	read := state{ConfigFile: base.ConfigFile, Config: &Config{}}
+
+	if err := Read(read); err != nil {
+		t.Fatalf("The config file doesn't parse: %v", err)
+	}
+
+	if read.Config.CurrentUserName != name {
+		t.Errorf("The config file names %q, expected %q", read.Config.CurrentUserName, name)
+	}
+}
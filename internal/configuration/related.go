@@ -0,0 +1,95 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"time"
+)
+
+/** A post, as reported by 'related'. */
+type relatedPost struct {
+	ID          uuid.UUID `json:"id"`
+	Feed        string    `json:"feed"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+/*
+  - List the posts in the current user's feeds which are most similar
+    to the given one, as judged by full-text search on the words of
+    its title. This is handy for following a story as it develops.
+*/
+func handlerRelated(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("related", flag.ContinueOnError)
+	limit := flagSet.Int("limit", 10, "maximum number of posts to show")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'related' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'related' command takes a single POST-ID argument")
+	}
+
+	postID, err := uuid.Parse(args[0])
+
+	if err != nil {
+		return fmt.Errorf("Can't parse %q as a post ID", args[0])
+	}
+
+	post, err := state.db.GetPostByID(state.ctx, postID)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No post with ID %s", postID)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up post %s", postID)
+	}
+
+	posts, err := state.db.GetRelatedPosts(state.ctx, database.GetRelatedPostsParams{
+		ID:     post.ID,
+		UserID: currentUser.ID,
+		Limit:  int32(*limit),
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to find posts related to %q", post.Title)
+	}
+
+	related := make([]relatedPost, 0, len(posts))
+
+	for _, post := range posts {
+		related = append(related, relatedPost{
+			ID:          post.ID,
+			Feed:        post.Feedname,
+			Title:       post.Title,
+			URL:         post.Url,
+			PublishedAt: post.PublishedAt,
+		})
+	}
+
+	return output.Print(os.Stdout, state.JSON, related, func(w io.Writer) error {
+		if len(related) == 0 {
+			fmt.Fprintf(w, "No posts related to %q\n", post.Title)
+			return nil
+		}
+
+		rows := make([][]string, 0, len(related))
+
+		for _, post := range related {
+			rows = append(rows, []string{post.PublishedAt.Format(time.DateOnly), post.Feed, post.Title, post.ID.String()})
+		}
+
+		return output.Table(w, []string{"PUBLISHED", "FEED", "TITLE", "ID"}, rows)
+	})
+}
@@ -0,0 +1,230 @@
+package configuration
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+  - Commands which can't take part in an '--atomic' script, because
+    they manage their own transactions or talk to the connection pool
+    directly, and so would escape the script's transaction.
+*/
+var nonTransactionalCommands = map[string]bool{
+	"mergefeeds": true,
+	"partition":  true,
+}
+
+/*
+  - Run the gator commands listed in a script file (or standard input,
+    if no file, or "-", is given), one per line, in a single process.
+    Blank lines and lines starting with '#' are ignored.
+
+    By default, the first failing command stops the script; with
+    '--keep-going', failures are reported and the script continues.
+    With '--atomic', the whole script runs in one transaction, which
+    is rolled back if any command fails.
+*/
+func handlerRun(state state, args []string) error {
+	flagSet := flag.NewFlagSet("run", flag.ContinueOnError)
+	keepGoing := flagSet.Bool("keep-going", false, "report failing commands and carry on")
+	atomic := flagSet.Bool("atomic", false, "run the whole script in one transaction")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'run' command: %v", err)
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("The 'run' command takes at most a single SCRIPT argument")
+	}
+
+	if *keepGoing && *atomic {
+		return fmt.Errorf("The 'run' command: --keep-going and --atomic can't be combined")
+	}
+
+	input := io.Reader(os.Stdin)
+	name := "<stdin>"
+
+	if len(args) == 1 && args[0] != "-" {
+		file, err := os.Open(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		input = file
+		name = args[0]
+	}
+
+	script, err := readScript(input)
+
+	if err != nil {
+		return wrapError(err, "Failed to read script %q", name)
+	}
+
+	// Check every line up front, so that a typo near the end doesn't
+	// leave the script half-applied.
+	for _, line := range script {
+		if _, err := GetCommand(line.args[0]); err != nil {
+			return fmt.Errorf("%s:%d: %v", name, line.number, err)
+		}
+
+		if line.args[0] == "run" || longRunningCommands[line.args[0]] {
+			return fmt.Errorf("%s:%d: The '%s' command can't be run from a script", name, line.number, line.args[0])
+		}
+
+		if *atomic && nonTransactionalCommands[line.args[0]] {
+			return fmt.Errorf("%s:%d: The '%s' command can't be run from an atomic script", name, line.number, line.args[0])
+		}
+	}
+
+	if *atomic {
+		tx, err := state.conn.BeginTx(state.ctx, nil)
+
+		if err != nil {
+			return err
+		}
+
+		defer tx.Rollback()
+
+		state.db = state.db.WithTx(tx)
+
+		if err = runScript(state, name, script, false); err != nil {
+			return wrapError(err, "%v (the script was rolled back)", err)
+		}
+
+		return tx.Commit()
+	}
+
+	return runScript(state, name, script, *keepGoing)
+}
+
+/** A single command of a script, along with where it was found. */
+type scriptLine struct {
+	number int
+	args   []string
+}
+
+/** Run each command of the script in turn, each under its own deadline. */
+func runScript(state state, name string, script []scriptLine, keepGoing bool) error {
+	failures := 0
+
+	for _, line := range script {
+		command, err := GetCommand(line.args[0])
+
+		if err != nil {
+			return err
+		}
+
+		commandState := state
+		cancel := context.CancelFunc(func() {})
+
+		if state.Timeout > 0 {
+			commandState.ctx, cancel = context.WithTimeout(state.ctx, state.Timeout)
+		}
+
+		err = command(commandState, line.args[1:])
+		cancel()
+
+		if err == nil {
+			continue
+		}
+
+		if !keepGoing {
+			return wrapError(err, "%s:%d: %v", name, line.number, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s:%d: %v\n", name, line.number, err)
+		failures++
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d commands in %q failed", failures, len(script), name)
+	}
+
+	return nil
+}
+
+/** Read the commands of a script, skipping blank lines and comments. */
+func readScript(input io.Reader) ([]scriptLine, error) {
+	var script []scriptLine
+
+	scanner := bufio.NewScanner(input)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := splitWords(line)
+
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", number, err)
+		}
+
+		script = append(script, scriptLine{number: number, args: args})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return script, nil
+}
+
+/*
+  - Split a script line into words on whitespace, the way a shell
+    would, except that only single and double quotes (and no escapes)
+    are understood.
+*/
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+
+	inWord := false
+	quote := rune(0)
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated %c quote", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,953 @@
+package configuration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/canonical"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/BrandonIrizarry/gator/internal/mirror"
+	"github.com/BrandonIrizarry/gator/internal/normalize"
+	"github.com/BrandonIrizarry/gator/internal/paywall"
+	"github.com/BrandonIrizarry/gator/internal/readtime"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"github.com/BrandonIrizarry/gator/internal/scheduler"
+	"github.com/google/uuid"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+func handlerAgg(state state, args []string) error {
+	flagSet := flag.NewFlagSet("agg", flag.ContinueOnError)
+	pprofAddr := flagSet.String("pprof", "", "serve runtime profiling endpoints at this address")
+	distributed := flagSet.Bool("distributed", false, "enqueue due feeds for 'worker' processes instead of fetching them")
+	shared := flagSet.Bool("shared", false, "claim due feeds through the database, alongside other 'agg --shared' processes")
+	workers := flagSet.Int("workers", 1, "number of feeds to fetch concurrently")
+	writers := flagSet.Int("writers", state.Config.DBWriters, "number of goroutines saving fetched posts (0 to save them as they're fetched)")
+	once := flagSet.Bool("once", false, "fetch the feeds that are due, then exit")
+	pidfile := flagSet.String("pidfile", "", "write the process ID to this file while running")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'agg' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'agg' command takes a single time-between-requests argument")
+	}
+
+	duration, err := time.ParseDuration(args[0])
+
+	if err != nil {
+		return fmt.Errorf("Unable to parse %q as a duration", args[0])
+	}
+
+	if *workers < 1 {
+		return fmt.Errorf("The 'agg' command needs at least one worker")
+	}
+
+	if *distributed && *shared {
+		return fmt.Errorf("The 'agg' command takes only one of --distributed and --shared")
+	}
+
+	if *writers < 0 {
+		return fmt.Errorf("The 'agg' command can't have a negative number of writers")
+	}
+
+	if *pidfile != "" {
+		removePidfile, err := writePidfile(*pidfile)
+
+		if err != nil {
+			return err
+		}
+
+		defer removePidfile()
+	}
+
+	if *pprofAddr != "" {
+		startProfiler(*pprofAddr)
+	}
+
+	if *writers > 0 && !*distributed {
+		queueSize := state.Config.WriteQueueSize
+
+		if queueSize <= 0 {
+			queueSize = DefaultWriteQueueSize
+		}
+
+		// Every fetch waits for its own posts to be saved, so by the
+		// time the writers are stopped, there's nothing left for them.
+		state.writers = startWriters(*writers, queueSize)
+		defer state.writers.stop()
+	}
+
+	state.feedAliases = rss.NewAliases()
+
+	// On SIGINT or SIGTERM, stop taking on feeds, but let those being
+	// fetched finish saving their posts.
+	shutdown, stopShutdown := notifyShutdown(state.ctx)
+	defer stopShutdown()
+
+	keepPartitionsAhead(shutdown, state)
+
+	// Either fetch due feeds ourselves, or hand them off to workers.
+	fetch := scrapeWithDeadline
+
+	if *distributed {
+		fetch = enqueueFetch
+	}
+
+	if *once {
+		fmt.Printf("Collecting feeds not fetched in the last %s\n\n", duration)
+	} else {
+		fmt.Printf("Collecting feeds now; afterwards each feed every %s\n\n", duration)
+	}
+
+	if *shared {
+		return aggShared(shutdown, state, duration, *workers, *once)
+	}
+
+	queue := scheduler.NewQueue()
+	intervals := &fetchIntervals{fallback: duration}
+
+	if err = syncQueue(state, queue, intervals); err != nil {
+		return err
+	}
+
+	// Since feeds can be followed or unfollowed while we're running,
+	// periodically bring the queue back in line with the database.
+	nextSync := time.Now().Add(duration)
+
+	// Whether anything was scraped since the unread counts were last
+	// refreshed.
+	dirty := false
+
+	for shutdown.Err() == nil {
+		if !time.Now().Before(nextSync) {
+			if err = syncQueue(state, queue, intervals); err != nil {
+				return err
+			}
+
+			nextSync = time.Now().Add(duration)
+		}
+
+		if batch := takeDueFeeds(queue, intervals); len(batch) > 0 {
+			failed := fetchConcurrently(shutdown, state, fetch, batch, *workers)
+
+			if err = backOff(state, queue, intervals, failed); err != nil {
+				return err
+			}
+
+			// In distributed mode, the workers refresh the counts.
+			dirty = !*distributed
+
+			if !*once {
+				continue
+			}
+		}
+
+		// Having caught up on every due feed, refresh the unread
+		// counts before going to sleep.
+		if dirty {
+			refreshUnreadCounts(state)
+			dirty = false
+		}
+
+		if *once {
+			return nil
+		}
+
+		// Sleep until either the next feed is due, or it's time to
+		// sync again, whichever comes first.
+		_, due, ok := queue.Peek()
+
+		if !ok || due.After(nextSync) {
+			sleepUntil(shutdown, nextSync)
+			continue
+		}
+
+		sleepUntil(shutdown, due)
+	}
+
+	// Interrupted mid-batch, the counts may still need refreshing.
+	if dirty {
+		refreshUnreadCounts(state)
+	}
+
+	return nil
+}
+
+/*
+  - Like 'agg', but rather than keeping a queue of its own, claim due
+    feeds from the database (which marks them fetched), up to
+    'workers' at a time. Claims skip feeds another process is busy
+    claiming, so several processes can share the work without
+    fetching any feed twice.
+*/
+func aggShared(shutdown context.Context, state state, interval time.Duration, workers int, once bool) error {
+	// How long to wait, having caught up, before looking again.
+	poll := min(interval, time.Minute)
+	dirty := false
+
+	for shutdown.Err() == nil {
+		feeds, err := state.db.GetNextNFeedsToFetch(state.ctx, database.GetNextNFeedsToFetchParams{
+			MaxFailures:     maxFeedFailures(state),
+			IntervalSeconds: int32(max(interval/time.Second, 1)),
+			Limit:           int32(workers),
+		})
+
+		if err != nil {
+			return wrapError(err, "Failed to claim feeds to fetch")
+		}
+
+		if len(feeds) > 0 {
+			fetchConcurrently(shutdown, state, scrapeWithDeadline, feeds, workers)
+			dirty = true
+
+			continue
+		}
+
+		if dirty {
+			refreshUnreadCounts(state)
+			dirty = false
+		}
+
+		if once {
+			return nil
+		}
+
+		sleepUntil(shutdown, time.Now().Add(poll))
+	}
+
+	if dirty {
+		refreshUnreadCounts(state)
+	}
+
+	return nil
+}
+
+/*
+  - How often each feed is fetched: those given an interval of their
+    own with 'feedconfig' use it, and the rest use the one 'agg' was
+    started with.
+*/
+type fetchIntervals struct {
+	fallback  time.Duration
+	overrides map[uuid.UUID]time.Duration
+}
+
+func (intervals *fetchIntervals) of(feedID uuid.UUID) time.Duration {
+	if interval, ok := intervals.overrides[feedID]; ok {
+		return interval
+	}
+
+	return intervals.fallback
+}
+
+/** Reload the per-feed intervals from the database. */
+func (intervals *fetchIntervals) load(state state) error {
+	rows, err := state.db.GetFeedFetchIntervals(state.ctx)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch per-feed fetch intervals")
+	}
+
+	intervals.overrides = make(map[uuid.UUID]time.Duration, len(rows))
+
+	for _, row := range rows {
+		intervals.overrides[row.FeedID] = time.Duration(row.FetchIntervalSeconds.Int32) * time.Second
+	}
+
+	return nil
+}
+
+/*
+  - The longest a failing feed is left before it's tried again (unless
+    its own interval is longer still.)
+*/
+const maxFetchBackoff = 24 * time.Hour
+
+/*
+  - How long to leave a feed before fetching it again, given its
+    interval and how many times in a row fetching it has failed: the
+    interval doubles with each failure, up to 'maxFetchBackoff'.
+*/
+func backoffDelay(interval time.Duration, failures int32) time.Duration {
+	delay := interval
+
+	for i := int32(0); i < failures && delay < maxFetchBackoff; i++ {
+		delay *= 2
+	}
+
+	return max(interval, min(delay, maxFetchBackoff))
+}
+
+/** How many failed fetches in a row mark a feed as broken. */
+func maxFeedFailures(state state) int32 {
+	if state.Config.MaxFeedFailures > 0 {
+		return int32(state.Config.MaxFeedFailures)
+	}
+
+	return DefaultMaxFeedFailures
+}
+
+/** Whether 'agg' has given up on the feed, having failed to fetch it too often. */
+func feedBroken(state state, feed database.Feed) bool {
+	return feed.FailureCount >= maxFeedFailures(state)
+}
+
+/*
+  - Reschedule the feeds which just failed to fetch according to how
+    often they've failed, dropping those now broken from the queue.
+*/
+func backOff(state state, queue *scheduler.Queue, intervals *fetchIntervals, failed []database.Feed) error {
+	for _, feed := range failed {
+		current, err := state.db.GetFeedByID(state.ctx, feed.ID)
+
+		if err != nil {
+			return wrapError(err, "Failed to look up feed %q", feed.Url)
+		}
+
+		if feedBroken(state, current) {
+			queue.Remove(current.ID)
+			continue
+		}
+
+		queue.Schedule(current, time.Now().Add(backoffDelay(intervals.of(current.ID), current.FailureCount)))
+	}
+
+	return nil
+}
+
+/*
+  - Return every feed in the queue which is now due, rescheduling each
+    for one of its intervals from now.
+*/
+func takeDueFeeds(queue *scheduler.Queue, intervals *fetchIntervals) []database.Feed {
+	batch := make([]database.Feed, 0)
+	now := time.Now()
+
+	for {
+		feed, due, ok := queue.Peek()
+
+		if !ok || due.After(now) {
+			return batch
+		}
+
+		batch = append(batch, feed)
+		queue.Schedule(feed, now.Add(intervals.of(feed.ID)))
+	}
+}
+
+/*
+  - Fetch the given feeds with at most 'workers' of them in flight at
+    once, returning those that failed once all are done. A feed that
+    fails to fetch is reported (and, when scraping, recorded against
+    the feed) without holding up the others. Once 'shutdown' is done,
+    no further feeds are started, though those in flight are seen
+    through.
+*/
+func fetchConcurrently(shutdown context.Context, state state, fetch func(state, database.Feed) error, feeds []database.Feed, workers int) []database.Feed {
+	if state.feedAliases != nil {
+		state.ctx = rss.CoalesceFetches(state.ctx, state.feedAliases)
+	}
+
+	jobs := make(chan database.Feed)
+	failed := make([]database.Feed, 0)
+
+	var wg sync.WaitGroup
+	var failedMutex sync.Mutex
+
+	for range min(workers, len(feeds)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for feed := range jobs {
+				if err := fetch(state, feed); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to fetch feed %s: %v\n", feed.Url, err)
+
+					failedMutex.Lock()
+					failed = append(failed, feed)
+					failedMutex.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, feed := range feeds {
+		select {
+		case jobs <- feed:
+		case <-shutdown.Done():
+			break dispatch
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return failed
+}
+
+/*
+  - Hydrate the scheduling queue from the set of currently followed
+    feeds: newly followed feeds are added (due according to when they
+    were last fetched, and how often they've failed since), and feeds
+    nobody follows anymore, or which are broken, are dropped. Feeds
+    already queued keep their place, unless their interval or failure
+    count has since changed.
+*/
+func syncQueue(state state, queue *scheduler.Queue, intervals *fetchIntervals) error {
+	feeds, err := state.db.GetFollowedFeeds(state.ctx)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch followed feeds")
+	}
+
+	previous := *intervals
+
+	if err = intervals.load(state); err != nil {
+		return err
+	}
+
+	followed := make(map[uuid.UUID]bool, len(feeds))
+
+	for _, feed := range feeds {
+		if feedBroken(state, feed) {
+			continue
+		}
+
+		followed[feed.ID] = true
+
+		if queued, ok := queue.Get(feed.ID); ok && queued.FailureCount == feed.FailureCount && intervals.of(feed.ID) == previous.of(feed.ID) {
+			continue
+		}
+
+		// A feed that was never fetched is due immediately.
+		due := time.Now()
+
+		if feed.LastFetchedAt.Valid {
+			due = feed.LastFetchedAt.Time.Add(backoffDelay(intervals.of(feed.ID), feed.FailureCount))
+		}
+
+		queue.Schedule(feed, due)
+	}
+
+	for _, id := range queue.IDs() {
+		if !followed[id] {
+			queue.Remove(id)
+		}
+	}
+
+	return nil
+}
+
+/*
+  - Bring the unread-counts summary up to date. Failing to do so isn't
+    fatal, since the counts merely lag until the next refresh.
+*/
+func refreshUnreadCounts(state state) {
+	if err := state.db.RefreshUnreadCounts(state.ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to refresh unread counts: %v\n", err)
+	}
+}
+
+/** Block until 't', or until 'ctx' is done. */
+func sleepUntil(ctx context.Context, t time.Time) {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+/*
+  - Since 'agg' runs indefinitely, apply the per-command deadline to
+    each individual scrape instead.
+*/
+func scrapeWithDeadline(state state, feed database.Feed) error {
+	if state.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(state.ctx, state.Timeout)
+		defer cancel()
+
+		state.ctx = ctx
+	}
+
+	return scrapeFeedSafely(state, feed)
+}
+
+/*
+  - Scrape a single feed, recovering from any panic raised while doing
+    so (for example, by a pathological feed tripping up the parser.)
+    The panic is logged and returned as the scrape's error, and like
+    any other failure counts against the feed, so that one which
+    panics every time is eventually given up on, while a long-running
+    'agg' carries on with the remaining feeds.
+*/
+func scrapeFeedSafely(state state, feed database.Feed) (err error) {
+	defer func() {
+		recovered := recover()
+
+		if recovered == nil {
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "Recovered from panic while scraping %s: %v\n%s", feed.Url, recovered, debug.Stack())
+
+		err = fmt.Errorf("panic: %v", recovered)
+		recordFetchOutcome(state, feed, err)
+	}()
+
+	err = scrapeFeed(state, feed)
+	recordFetchOutcome(state, feed, err)
+
+	return err
+}
+
+/*
+  - Record the error from fetching the given feed against it, or clear
+    the previous one if the fetch succeeded, so that 'feeds --dead'
+    shows which feeds are currently failing. Failures in a row are
+    counted, and once there are too many, the feed is broken, and
+    'agg' stops fetching it.
+*/
+func recordFetchOutcome(state state, feed database.Feed, fetchErr error) {
+	// A feed passed over because its host is down wasn't itself tried,
+	// so it mustn't count towards its being broken.
+	if errors.Is(fetchErr, rss.ErrCircuitOpen) {
+		return
+	}
+
+	lastError := sql.NullString{}
+
+	if fetchErr != nil {
+		lastError = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	// The fetch may have failed by running out of time, which mustn't
+	// stop the failure from being recorded.
+	ctx := context.WithoutCancel(state.ctx)
+
+	failures, err := state.db.RecordFeedError(ctx, database.RecordFeedErrorParams{
+		ID:        feed.ID,
+		LastError: lastError,
+	})
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to record the outcome of fetching feed %s: %v\n", feed.Url, err)
+		return
+	}
+
+	if failures == maxFeedFailures(state) {
+		fmt.Fprintf(os.Stderr, "Giving up on feed %s after %d failures in a row (see 'feeds --broken')\n", feed.Url, failures)
+	}
+}
+
+func scrapeFeed(state state, feed database.Feed) error {
+	if err := state.db.MarkFeedFetched(state.ctx, feed.ID); err != nil {
+		return wrapError(err, "Failed to mark feed %q as fetched", feed.Url)
+	}
+
+	// The queued copy of the feed predates its last fetch, so look up
+	// what the feed's server said then; the feed is only fetched if
+	// it's changed since.
+	current, err := state.db.GetFeedByID(state.ctx, feed.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", feed.Url)
+	}
+
+	feed = current
+
+	validators := rss.Validators{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	}
+
+	var channel rss.Channel
+
+	// Stream the feed's items in batches, so that a feed shipping
+	// thousands of items can't balloon memory.
+	options := rss.StreamOptions{
+		BatchSize:  state.Config.IngestBatchSize,
+		MaxItems:   state.Config.MaxItemsPerFeed,
+		Validators: &validators,
+		Channel:    &channel,
+	}
+
+	if options.BatchSize == 0 {
+		options.BatchSize = DefaultIngestBatchSize
+	}
+
+	if options.MaxItems == 0 {
+		options.MaxItems = DefaultMaxItemsPerFeed
+	}
+
+	itemLimit, overflowPolicy, err := feedItemLimit(state, feed)
+
+	if err != nil {
+		return err
+	}
+
+	// Note what the feed contained this time, for 'diff'.
+	snapshot := make([]snapshotItem, 0)
+
+	// With a per-feed cap, nothing can be saved until the whole feed
+	// has been read, since only then is it known which items are the
+	// newest. The global cap still bounds how many are held.
+	held := make([]rss.RSSItem, 0)
+	saver := newPostSaver(state, feed)
+
+	_, err = rss.StreamFeed(state.ctx, feed.Url, options, func(batch []rss.RSSItem) error {
+		for _, rssItem := range batch {
+			snapshot = append(snapshot, snapshotItem{Title: rssItem.Title, Link: rssItem.Link})
+		}
+
+		if itemLimit > 0 {
+			held = append(held, batch...)
+			return nil
+		}
+
+		return saver.save(batch, false)
+	})
+
+	// However the fetch went, what was handed to the writers must be
+	// saved (or have failed) before going on.
+	if writeErr := saver.wait(); err == nil {
+		err = writeErr
+	}
+
+	if errors.Is(err, rss.ErrNotModified) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if itemLimit > 0 {
+		newest, overflow := newestItems(held, itemLimit)
+		err = saver.saveInBatches(newest, options.BatchSize, false)
+
+		// The rest are either dropped, or kept out of the way.
+		if err == nil && overflowPolicy == "mark-read" {
+			err = saver.saveInBatches(overflow, options.BatchSize, true)
+		}
+
+		if writeErr := saver.wait(); err == nil {
+			err = writeErr
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if validators.ETag != feed.Etag.String || validators.LastModified != feed.LastModified.String {
+		if err = state.db.SetFeedValidators(state.ctx, database.SetFeedValidatorsParams{
+			ID:           feed.ID,
+			Etag:         sql.NullString{String: validators.ETag, Valid: validators.ETag != ""},
+			LastModified: sql.NullString{String: validators.LastModified, Valid: validators.LastModified != ""},
+		}); err != nil {
+			return wrapError(err, "Failed to record the validators of feed %q", feed.Url)
+		}
+	}
+
+	if added := saver.added(); added == 1 {
+		fmt.Printf("1 new post from %s\n", feed.Name)
+	} else {
+		fmt.Printf("%d new posts from %s\n", added, feed.Name)
+	}
+
+	if err = recordChannelChanges(state, feed, channel); err != nil {
+		return err
+	}
+
+	return saveSnapshot(state, feed, snapshot)
+}
+
+/*
+  - Return the most items to save from each fetch of the given feed, as
+    set with 'setlimit', or zero if the feed isn't capped, along with
+    what to do with the rest.
+*/
+func feedItemLimit(state state, feed database.Feed) (int, string, error) {
+	settings, err := state.db.GetFeedSettings(state.ctx, feed.ID)
+
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+
+	if err != nil {
+		return 0, "", wrapError(err, "Failed to fetch the settings of feed %q", feed.Url)
+	}
+
+	if !settings.MaxItemsPerFetch.Valid {
+		return 0, "", nil
+	}
+
+	return int(settings.MaxItemsPerFetch.Int32), settings.OverflowPolicy, nil
+}
+
+/*
+  - Split the given items into the 'limit' most recently published, and
+    the rest. Items with unparseable dates count as oldest.
+*/
+func newestItems(rssItems []rss.RSSItem, limit int) ([]rss.RSSItem, []rss.RSSItem) {
+	if len(rssItems) <= limit {
+		return rssItems, nil
+	}
+
+	sorted := slices.Clone(rssItems)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, _ := parseRawTime(sorted[i].PubDate)
+		right, _ := parseRawTime(sorted[j].PubDate)
+
+		return left.After(right)
+	})
+
+	return sorted[:limit], sorted[limit:]
+}
+
+/*
+  - Insert the given posts, skipping those whose URLs were already
+    saved, and return the new ones.
+
+    Once posts are partitioned, their unique key is (url, published_at),
+    which doesn't stop a post from being saved again under a new date,
+    so the insert names no conflict target and looks for each URL
+    itself. For that to hold against other writers saving the same
+    URLs, each URL is locked until the insert commits, so that the
+    second writer's insert only starts once the first one's posts can
+    be seen.
+*/
+func createPosts(state state, params database.CreatePostsParams) ([]database.Post, error) {
+	tx, err := state.conn.BeginTx(state.ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	queries := state.db.WithTx(tx)
+
+	if err = queries.LockPostURLs(state.ctx, params.Urls); err != nil {
+		return nil, err
+	}
+
+	posts, err := queries.CreatePosts(state.ctx, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, tx.Commit()
+}
+
+/*
+  - Save the given RSS items to the 'posts' table, in a single insert
+    however many there are. New posts are tagged for the feed's
+    followers with the items' categories, unless the feed is opted out
+    with 'feedconfig'. With 'markRead', they're also marked as read for
+    them, so that they're kept without cluttering 'browse'. Returns how
+    many of the items were new.
+*/
+func savePosts(state state, feed database.Feed, rssItems []rss.RSSItem, markRead bool) (int, error) {
+	now := time.Now()
+	params := database.CreatePostsParams{CreatedAt: now, FeedID: feed.ID}
+	itemsByLink := make(map[string]rss.RSSItem, len(rssItems))
+
+	// An item whose date can't be parsed stops the feed's remaining
+	// items from being saved, though those before it still are.
+	var parseErr error
+
+	for _, rssItem := range rssItems {
+		if state.Config.NormalizeText {
+			rssItem.Title = normalize.Title(rssItem.Title, feed.Name)
+			rssItem.Description = normalize.Whitespace(rssItem.Description)
+		}
+
+		// Parse the provided publication date into a Go time object.
+		pubDate, err := parseRawTime(rssItem.PubDate)
+
+		if err != nil {
+			parseErr = err
+			break
+		}
+
+		// A feed listing the same link twice only gets the first of
+		// them saved.
+		if _, ok := itemsByLink[rssItem.Link]; ok {
+			continue
+		}
+
+		itemsByLink[rssItem.Link] = rssItem
+
+		params.Ids = append(params.Ids, ids.New())
+		params.Titles = append(params.Titles, rssItem.Title)
+		params.Urls = append(params.Urls, rssItem.Link)
+		params.Descriptions = append(params.Descriptions, rssItem.Description)
+		params.PublishedAts = append(params.PublishedAts, pubDate)
+		params.Authors = append(params.Authors, rssItem.AuthorName())
+		params.Paywalled = append(params.Paywalled, paywall.Detect(rssItem.Title, rssItem.Description))
+		params.ReadingSeconds = append(params.ReadingSeconds, int32(readtime.Estimate(rssItem.Description)/time.Second))
+		params.DedupeKeys = append(params.DedupeKeys, canonical.Key(rssItem.Link, rssItem.GUID))
+	}
+
+	if len(params.Ids) == 0 {
+		return 0, parseErr
+	}
+
+	posts, err := createPosts(state, params)
+
+	if err != nil {
+		return 0, wrapError(err, "Failed to save the posts of feed %q", feed.Name)
+	}
+
+	tagCategories := true
+
+	if len(posts) > 0 {
+		settings, err := state.db.GetFeedSettings(state.ctx, feed.ID)
+
+		if err != nil && err != sql.ErrNoRows {
+			return 0, wrapError(err, "Failed to fetch the settings of feed %q", feed.Url)
+		}
+
+		tagCategories = err == sql.ErrNoRows || settings.CategoryTags
+	}
+
+	for _, post := range posts {
+		rssItem := itemsByLink[post.Url]
+
+		fmt.Println(post.Url)
+
+		if state.Config.ImageMirrorDir != "" {
+			mirrorImages(state, post)
+		}
+
+		if state.Config.ResolveCanonicalURLs {
+			resolveCanonicalURL(state, post, rssItem.GUID)
+		}
+
+		if tags := categoryTags(state, rssItem.Categories); tagCategories && len(tags) > 0 {
+			if err = state.db.AddPostTagsForFollowers(state.ctx, database.AddPostTagsForFollowersParams{
+				PostID:    post.ID,
+				CreatedAt: now,
+				Tags:      tags,
+				FeedID:    feed.ID,
+			}); err != nil {
+				return 0, wrapError(err, "Failed to tag post %q", post.Url)
+			}
+		}
+
+		if markRead {
+			if err = state.db.MarkPostReadForFollowers(state.ctx, database.MarkPostReadForFollowersParams{
+				PostID: post.ID,
+				ReadAt: time.Now(),
+				FeedID: feed.ID,
+			}); err != nil {
+				return 0, wrapError(err, "Failed to mark post %q as read", post.Url)
+			}
+		}
+	}
+
+	return len(posts), parseErr
+}
+
+/** Downloading an image for the mirror shouldn't hold up 'agg' for long. */
+var imageMirrorClient = &http.Client{Timeout: 30 * time.Second}
+
+/*
+  - Save local copies of the images in a newly saved post, pointing the
+    post at them. Images that can't be saved keep their original
+    links, and a failure to update the post is merely reported, since
+    the post itself is saved either way.
+*/
+func mirrorImages(state state, post database.Post) {
+	imageMirror := mirror.Mirror{Dir: state.Config.ImageMirrorDir, Client: imageMirrorClient}
+	description, mirrored := imageMirror.Rewrite(state.ctx, post.Url, post.Description)
+
+	if mirrored == 0 {
+		return
+	}
+
+	if err := state.db.SetPostDescription(state.ctx, database.SetPostDescriptionParams{
+		ID:          post.ID,
+		Description: description,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to point post %q at its mirrored images: %v\n", post.Url, err)
+	}
+}
+
+/** Fetching a post's page for its canonical URL shouldn't hold up 'agg' for long. */
+var canonicalClient = &http.Client{Timeout: 10 * time.Second}
+
+/*
+  - Record where a newly saved post's article really lives, as its page
+    says, so that 'open' goes there and copies of it in other feeds
+    are recognized (see 'browse --dedupe'). As with mirroring, failing
+    at this is merely reported.
+*/
+func resolveCanonicalURL(state state, post database.Post, guid string) {
+	canonicalURL, err := canonical.Resolve(state.ctx, canonicalClient, post.Url)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't find the canonical URL of post %q: %v\n", post.Url, err)
+		return
+	}
+
+	if canonicalURL == post.Url {
+		return
+	}
+
+	if err = state.db.SetPostCanonicalURL(state.ctx, database.SetPostCanonicalURLParams{
+		ID:           post.ID,
+		CanonicalUrl: canonicalURL,
+		DedupeKey:    canonical.Key(canonicalURL, guid),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to record the canonical URL of post %q: %v\n", post.Url, err)
+	}
+}
+
+/*
+Attempt to parse every RFC layout in the time package.
+Return the first valid time.Time. If there are none, return an error.
+*/
+func parseRawTime(timeStr string) (time.Time, error) {
+	layouts := []string{
+		time.RFC822,
+		time.RFC822Z,
+		time.RFC850,
+		time.RFC1123,
+		time.RFC1123Z,
+		time.RFC3339,
+		time.RFC3339Nano,
+	}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, timeStr)
+
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	// Construct a zero-time, to return as a degenerate value.
+	var zero time.Time
+	return zero, fmt.Errorf("Can't get a valid time from %q; maybe add this layout?", timeStr)
+}
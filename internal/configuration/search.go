@@ -0,0 +1,84 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+/** A post, as reported by 'search'. */
+type searchResult struct {
+	ID          uuid.UUID `json:"id"`
+	Feed        string    `json:"feed"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+	Rank        float32   `json:"rank"`
+}
+
+/*
+  - Search the titles and descriptions of the posts in the current
+    user's feeds, best matches first. The query is given in the usual
+    web search syntax: quoted phrases, "or", and "-" to exclude a word.
+*/
+func handlerSearch(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := flagSet.Int("limit", 10, "maximum number of posts to show")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'search' command: %v", err)
+	}
+
+	// The query can be given as several words, without quoting.
+	query := strings.TrimSpace(strings.Join(args, " "))
+
+	if query == "" {
+		return fmt.Errorf("The 'search' command takes a QUERY argument")
+	}
+
+	posts, err := state.db.SearchPosts(state.ctx, database.SearchPostsParams{
+		Query:  query,
+		UserID: currentUser.ID,
+		Limit:  int32(*limit),
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to search the posts of user %q", currentUser.Name)
+	}
+
+	results := make([]searchResult, 0, len(posts))
+
+	for _, post := range posts {
+		results = append(results, searchResult{
+			ID:          post.ID,
+			Feed:        post.Feedname,
+			Title:       post.Title,
+			URL:         post.Url,
+			PublishedAt: post.PublishedAt,
+			Rank:        post.Rank,
+		})
+	}
+
+	return output.Print(os.Stdout, state.JSON, results, func(w io.Writer) error {
+		if len(results) == 0 {
+			fmt.Fprintf(w, "No posts match %q\n", query)
+			return nil
+		}
+
+		rows := make([][]string, 0, len(results))
+
+		for _, post := range results {
+			rows = append(rows, []string{post.PublishedAt.Format(time.DateOnly), post.Feed, post.Title, post.ID.String()})
+		}
+
+		return output.Table(w, []string{"PUBLISHED", "FEED", "TITLE", "ID"}, rows)
+	})
+}
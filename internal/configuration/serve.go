@@ -0,0 +1,190 @@
+package configuration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"github.com/google/uuid"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+  - Run gator's HTTP server. This serves short links of the form
+    /p/SHORT-ID, which record that the post was opened and then
+    redirect to it, so that digests can carry trackable links.
+
+    With '--api', a JSON API is also served, for any user with an API
+    key (see 'registerAPI'). With '--web', a web UI for reading the
+    current user's posts is served, with '--fever' and
+    '--google-reader', the Fever and Google Reader APIs, for the
+    readers which sync through them, and with '--activitypub', their
+    starred posts are published as an ActivityPub actor.
+*/
+func handlerServe(state state, args []string) error {
+	flagSet := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flagSet.String("addr", "localhost:8080", "address to listen at")
+	api := flagSet.Bool("api", false, "serve a JSON API under /api, for users with API keys")
+	webUI := flagSet.Bool("web", false, "serve a web UI for the current user under /ui/")
+	fever := flagSet.Bool("fever", false, "serve the Fever API for the current user under /fever/")
+	googleReader := flagSet.Bool("google-reader", false, "serve the Google Reader API for the current user")
+	activityPub := flagSet.Bool("activitypub", false, "publish the current user's starred posts via ActivityPub")
+	pprofAddr := flagSet.String("pprof", "", "serve runtime profiling endpoints at this address")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'serve' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'serve' command takes no arguments")
+	}
+
+	if *pprofAddr != "" {
+		startProfiler(*pprofAddr)
+	}
+
+	// A mux of our own, since the default one carries the profiling
+	// endpoints.
+	mux := http.NewServeMux()
+	handler := http.Handler(mux)
+
+	mux.HandleFunc("GET /p/{id}", func(w http.ResponseWriter, r *http.Request) {
+		state, cancel := requestState(state, r)
+		defer cancel()
+
+		serveShortLink(state, w, r)
+	})
+
+	if *api {
+		registerAPI(state, mux)
+	}
+
+	if *webUI || *fever || *googleReader || *activityPub {
+		currentUser, err := loggedInUser(state)
+
+		if err != nil {
+			return err
+		}
+
+		if *webUI {
+			if err = registerWebUI(state, mux, currentUser, *addr); err != nil {
+				return err
+			}
+		}
+
+		if *fever {
+			if err = registerFever(state, mux, currentUser); err != nil {
+				return err
+			}
+		}
+
+		if *googleReader {
+			if handler, err = registerGoogleReader(state, mux, currentUser); err != nil {
+				return err
+			}
+		}
+
+		if *activityPub {
+			if err = registerActivityPub(state, mux, currentUser); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Serving at http://%s\n", *addr)
+
+	return http.ListenAndServe(*addr, handler)
+}
+
+/*
+  - Since 'serve' runs indefinitely, apply the per-command deadline to
+    each request instead. The returned function must be called once
+    the request is handled.
+*/
+func requestState(state state, r *http.Request) (state, context.CancelFunc) {
+	var cancel context.CancelFunc
+
+	if state.Timeout > 0 {
+		state.ctx, cancel = context.WithTimeout(r.Context(), state.Timeout)
+	} else {
+		state.ctx, cancel = context.WithCancel(r.Context())
+	}
+
+	return state, cancel
+}
+
+/** Record that a post was opened through a short link, and go to it. */
+func serveShortLink(state state, w http.ResponseWriter, r *http.Request) {
+	postID, err := parseShortID(r.PathValue("id"))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	post, err := state.db.GetPostByID(state.ctx, postID)
+
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to look up post %s: %v\n", postID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Failing to record the click shouldn't keep anyone from the post.
+	if err = state.db.RecordPostOpen(state.ctx, database.RecordPostOpenParams{
+		ID:       ids.New(),
+		PostID:   post.ID,
+		FeedID:   post.FeedID,
+		OpenedAt: time.Now(),
+		Via:      "link",
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to record the opening of post %s: %v\n", post.ID, err)
+	}
+
+	// Short links are handed out to the configured user (in digests,
+	// say), so following one means they've read the post.
+	markReadByCurrentUser(state, post.ID)
+
+	http.Redirect(w, r, articleURL(post), http.StatusFound)
+}
+
+/*
+  - Return the link to the given post to hand out, for example in
+    digests: a short link through 'serve' if 'serve_url' is
+    configured, or else the post's own URL.
+*/
+func postLink(state state, postID uuid.UUID, url string) string {
+	if state.Config.ServeURL == "" {
+		return url
+	}
+
+	return fmt.Sprintf("%s/p/%s", strings.TrimSuffix(state.Config.ServeURL, "/"), shortID(postID))
+}
+
+/** A post ID in a compact, URL-safe form (22 characters.) */
+func shortID(id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+func parseShortID(short string) (uuid.UUID, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(short)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.FromBytes(bytes)
+}
@@ -0,0 +1,164 @@
+package configuration
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/password"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+/** Standard input, shared by every prompt so that none loses what another buffered. */
+var stdin = bufio.NewReader(os.Stdin)
+
+/*
+  - Read a password from standard input, up to the end of the line,
+    prompting for it on standard error (so that it stays out of any
+    JSON output.) At a terminal, it isn't echoed as it's typed;
+    otherwise it's read as a plain line, so it can also be piped in.
+*/
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	defer pauseDeadline()()
+
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		given, err := term.ReadPassword(fd)
+
+		// The newline typed at the end wasn't echoed either.
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to read a password: %v", err)
+		}
+
+		return string(given), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("Failed to read a password: %v", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+/*
+  - Check the password given for a user with one, asking for it on
+    standard input. Users without a password need none.
+*/
+func checkPassword(user database.User) error {
+	if user.PasswordHash == "" {
+		return nil
+	}
+
+	given, err := readPassword(fmt.Sprintf("Password for '%s': ", user.Name))
+
+	if err != nil {
+		return err
+	}
+
+	if !password.Verify(user.PasswordHash, given) {
+		return wrapError(ErrWrongPassword, "Wrong password for user '%s'", user.Name)
+	}
+
+	return nil
+}
+
+/*
+  - Start a session for the given user, returning its token, to be
+    kept in the config file. Users without a password don't need
+    sessions, and get no token.
+*/
+func startSession(state state, user database.User) (string, error) {
+	if user.PasswordHash == "" {
+		return "", nil
+	}
+
+	token, err := newToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = state.db.CreateSession(state.ctx, database.CreateSessionParams{
+		TokenHash: tokenHash(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return "", wrapError(err, "Failed to start a session for user '%s'", user.Name)
+	}
+
+	return token, nil
+}
+
+/** End the session with the given token, if there is one. */
+func endSession(state state, token string) error {
+	if token == "" {
+		return nil
+	}
+
+	if err := state.db.DeleteSession(state.ctx, tokenHash(token)); err != nil {
+		return wrapError(err, "Failed to end the current session")
+	}
+
+	return nil
+}
+
+/*
+  - Check that the config file's session belongs to the given user, so
+    that someone editing 'current_user_name' by hand doesn't become a
+    user with a password. Users without one are taken at their word.
+*/
+func checkSession(state state, user database.User) error {
+	if user.PasswordHash == "" {
+		return nil
+	}
+
+	if state.Config.SessionToken == "" {
+		return wrapError(ErrNotLoggedIn, "Not logged in: user '%s' has a password, so log in with 'login %s'", user.Name, user.Name)
+	}
+
+	userID, err := state.db.GetSessionUserID(state.ctx, tokenHash(state.Config.SessionToken))
+
+	if err == sql.ErrNoRows || (err == nil && userID != user.ID) {
+		return wrapError(ErrNotLoggedIn, "Not logged in: the session of user '%s' isn't valid (use 'login %s')", user.Name, user.Name)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up the current session")
+	}
+
+	return nil
+}
+
+/** A random, URL-safe token, for a session or an API key. */
+func newToken() (string, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+/*
+  - Tokens are looked up by a hash of them, so that the database alone
+    can't be used to log in.
+*/
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"os"
+	"strconv"
+	"time"
+)
+
+/** What happens to the items of a fetch beyond a feed's cap. */
+var overflowPolicies = map[string]bool{
+	"drop-oldest": true,
+	"mark-read":   true,
+}
+
+/*
+  - Cap how many items 'agg' saves from each fetch of the given feed,
+    so that a feed dumping its whole archive doesn't flood the river.
+    A limit of zero removes the cap.
+*/
+func handlerSetLimit(state state, args []string) error {
+	flagSet := flag.NewFlagSet("setlimit", flag.ContinueOnError)
+	overflow := flagSet.String("overflow", "drop-oldest", "what to do with the items beyond the cap")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'setlimit' command: %v", err)
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("The 'setlimit' command takes a FEED-URL and LIMIT argument")
+	}
+
+	if !overflowPolicies[*overflow] {
+		return fmt.Errorf("Unknown overflow policy %q (use 'drop-oldest' or 'mark-read')", *overflow)
+	}
+
+	url := args[0]
+	limit, err := strconv.ParseInt(args[1], 10, 32)
+
+	if err != nil || limit < 0 {
+		return fmt.Errorf("Can't parse %q as a non-negative int", args[1])
+	}
+
+	feed, err := state.db.GetFeedByURL(state.ctx, url)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No feed with URL %q (use 'addfeed' to add it)", url)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", url)
+	}
+
+	if err = state.db.SetFeedItemLimit(state.ctx, database.SetFeedItemLimitParams{
+		FeedID:    feed.ID,
+		UpdatedAt: time.Now(),
+		MaxItemsPerFetch: sql.NullInt32{
+			Int32: int32(limit),
+			Valid: limit > 0,
+		},
+		OverflowPolicy: *overflow,
+	}); err != nil {
+		return wrapError(err, "Failed to set the item limit of feed %q", feed.Name)
+	}
+
+	result := itemLimit{
+		Feed:     feed.Name,
+		URL:      feed.Url,
+		MaxItems: int32(limit),
+		Overflow: *overflow,
+	}
+
+	if limit == 0 {
+		return output.Message(os.Stdout, state.JSON, result, "Removed the item limit of feed %q", feed.Name)
+	}
+
+	return output.Message(os.Stdout, state.JSON, result, "Feed %q is now limited to %d items per fetch (%s)", feed.Name, limit, *overflow)
+}
+
+/** A feed's item limit, as set by 'setlimit'; zero means no limit. */
+type itemLimit struct {
+	Feed     string `json:"feed"`
+	URL      string `json:"url"`
+	MaxItems int32  `json:"max_items_per_fetch"`
+	Overflow string `json:"overflow_policy"`
+}
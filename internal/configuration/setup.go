@@ -0,0 +1,93 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/bundles"
+	"github.com/BrandonIrizarry/gator/internal/i18n"
+	"github.com/BrandonIrizarry/gator/internal/migrate"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/sql/schema"
+	"io"
+	"os"
+	"strings"
+)
+
+/** What 'setup' found built in, and what it did to the database. */
+type setupReport struct {
+	Migrations int      `json:"migrations"`
+	Applied    []string `json:"applied"`
+	Bundles    []string `json:"bundles"`
+	Languages  []string `json:"languages"`
+}
+
+/*
+  - Get a bare machine ready to use gator: check that everything built
+    into the binary (the schema migrations, starter bundles and message
+    catalogs; the web UI's pages are checked as gator starts) is intact,
+    then bring the database up to date. Only the database itself, and
+    its connection string, need to exist beforehand.
+*/
+func handlerSetup(state state, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("The 'setup' command takes no arguments")
+	}
+
+	migrations, err := migrate.Load(schema.Migrations)
+
+	if err != nil {
+		return wrapError(err, "The built-in schema migrations are unreadable")
+	}
+
+	available, err := bundles.List()
+
+	if err != nil {
+		return wrapError(err, "The built-in bundles are unreadable")
+	}
+
+	languages, err := i18n.Languages()
+
+	if err != nil {
+		return wrapError(err, "The built-in message catalogs are unreadable")
+	}
+
+	for _, language := range languages {
+		if _, err := i18n.Load(language); err != nil {
+			return wrapError(err, "The built-in message catalog %q is unreadable", language)
+		}
+	}
+
+	applied, err := migrate.Up(state.ctx, state.conn, migrations)
+
+	if err != nil {
+		return wrapError(err, "Failed to set up the database")
+	}
+
+	report := setupReport{
+		Migrations: len(migrations),
+		Applied:    make([]string, 0, len(applied)),
+		Bundles:    make([]string, 0, len(available)),
+		Languages:  languages,
+	}
+
+	for _, migration := range applied {
+		report.Applied = append(report.Applied, migration.Name)
+	}
+
+	for _, bundle := range available {
+		report.Bundles = append(report.Bundles, bundle.Name)
+	}
+
+	return output.Print(os.Stdout, state.JSON, report, func(w io.Writer) error {
+		if len(applied) == 0 {
+			fmt.Fprintf(w, "The database is already up to date (%d migrations)\n", report.Migrations)
+		} else {
+			fmt.Fprintf(w, "Applied %d of %d migrations (through %s)\n", len(applied), report.Migrations, report.Applied[len(applied)-1])
+		}
+
+		fmt.Fprintf(w, "Starter bundles: %s (see 'bundle')\n", strings.Join(report.Bundles, ", "))
+		fmt.Fprintf(w, "Languages: %s\n", strings.Join(report.Languages, ", "))
+		fmt.Fprintln(w, "Ready: create a user with 'register USERNAME'")
+
+		return nil
+	})
+}
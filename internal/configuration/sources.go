@@ -0,0 +1,84 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+  - Shorthands for the sources gator makes feeds of itself, each
+    mapped to the scheme of the feed URLs it stands for (so that
+    "docker:nginx" stands for "docker://nginx", and "@handle" for
+    "nitter://handle".)
+*/
+var sourceShorthands = map[string]string{
+	"docker:": "docker://",
+	"npm:":    "npm://",
+	"pypi:":   "pypi://",
+	"crates:": "crates://",
+	"go:":     "go://",
+	"@":       "nitter://",
+}
+
+/*
+  - Expand a shorthand such as "docker:nginx" into its feed's URL. The
+    shorthand itself doubles as the feed's name.
+*/
+func expandShorthand(source string) (URL string, name string, ok bool) {
+	for prefix, scheme := range sourceShorthands {
+		rest, found := strings.CutPrefix(source, prefix)
+
+		// The full URL ("docker://nginx") is no shorthand.
+		if found && rest != "" && !strings.HasPrefix(rest, "//") {
+			return scheme + rest, source, true
+		}
+	}
+
+	return "", "", false
+}
+
+/** The kinds of feed a repository offers. */
+const (
+	repoReleases = "releases"
+	repoCommits  = "commits"
+)
+
+/*
+  - Turn a GitHub repository, given as "gh:ORG/REPO",
+    "github.com/ORG/REPO", or "https://github.com/ORG/REPO", into the
+    URL and name of its releases or commits feed. 'ok' is false when
+    'source' isn't a repository at all (in which case it's presumably
+    a feed URL already.)
+*/
+func expandRepoSource(source string, kind string) (URL string, name string, ok bool, err error) {
+	var repo string
+
+	switch {
+	case strings.HasPrefix(source, "gh:"):
+		repo = strings.TrimPrefix(source, "gh:")
+	case strings.HasPrefix(source, "github.com/"):
+		repo = strings.TrimPrefix(source, "github.com/")
+	case strings.HasPrefix(source, "https://github.com/"):
+		repo = strings.TrimPrefix(source, "https://github.com/")
+	default:
+		return "", "", false, nil
+	}
+
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	parts := strings.Split(repo, "/")
+
+	// A URL deeper into the repository (such as its releases page)
+	// names a feed already.
+	if len(parts) > 2 && !strings.HasPrefix(source, "gh:") {
+		return "", "", false, nil
+	}
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false, fmt.Errorf("Can't make sense of repository %q (expected ORG/REPO)", source)
+	}
+
+	URL = fmt.Sprintf("https://github.com/%s/%s/%s.atom", parts[0], parts[1], kind)
+	name = fmt.Sprintf("%s/%s %s", parts[0], parts[1], kind)
+
+	return URL, name, true, nil
+}
@@ -0,0 +1,175 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"time"
+)
+
+/** A starred post, as reported by 'star' and 'starred'. */
+type starredPost struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Feed        string    `json:"feed"`
+	Author      string    `json:"author"`
+	PublishedAt time.Time `json:"published_at"`
+	StarredAt   time.Time `json:"starred_at"`
+	Snapshot    string    `json:"snapshot,omitempty"`
+}
+
+/*
+  - Star the given post (by ID or URL), keeping a copy of it for good,
+    whether or not it's read, and even once the post itself is pruned.
+    With '--archive', the post is also submitted to the Wayback
+    Machine, so that a copy of the page itself survives too.
+*/
+func handlerStar(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("star", flag.ContinueOnError)
+	archive := flagSet.Bool("archive", false, "also submit the post to the Wayback Machine")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'star' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'star' command takes a single POST-ID or POST-URL argument")
+	}
+
+	post, err := lookUpPost(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	starred, count, err := starPost(state, currentUser, post)
+
+	if err != nil {
+		return err
+	}
+
+	if *archive {
+		if starred.Snapshot, err = archivePost(state, post.Url); err != nil {
+			return err
+		}
+	}
+
+	if count == 0 {
+		return output.Message(os.Stdout, state.JSON, starred, "%q was already starred", post.Title)
+	}
+
+	return output.Message(os.Stdout, state.JSON, starred, "Starred %q", post.Title)
+}
+
+/*
+  - Star the given post for the given user, returning the star along
+    with how many were added (none if it was starred already.)
+*/
+func starPost(state state, user database.User, post database.Post) (starredPost, int64, error) {
+	feed, err := state.db.GetFeedByID(state.ctx, post.FeedID)
+
+	if err != nil {
+		return starredPost{}, 0, wrapError(err, "Failed to look up the feed of post %q", post.Title)
+	}
+
+	starred := starredPost{
+		Title:       post.Title,
+		URL:         post.Url,
+		Feed:        feed.Name,
+		Author:      post.Author,
+		PublishedAt: post.PublishedAt,
+		StarredAt:   time.Now(),
+	}
+
+	count, err := state.db.StarPost(state.ctx, database.StarPostParams{
+		UserID:      user.ID,
+		PostID:      uuid.NullUUID{UUID: post.ID, Valid: true},
+		Url:         post.Url,
+		Title:       post.Title,
+		Description: post.Description,
+		Author:      post.Author,
+		FeedName:    feed.Name,
+		PublishedAt: post.PublishedAt,
+		StarredAt:   starred.StarredAt,
+	})
+
+	if err != nil {
+		return starredPost{}, 0, wrapError(err, "Failed to star post %q", post.Title)
+	}
+
+	return starred, count, nil
+}
+
+/** Unstar the given post, by ID or URL. */
+func handlerUnstar(state state, args []string, currentUser database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("The 'unstar' command takes a single POST-ID or POST-URL argument")
+	}
+
+	count, err := state.db.UnstarPost(state.ctx, database.UnstarPostParams{
+		UserID:  currentUser.ID,
+		IDOrUrl: args[0],
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to unstar post %q", args[0])
+	}
+
+	if count == 0 {
+		return wrapError(ErrNotFound, "User %q hasn't starred a post with ID or URL %q", currentUser.Name, args[0])
+	}
+
+	return output.Message(os.Stdout, state.JSON, unstarredPost{Post: args[0], Starred: false}, "Unstarred %q", args[0])
+}
+
+/** The outcome of 'unstar': the post, as it was given. */
+type unstarredPost struct {
+	Post    string `json:"post"`
+	Starred bool   `json:"starred"`
+}
+
+/** List the current user's starred posts, most recently starred first. */
+func handlerStarred(state state, args []string, currentUser database.User) error {
+	if len(args) > 0 {
+		return fmt.Errorf("The 'starred' command takes no arguments")
+	}
+
+	rows, err := state.db.GetStarredPosts(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the starred posts of user %q", currentUser.Name)
+	}
+
+	starred := make([]starredPost, 0, len(rows))
+
+	for _, row := range rows {
+		starred = append(starred, starredPost{
+			Title:       row.Title,
+			URL:         row.Url,
+			Feed:        row.FeedName,
+			Author:      row.Author,
+			PublishedAt: row.PublishedAt,
+			StarredAt:   row.StarredAt,
+		})
+	}
+
+	return output.Print(os.Stdout, state.JSON, starred, func(w io.Writer) error {
+		if len(starred) == 0 {
+			fmt.Fprintln(w, "No starred posts (use 'star' to star some)")
+			return nil
+		}
+
+		for _, post := range starred {
+			fmt.Fprintf(w, "★ %s\n", post.Title)
+			fmt.Fprintf(w, "  %s (%s, %s)\n", post.URL, post.Feed, post.PublishedAt.Format(time.DateOnly))
+		}
+
+		return nil
+	})
+}
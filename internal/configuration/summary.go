@@ -0,0 +1,215 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** The longest a post's one-line summary gets, in characters. */
+const summaryLength = 140
+
+/** The heading under which 'summary' puts the feeds with no tags. */
+const untaggedHeading = "Untagged"
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+/*
+  - Print a Markdown digest of the posts published recently in the
+    current user's feeds, grouped by tag and then by feed, suitable for
+    pasting into a chat channel or email. A feed with several tags is
+    put under the first of them, so that no post is listed twice. Posts which went out in an earlier digest
+    are left out, whether or not they've since been read, unless
+    '--include-previous' is given.
+*/
+func handlerSummary(state state, args []string, currentUser database.User) error {
+	flagSet := flag.NewFlagSet("summary", flag.ContinueOnError)
+	sinceFlag := flagSet.String("since", "7d", "how far back to look (for example, 24h, 7d, or 2w)")
+	includePrevious := flagSet.Bool("include-previous", false, "include posts which went out in earlier digests")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'summary' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'summary' command takes no arguments")
+	}
+
+	since, err := parseLongDuration(*sinceFlag)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	start := now.Add(-since)
+
+	posts, err := state.db.GetPostsForDigest(state.ctx, database.GetPostsForDigestParams{
+		UserID:          currentUser.ID,
+		PublishedAt:     start,
+		IncludePrevious: *includePrevious,
+	})
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch posts for user %q", currentUser.Name)
+	}
+
+	digest := digest{
+		From:  start,
+		To:    now,
+		Posts: make([]digestPost, 0, len(posts)),
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(posts))
+
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		digest.Posts = append(digest.Posts, digestPost{
+			Tag:     post.Tag.String,
+			Feed:    post.Feedname,
+			Title:   post.Title,
+			URL:     postLink(state, post.ID, post.Url),
+			Summary: summarize(post.Description),
+		})
+	}
+
+	if err = output.Print(os.Stdout, state.JSON, digest, func(w io.Writer) error {
+		fmt.Fprintf(w, "# Digest: %s to %s\n", start.Format(time.DateOnly), now.Format(time.DateOnly))
+
+		if len(digest.Posts) == 0 {
+			fmt.Fprintln(w, "\nNothing new.")
+			return nil
+		}
+
+		// The posts arrive ordered by tag and then by feed, so a new
+		// heading is due whenever either changes.
+		var currentTag, currentFeed string
+
+		for i, post := range digest.Posts {
+			newTag := i == 0 || post.Tag != currentTag
+
+			if newTag {
+				currentTag = post.Tag
+				heading := currentTag
+
+				if heading == "" {
+					heading = untaggedHeading
+				}
+
+				fmt.Fprintf(w, "\n## %s\n", heading)
+			}
+
+			if newTag || post.Feed != currentFeed {
+				currentFeed = post.Feed
+				fmt.Fprintf(w, "\n### %s\n\n", currentFeed)
+			}
+
+			line := fmt.Sprintf("- [%s](%s)", post.Title, post.URL)
+
+			if post.Summary != "" {
+				line = fmt.Sprintf("%s: %s", line, post.Summary)
+			}
+
+			fmt.Fprintln(w, line)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Only once the digest is out are its posts counted as sent.
+	if err = state.db.MarkPostsDigested(state.ctx, database.MarkPostsDigestedParams{
+		UserID:     currentUser.ID,
+		DigestedAt: now,
+		PostIds:    postIDs,
+	}); err != nil {
+		return wrapError(err, "Failed to record the posts in the digest for user %q", currentUser.Name)
+	}
+
+	return nil
+}
+
+/** The digest written by 'summary'. */
+type digest struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Posts []digestPost `json:"posts"`
+}
+
+type digestPost struct {
+	Tag     string `json:"tag"`
+	Feed    string `json:"feed"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+}
+
+/*
+  - Reduce a post description (often HTML) to a single line of plain
+    text, truncated at a word boundary. The length is counted in runes,
+    so that a multibyte character is never cut in half.
+*/
+func summarize(description string) string {
+	text := htmlTagPattern.ReplaceAllString(description, " ")
+	text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+
+	runes := []rune(text)
+
+	if len(runes) <= summaryLength {
+		return text
+	}
+
+	text = string(runes[:summaryLength])
+
+	if cut := strings.LastIndex(text, " "); cut > 0 {
+		text = text[:cut]
+	}
+
+	return text + "…"
+}
+
+/*
+  - Like time.ParseDuration, but also accepting a whole number of days
+    ("7d") or weeks ("2w"), which are more natural for looking back
+    over posts.
+*/
+func parseLongDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(count)
+
+			if err != nil {
+				return 0, fmt.Errorf("Unable to parse %q as a duration", value)
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("Unable to parse %q as a duration", value)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,86 @@
+package configuration
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/categorize"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"os"
+	"strings"
+)
+
+/** The most recent items consulted when suggesting a feed's tags. */
+const suggestionItems = 20
+
+/*
+  - Fetch the given feed and suggest tags for it from its title,
+    description, and recent items' titles. A feed which can't be
+    fetched simply gets no suggestions.
+*/
+func suggestFeedTags(state state, url string) []string {
+	feed, err := rss.FetchFeed(state.ctx, url)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't fetch %s to suggest tags: %v\n", url, err)
+		return nil
+	}
+
+	texts := []string{feed.Channel.Title, feed.Channel.Description}
+
+	for i, item := range feed.Channel.Item {
+		if i == suggestionItems {
+			break
+		}
+
+		texts = append(texts, item.Title)
+	}
+
+	return categorize.Suggest(texts...)
+}
+
+/*
+  - Present the suggested tags for confirmation, returning those the
+    user accepts. The user can accept them all, reject them all, or
+    type a replacement list.
+*/
+func confirmTags(in io.Reader, out io.Writer, suggested []string) []string {
+	if len(suggested) == 0 {
+		fmt.Fprintln(out, "No tags to suggest")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Suggested tags: %s\n", strings.Join(suggested, ", "))
+	fmt.Fprint(out, "Accept them? [Y/n, or type comma-separated tags to use instead] ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+
+	// Without an answer (say, when input isn't interactive), nothing
+	// is accepted.
+	if err != nil && line == "" {
+		fmt.Fprintln(out)
+		return nil
+	}
+
+	switch answer := strings.TrimSpace(line); strings.ToLower(answer) {
+	case "", "y", "yes":
+		return suggested
+	case "n", "no":
+		return nil
+	default:
+		return splitTags(answer)
+	}
+}
+
+/** Split a comma-separated list of tags, dropping empty entries. */
+func splitTags(list string) []string {
+	tags := make([]string, 0)
+
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
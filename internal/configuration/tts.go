@@ -0,0 +1,124 @@
+package configuration
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/output"
+	"github.com/BrandonIrizarry/gator/internal/tts"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+  - How 'tts' speaks posts: either by running a local program (such as
+    piper), or through a cloud API.
+*/
+type TTSConfig struct {
+	// The voice used unless '--voice' is given: for piper, say, the
+	// path to a voice model, and for an API, the voice's name.
+	Voice string `json:"voice,omitempty"`
+
+	// A program to run; see 'tts.Command'.
+	Command []string `json:"command,omitempty"`
+
+	API *tts.API `json:"api,omitempty"`
+}
+
+/*
+  - Read the given post (by ID or URL) aloud into an audio file, so
+    that it can be listened to like a podcast. What's read is the
+    post's title, followed by its content as saved by 'agg'. The
+    output file's extension (mp3 unless otherwise given) picks the
+    audio format.
+*/
+func handlerTTS(state state, args []string) error {
+	flagSet := flag.NewFlagSet("tts", flag.ContinueOnError)
+	voice := flagSet.String("voice", "", "the voice to speak with, overriding the configured one")
+
+	var outPath string
+
+	flagSet.StringVar(&outPath, "o", "", "write the audio to this file")
+	flagSet.StringVar(&outPath, "output", "", "write the audio to this file")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'tts' command: %v", err)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("The 'tts' command takes a single POST-ID or POST-URL argument")
+	}
+
+	if outPath == "" {
+		return fmt.Errorf("The 'tts' command requires -o FILE to write the audio to")
+	}
+
+	speaker, defaultVoice, err := ttsSpeaker(state)
+
+	if err != nil {
+		return err
+	}
+
+	if *voice == "" {
+		*voice = defaultVoice
+	}
+
+	post, err := lookUpPost(state, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	text := strings.TrimSpace(post.Title + ".\n\n" + tts.PlainText(post.Description))
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(outPath), "."))
+
+	if format == "" {
+		format = "mp3"
+	}
+
+	file, err := os.Create(outPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err = speaker.Speak(state.ctx, text, *voice, format, file); err != nil {
+		file.Close()
+		os.Remove(outPath)
+
+		return wrapError(err, "Failed to read post %q aloud", post.Title)
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return output.Message(os.Stdout, state.JSON, spokenPost{Title: post.Title, Output: outPath}, "Read %q aloud into %s", post.Title, outPath)
+}
+
+/** The configured speaker, and the voice it uses by default. */
+func ttsSpeaker(state state) (tts.Speaker, string, error) {
+	config := state.Config.TTS
+
+	switch {
+	case config == nil:
+		return nil, "", fmt.Errorf("No text-to-speech backend is configured (set 'tts' in the config file)")
+	case len(config.Command) > 0 && config.API != nil:
+		return nil, "", fmt.Errorf("The 'tts' config takes either a command or an api, not both")
+	case len(config.Command) > 0:
+		return tts.Command{Command: config.Command}, config.Voice, nil
+	case config.API != nil:
+		return *config.API, config.Voice, nil
+	default:
+		return nil, "", fmt.Errorf("The 'tts' config needs either a command or an api")
+	}
+}
+
+/** The outcome of 'tts'. */
+type spokenPost struct {
+	Title  string `json:"title"`
+	Output string `json:"output"`
+}
@@ -0,0 +1,145 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/google/uuid"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*
+  - Find the feed the user means to unfollow, given as its URL or its
+    name. A name needn't be exact: failing a feed of that name (in any
+    case), feeds whose names contain it are considered, and failing
+    those, feeds whose names are a typo or two away from it. Should
+    several feeds match, the user is asked which they mean, when
+    there's a terminal to ask on.
+*/
+func matchFollow(state state, currentUser database.User, query string) (database.GetFeedFollowsForUserRow, error) {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	for _, follow := range follows {
+		if follow.Feedurl == query {
+			return follow, nil
+		}
+	}
+
+	lowered := strings.ToLower(query)
+
+	tiers := []func(name string) bool{
+		func(name string) bool { return name == lowered },
+		func(name string) bool { return strings.Contains(name, lowered) },
+		func(name string) bool {
+			return editDistance(name, lowered) <= max(1, len([]rune(lowered))/3)
+		},
+	}
+
+	for _, matches := range tiers {
+		candidates := make([]database.GetFeedFollowsForUserRow, 0)
+
+		for _, follow := range follows {
+			if matches(strings.ToLower(follow.Feedname)) {
+				candidates = append(candidates, follow)
+			}
+		}
+
+		if len(candidates) > 0 {
+			return chooseFollow(query, candidates)
+		}
+	}
+
+	return database.GetFeedFollowsForUserRow{}, wrapError(ErrNotFound, "User %q isn't following a feed with URL or name %q", currentUser.Name, query)
+}
+
+/** Pick one of the feeds matching 'query', asking the user if there's more than one. */
+func chooseFollow(query string, candidates []database.GetFeedFollowsForUserRow) (database.GetFeedFollowsForUserRow, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		names := make([]string, 0, len(candidates))
+
+		for _, candidate := range candidates {
+			names = append(names, fmt.Sprintf("%q (%s)", candidate.Feedname, candidate.Feedurl))
+		}
+
+		return database.GetFeedFollowsForUserRow{}, fmt.Errorf("%q could be any of these feeds, so give its URL instead: %s", query, strings.Join(names, ", "))
+	}
+
+	fmt.Fprintf(os.Stderr, "%q could be any of these feeds:\n", query)
+
+	for i, candidate := range candidates {
+		fmt.Fprintf(os.Stderr, "  %d. %s (%s)\n", i+1, candidate.Feedname, candidate.Feedurl)
+	}
+
+	answer, err := promptLine("Feed to unfollow (blank to cancel)", "")
+
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, err
+	}
+
+	if answer == "" {
+		return database.GetFeedFollowsForUserRow{}, fmt.Errorf("Nothing unfollowed")
+	}
+
+	choice, err := strconv.Atoi(answer)
+
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return database.GetFeedFollowsForUserRow{}, fmt.Errorf("No feed numbered %q; expected a number from 1 to %d", answer, len(candidates))
+	}
+
+	return candidates[choice-1], nil
+}
+
+/** Find the followed feed with the given ID. */
+func followByFeedID(state state, currentUser database.User, feedID uuid.UUID) (database.GetFeedFollowsForUserRow, error) {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, currentUser.ID)
+
+	if err != nil {
+		return database.GetFeedFollowsForUserRow{}, wrapError(err, "Failed to fetch the feeds followed by user %q", currentUser.Name)
+	}
+
+	for _, follow := range follows {
+		if follow.FeedID == feedID {
+			return follow, nil
+		}
+	}
+
+	return database.GetFeedFollowsForUserRow{}, wrapError(ErrNotFound, "User %q isn't following a feed with ID %s", currentUser.Name, feedID)
+}
+
+/** The Levenshtein distance between two strings, counted in runes. */
+func editDistance(a string, b string) int {
+	left, right := []rune(a), []rune(b)
+	previous := make([]int, len(right)+1)
+	current := make([]int, len(right)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(left); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(right); j++ {
+			substitution := previous[j-1]
+
+			if left[i-1] != right[j-1] {
+				substitution++
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(right)]
+}
@@ -0,0 +1,514 @@
+package configuration
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/password"
+	"github.com/BrandonIrizarry/gator/internal/tts"
+	"github.com/BrandonIrizarry/gator/internal/web"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+/** How many posts a page of the web UI shows. */
+const webPageSize = 50
+
+/** How much of a post's content is shown under its title, in characters. */
+const webExcerptLength = 280
+
+/** A feed, as listed on the web UI's front page. */
+type webFeed struct {
+	Name   string
+	URL    string
+	Folder string
+	Unread int64
+}
+
+/** A post, as shown by the web UI; 'Seq' identifies it in the UI's own links. */
+type webPost struct {
+	Seq         int64
+	Title       string
+	URL         string
+	Feed        string
+	Author      string
+	Excerpt     string
+	PublishedAt time.Time
+	Read        bool
+	Starred     bool
+}
+
+/** Handles a web UI request, writing the page (or redirect) itself. */
+type webHandler func(state state, w http.ResponseWriter, r *http.Request, user database.User) error
+
+/** The cookie holding a web UI session's token. */
+const webSessionCookie = "gator_session"
+
+/*
+  - Serve a web UI for the given user under /ui/: the feeds they
+    follow, along with how many of each's posts are unread, and each
+    feed's unread posts, which can be marked read (or unread again)
+    and starred.
+
+    A user with a password logs in to the UI with it, starting a
+    session as 'login' does, and the UI also accepts the user's API
+    keys, as the JSON API does. A user without a password has nothing
+    to log in with, so their UI is only served at a loopback 'addr',
+    out of reach of anyone else. Forms posted from other sites are
+    refused either way, so that a page elsewhere can't act on the
+    user's behalf.
+*/
+func registerWebUI(state state, mux *http.ServeMux, user database.User, addr string) error {
+	if user.PasswordHash == "" && !loopbackAddress(addr) {
+		return fmt.Errorf("User %q has no password to log in to the web UI with, so it's only served at a loopback address such as localhost:8080", user.Name)
+	}
+
+	handle := func(pattern string, handler webHandler, authenticated bool) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost && !sameOrigin(r) {
+				http.Error(w, "Cross-site requests aren't allowed", http.StatusForbidden)
+				return
+			}
+
+			state, cancel := requestState(state, r)
+			defer cancel()
+
+			if authenticated {
+				ok, err := webAuthenticated(state, r, user)
+
+				if err != nil {
+					writeWebError(w, err)
+					return
+				}
+
+				if !ok {
+					if r.Method == http.MethodGet {
+						http.Redirect(w, r, "/ui/login", http.StatusSeeOther)
+					} else {
+						http.Error(w, "Log in first", http.StatusUnauthorized)
+					}
+
+					return
+				}
+			}
+
+			if r.Method == http.MethodGet {
+				state = readFromReplica(state)
+			}
+
+			if err := handler(state, w, r, user); err != nil {
+				writeWebError(w, err)
+			}
+		})
+	}
+
+	mux.Handle("GET /ui/static/", http.StripPrefix("/ui/static/", web.Static()))
+
+	handle("GET /ui/login", webLoginPage, false)
+	handle("POST /ui/login", webLogin, false)
+	handle("GET /ui/{$}", webIndex, true)
+	handle("GET /ui/feed", webFeedPosts, true)
+	handle("GET /ui/starred", webStarred, true)
+	handle("POST /ui/feed/read", webMarkFeedRead, true)
+	handle("POST /ui/posts/{seq}/{action}", webPostAction, true)
+
+	return nil
+}
+
+/** Report an error as a plain page, with a status to match its cause. */
+func writeWebError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+	message := err.Error()
+
+	if status == http.StatusInternalServerError {
+		fmt.Fprintf(os.Stderr, "Web UI request failed: %v\n", err)
+		message = "Internal server error"
+	}
+
+	http.Error(w, message, status)
+}
+
+/** Whether 'addr' (as given to 'serve --addr') only listens on a loopback interface. */
+func loopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+/*
+  - Whether a request to the UI of 'user' may go ahead: always for a
+    user without a password, and otherwise only with a session of
+    theirs (as started by logging in to the UI) or one of their API
+    keys.
+*/
+func webAuthenticated(state state, r *http.Request, user database.User) (bool, error) {
+	if user.PasswordHash == "" {
+		return true, nil
+	}
+
+	if r.Header.Get("Authorization") != "" {
+		keyUser, err := apiKeyUser(state, r)
+
+		if errors.Is(err, errUnauthorized) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		return keyUser.ID == user.ID, nil
+	}
+
+	cookie, err := r.Cookie(webSessionCookie)
+
+	if err != nil {
+		return false, nil
+	}
+
+	userID, err := state.db.GetSessionUserID(state.ctx, tokenHash(cookie.Value))
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, wrapError(err, "Failed to look up a web UI session")
+	}
+
+	return userID == user.ID, nil
+}
+
+func webLoginPage(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	if user.PasswordHash == "" {
+		http.Redirect(w, r, "/ui/", http.StatusSeeOther)
+		return nil
+	}
+
+	return renderPage(w, "login.html", map[string]any{
+		"Title": "Log in",
+		"User":  user.Name,
+	})
+}
+
+/** Check the password posted from the login page, and if it's right, start a session. */
+func webLogin(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	if user.PasswordHash == "" {
+		http.Redirect(w, r, "/ui/", http.StatusSeeOther)
+		return nil
+	}
+
+	if !password.Verify(user.PasswordHash, r.PostFormValue("password")) {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return renderPage(w, "login.html", map[string]any{
+			"Title": "Log in",
+			"User":  user.Name,
+			"Error": "Wrong password",
+		})
+	}
+
+	token, err := startSession(state, user)
+
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     webSessionCookie,
+		Value:    token,
+		Path:     "/ui/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.Redirect(w, r, "/ui/", http.StatusSeeOther)
+
+	return nil
+}
+
+/** Whether a request comes from the UI's own pages, as far as the browser says. */
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+
+	return err == nil && parsed.Host == r.Host
+}
+
+func webIndex(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	follows, err := state.db.GetFeedFollowsForUser(state.ctx, user.ID)
+
+	if err != nil {
+		return wrapError(err, "Failed to fetch the feeds followed by user %q", user.Name)
+	}
+
+	feeds := make([]webFeed, 0, len(follows))
+
+	for _, follow := range follows {
+		feeds = append(feeds, webFeed{
+			Name:   follow.Feedname,
+			URL:    follow.Feedurl,
+			Folder: follow.Folder.String,
+			Unread: follow.Unread,
+		})
+	}
+
+	return renderPage(w, "index.html", map[string]any{
+		"Title": "Feeds",
+		"User":  user.Name,
+		"Feeds": feeds,
+	})
+}
+
+/** A feed's unread posts, or with '?all=true', all of them, newest first. */
+func webFeedPosts(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	query := r.URL.Query()
+	feedURL := query.Get("url")
+	all, _ := strconv.ParseBool(query.Get("all"))
+
+	feed, err := state.db.GetFeedByURL(state.ctx, feedURL)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No feed with URL %q", feedURL)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", feedURL)
+	}
+
+	params := database.GetReaderItemsParams{
+		UserID:     user.ID,
+		FeedUrl:    sql.NullString{String: feed.Url, Valid: true},
+		UnreadOnly: !all,
+	}
+
+	posts, more, err := webPosts(state, r, user, params)
+
+	if err != nil {
+		return err
+	}
+
+	return renderPage(w, "posts.html", map[string]any{
+		"Title":   feed.Name,
+		"User":    user.Name,
+		"FeedURL": feed.Url,
+		"All":     all,
+		"Now":     time.Now().Unix(),
+		"Posts":   posts,
+		"More":    more,
+	})
+}
+
+func webStarred(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	params := database.GetReaderItemsParams{
+		UserID:      user.ID,
+		StarredOnly: true,
+	}
+
+	posts, more, err := webPosts(state, r, user, params)
+
+	if err != nil {
+		return err
+	}
+
+	return renderPage(w, "posts.html", map[string]any{
+		"Title": "Starred",
+		"User":  user.Name,
+		"Posts": posts,
+		"More":  more,
+	})
+}
+
+/*
+  - Fetch a page of the posts 'params' asks for, starting after the
+    post given by the 'after' query parameter, if any. Along with them
+    comes the link to the next page, if there may be one.
+*/
+func webPosts(state state, r *http.Request, user database.User, params database.GetReaderItemsParams) ([]webPost, string, error) {
+	query := r.URL.Query()
+	params.Limit = webPageSize
+
+	if query.Get("after") != "" {
+		after, err := strconv.ParseInt(query.Get("after"), 10, 64)
+
+		if err != nil {
+			return nil, "", wrapError(errBadRequest, "Can't parse 'after' %q as a number", query.Get("after"))
+		}
+
+		params.Continuation = sql.NullInt64{Int64: after, Valid: true}
+	}
+
+	items, err := state.db.GetReaderItems(state.ctx, params)
+
+	if err != nil {
+		return nil, "", wrapError(err, "Failed to fetch posts for user %q", user.Name)
+	}
+
+	posts := make([]webPost, 0, len(items))
+
+	for _, item := range items {
+		link := item.Url
+
+		if item.CanonicalUrl != "" {
+			link = item.CanonicalUrl
+		}
+
+		excerpt := []rune(tts.PlainText(item.Description))
+
+		if len(excerpt) > webExcerptLength {
+			excerpt = append(excerpt[:webExcerptLength], '…')
+		}
+
+		posts = append(posts, webPost{
+			Seq:         item.Seq,
+			Title:       item.Title,
+			URL:         link,
+			Feed:        item.FeedName,
+			Author:      item.Author,
+			Excerpt:     string(excerpt),
+			PublishedAt: item.PublishedAt,
+			Read:        item.IsRead,
+			Starred:     item.IsStarred,
+		})
+	}
+
+	more := ""
+
+	if len(items) == webPageSize {
+		query.Set("after", strconv.FormatInt(items[len(items)-1].Seq, 10))
+		more = r.URL.Path + "?" + query.Encode()
+	}
+
+	return posts, more, nil
+}
+
+/*
+  - Mark a feed's posts read, as far as those added before the page
+    showing them was, so that posts which came in since stay unread.
+*/
+func webMarkFeedRead(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := r.URL.Query().Get("url")
+	before, err := strconv.ParseInt(r.PostFormValue("before"), 10, 64)
+
+	if err != nil {
+		return wrapError(errBadRequest, "Can't parse 'before' %q as a time", r.PostFormValue("before"))
+	}
+
+	feed, err := state.db.GetFeedByURL(state.ctx, feedURL)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No feed with URL %q", feedURL)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %q", feedURL)
+	}
+
+	if err = state.db.MarkPostsReadBefore(state.ctx, database.MarkPostsReadBeforeParams{
+		ReadAt:  time.Now(),
+		UserID:  user.ID,
+		Before:  time.Unix(before, 0),
+		FeedSeq: sql.NullInt64{Int64: feed.Seq, Valid: true},
+	}); err != nil {
+		return wrapError(err, "Failed to mark feed %q as read", feed.Name)
+	}
+
+	http.Redirect(w, r, "/ui/", http.StatusSeeOther)
+
+	return nil
+}
+
+/** Mark a post read or unread, or star or unstar it, then go back to the page it was on. */
+func webPostAction(state state, w http.ResponseWriter, r *http.Request, user database.User) error {
+	seq, err := strconv.ParseInt(r.PathValue("seq"), 10, 64)
+
+	if err != nil {
+		return wrapError(errBadRequest, "Can't parse %q as a post number", r.PathValue("seq"))
+	}
+
+	// These amount to tagging the post as a Google Reader client
+	// would.
+	tags := map[string]struct {
+		tag string
+		add bool
+	}{
+		"read":   {readerRead, true},
+		"unread": {readerRead, false},
+		"star":   {readerStarred, true},
+		"unstar": {readerStarred, false},
+	}
+
+	action, ok := tags[r.PathValue("action")]
+
+	if !ok {
+		return wrapError(ErrNotFound, "No such action as %q", r.PathValue("action"))
+	}
+
+	post, err := state.db.GetPostBySeq(state.ctx, seq)
+
+	if err == sql.ErrNoRows {
+		return wrapError(ErrNotFound, "No post numbered %d", seq)
+	}
+
+	if err != nil {
+		return wrapError(err, "Failed to look up post %d", seq)
+	}
+
+	if err = readerTag(state, user, post, action.tag, action.add); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, webReferrer(r), http.StatusSeeOther)
+
+	return nil
+}
+
+/** The UI page a form was posted from, or else the front page; never anywhere off-site. */
+func webReferrer(r *http.Request) string {
+	referrer, err := url.Parse(r.Referer())
+
+	if err != nil || referrer.Host != r.Host || referrer.Path == "" {
+		return "/ui/"
+	}
+
+	return referrer.RequestURI()
+}
+
+/** Render a page, buffering it so that a failure partway doesn't leave half a page. */
+func renderPage(w http.ResponseWriter, name string, data any) error {
+	var page bytes.Buffer
+
+	if err := web.Render(&page, name, data); err != nil {
+		return wrapError(err, "Failed to render page %q", name)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if _, err := page.WriteTo(w); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write page: %v\n", err)
+	}
+
+	return nil
+}
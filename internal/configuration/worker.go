@@ -0,0 +1,123 @@
+package configuration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/ids"
+	"os"
+	"time"
+)
+
+/** How many times a job is attempted before it's given up on. */
+const maxFetchJobAttempts = 5
+
+/*
+  - In distributed mode, 'agg' doesn't fetch feeds itself; instead it
+    enqueues a job for each due feed, to be claimed by one of possibly
+    many 'worker' processes.
+*/
+func enqueueFetch(state state, feed database.Feed) error {
+	if err := state.db.EnqueueFetchJob(state.ctx, database.EnqueueFetchJobParams{
+		ID:        ids.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		FeedID:    feed.ID,
+	}); err != nil {
+		return wrapError(err, "Failed to enqueue fetch job for feed %q", feed.Url)
+	}
+
+	return nil
+}
+
+/*
+  - Claim and run fetch jobs enqueued by 'agg --distributed', until
+    interrupted (whereupon the job at hand is finished first.)
+
+    A claimed job stays invisible to other workers for the duration of
+    '--visibility'. If this worker dies mid-fetch, the job reappears
+    once that expires, and some other worker picks it up.
+*/
+func handlerWorker(state state, args []string) error {
+	hostname, _ := os.Hostname()
+
+	flagSet := flag.NewFlagSet("worker", flag.ContinueOnError)
+	workerID := flagSet.String("id", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "name identifying this worker")
+	visibility := flagSet.Duration("visibility", 5*time.Minute, "how long a claimed job is hidden from other workers")
+	poll := flagSet.Duration("poll", 5*time.Second, "how long to wait when no jobs are available")
+
+	args, err := parseFlags(flagSet, args)
+
+	if err != nil {
+		return fmt.Errorf("The 'worker' command: %v", err)
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("The 'worker' command takes no arguments")
+	}
+
+	fmt.Printf("Worker %q waiting for jobs\n", *workerID)
+
+	// On SIGINT or SIGTERM, finish the job at hand before exiting.
+	shutdown, stopShutdown := notifyShutdown(state.ctx)
+	defer stopShutdown()
+
+	keepPartitionsAhead(shutdown, state)
+
+	// Whether any job was run since the unread counts were last
+	// refreshed.
+	dirty := false
+
+	for shutdown.Err() == nil {
+		job, err := state.db.ClaimFetchJob(state.ctx, database.ClaimFetchJobParams{
+			ClaimedBy: sql.NullString{String: *workerID, Valid: true},
+			VisibleAt: time.Now().Add(*visibility),
+		})
+
+		if err == sql.ErrNoRows {
+			if dirty {
+				refreshUnreadCounts(state)
+				dirty = false
+			}
+
+			sleepUntil(shutdown, time.Now().Add(*poll))
+			continue
+		}
+
+		if err != nil {
+			return wrapError(err, "Failed to claim a fetch job")
+		}
+
+		dirty = true
+
+		if err = runFetchJob(state, job); err != nil {
+			fmt.Fprintf(os.Stderr, "Job %s (attempt %d): %v\n", job.ID, job.Attempts, err)
+
+			// Leave the job in place so that it's retried once its
+			// visibility timeout expires, unless it's failed too
+			// many times already.
+			if job.Attempts < maxFetchJobAttempts {
+				continue
+			}
+
+			fmt.Fprintf(os.Stderr, "Giving up on job %s\n", job.ID)
+		}
+
+		if err = state.db.CompleteFetchJob(state.ctx, job.ID); err != nil {
+			return wrapError(err, "Failed to complete fetch job %s", job.ID)
+		}
+	}
+
+	return nil
+}
+
+func runFetchJob(state state, job database.FetchJob) error {
+	feed, err := state.db.GetFeedByID(state.ctx, job.FeedID)
+
+	if err != nil {
+		return wrapError(err, "Failed to look up feed %s", job.FeedID)
+	}
+
+	return scrapeWithDeadline(state, feed)
+}
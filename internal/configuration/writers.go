@@ -0,0 +1,201 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+/** A batch of fetched items, waiting for a writer to save it. */
+type writeJob struct {
+	state    state
+	feed     database.Feed
+	rssItems []rss.RSSItem
+	markRead bool
+	writes   *feedWrites
+}
+
+/*
+  - The batches of one fetch still being saved, the first of them to
+    fail, and how many new posts they've saved so far.
+*/
+type feedWrites struct {
+	wg    sync.WaitGroup
+	mutex sync.Mutex
+	err   error
+	added int
+}
+
+func (writes *feedWrites) fail(err error) {
+	writes.mutex.Lock()
+	defer writes.mutex.Unlock()
+
+	if writes.err == nil {
+		writes.err = err
+	}
+}
+
+func (writes *feedWrites) add(count int) {
+	writes.mutex.Lock()
+	defer writes.mutex.Unlock()
+
+	writes.added += count
+}
+
+func (writes *feedWrites) failure() error {
+	writes.mutex.Lock()
+	defer writes.mutex.Unlock()
+
+	return writes.err
+}
+
+/*
+  - A fixed set of goroutines saving fetched posts, fed through a
+    bounded queue. Fetches hand their batches over rather than saving
+    them, so that a slow feed server doesn't hold up the database, nor
+    a slow database the fetching; once the queue is full, fetches wait
+    for the writers to catch up.
+*/
+type writerPool struct {
+	jobs chan writeJob
+	wg   sync.WaitGroup
+}
+
+/** Start 'writers' goroutines, with room for 'queueSize' batches waiting on them. */
+func startWriters(writers int, queueSize int) *writerPool {
+	pool := &writerPool{jobs: make(chan writeJob, queueSize)}
+
+	for range writers {
+		pool.wg.Add(1)
+
+		go func() {
+			defer pool.wg.Done()
+
+			for job := range pool.jobs {
+				job.run()
+			}
+		}()
+	}
+
+	return pool
+}
+
+/*
+  - Stop the writers, once they've saved whatever is queued. Nothing
+    may be queued afterwards.
+*/
+func (pool *writerPool) stop() {
+	close(pool.jobs)
+	pool.wg.Wait()
+}
+
+/*
+  - Save the job's batch, recording any failure against its fetch.
+    Like a scrape, a panic while saving is recovered from, so that
+    one bad batch doesn't bring down 'agg'.
+*/
+func (job writeJob) run() {
+	defer job.writes.wg.Done()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Fprintf(os.Stderr, "Recovered from panic while saving posts of %s: %v\n%s", job.feed.Url, recovered, debug.Stack())
+			job.writes.fail(fmt.Errorf("panic: %v", recovered))
+		}
+	}()
+
+	// A batch after one that failed would only fail the same way.
+	if job.writes.failure() != nil {
+		return
+	}
+
+	added, err := savePosts(job.state, job.feed, job.rssItems, job.markRead)
+	job.writes.add(added)
+
+	if err != nil {
+		job.writes.fail(err)
+	}
+}
+
+/*
+  - Saves the posts of a single fetch of a feed: straight away, or,
+    when 'agg' runs a writer pool (see 'db_writers'), by handing them
+    to it.
+*/
+type postSaver struct {
+	state  state
+	feed   database.Feed
+	writes feedWrites
+}
+
+func newPostSaver(state state, feed database.Feed) *postSaver {
+	return &postSaver{state: state, feed: feed}
+}
+
+/** Save a batch of items; see 'savePosts'. */
+func (saver *postSaver) save(rssItems []rss.RSSItem, markRead bool) error {
+	if saver.state.writers == nil {
+		added, err := savePosts(saver.state, saver.feed, rssItems, markRead)
+		saver.writes.add(added)
+
+		return err
+	}
+
+	// Stop fetching once a batch has failed to save.
+	if err := saver.writes.failure(); err != nil {
+		return err
+	}
+
+	saver.writes.wg.Add(1)
+
+	// The job outlives this call, while the caller may reuse the
+	// slice for its next batch (as rss.StreamFeed does), so the job
+	// gets a copy of its own.
+	job := writeJob{
+		state:    saver.state,
+		feed:     saver.feed,
+		rssItems: slices.Clone(rssItems),
+		markRead: markRead,
+		writes:   &saver.writes,
+	}
+
+	select {
+	case saver.state.writers.jobs <- job:
+		return nil
+	case <-saver.state.ctx.Done():
+		saver.writes.wg.Done()
+		return wrapError(saver.state.ctx.Err(), "Gave up waiting to save posts of feed %q", saver.feed.Url)
+	}
+}
+
+/** Save the given items 'batchSize' at a time. */
+func (saver *postSaver) saveInBatches(rssItems []rss.RSSItem, batchSize int, markRead bool) error {
+	for start := 0; start < len(rssItems); start += batchSize {
+		end := min(start+batchSize, len(rssItems))
+
+		if err := saver.save(rssItems[start:end], markRead); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/** How many new posts the batches saved so far held; see 'wait'. */
+func (saver *postSaver) added() int {
+	saver.writes.mutex.Lock()
+	defer saver.writes.mutex.Unlock()
+
+	return saver.writes.added
+}
+
+/** Wait for every batch handed over so far to be saved, returning the first failure. */
+func (saver *postSaver) wait() error {
+	saver.writes.wg.Wait()
+
+	return saver.writes.failure()
+}
@@ -0,0 +1,110 @@
+package crosspost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/** The server used when a Bluesky account doesn't name its own. */
+const defaultBlueskyService = "https://bsky.social"
+
+/** A Bluesky account to post from. */
+type BlueskyAccount struct {
+	// The account's PDS; defaults to bsky.social.
+	Service string `json:"service,omitempty"`
+
+	// The account's handle (for example, "jane.bsky.social") and an
+	// app password created under Settings > App Passwords.
+	Handle      string `json:"handle"`
+	AppPassword string `json:"app_password"`
+}
+
+/*
+  - Post the given text, returning the new post's URL. Occurrences of
+    'link' in the text are made clickable, since Bluesky doesn't
+    detect links by itself.
+*/
+func Bluesky(ctx context.Context, account BlueskyAccount, text string, link string) (string, error) {
+	if account.Handle == "" || account.AppPassword == "" {
+		return "", fmt.Errorf("The Bluesky account needs both a handle and an app_password")
+	}
+
+	service := strings.TrimSuffix(account.Service, "/")
+
+	if service == "" {
+		service = defaultBlueskyService
+	}
+
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+		DID       string `json:"did"`
+	}
+
+	if err := postJSON(ctx, service+"/xrpc/com.atproto.server.createSession", "", map[string]string{
+		"identifier": account.Handle,
+		"password":   account.AppPassword,
+	}, &session); err != nil {
+		return "", err
+	}
+
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if facets := linkFacets(text, link); len(facets) > 0 {
+		record["facets"] = facets
+	}
+
+	var created struct {
+		URI string `json:"uri"`
+	}
+
+	if err := postJSON(ctx, service+"/xrpc/com.atproto.repo.createRecord", session.AccessJwt, map[string]any{
+		"repo":       session.DID,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	}, &created); err != nil {
+		return "", err
+	}
+
+	// The record's URI is "at://DID/app.bsky.feed.post/RKEY".
+	rkey := created.URI[strings.LastIndex(created.URI, "/")+1:]
+
+	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", account.Handle, rkey), nil
+}
+
+/** Rich-text facets marking every occurrence of 'link' in 'text'. */
+func linkFacets(text string, link string) []map[string]any {
+	facets := make([]map[string]any, 0)
+
+	if link == "" {
+		return facets
+	}
+
+	// Facets are addressed by UTF-8 byte offsets, which is what Go's
+	// string indices already are.
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], link)
+
+		if index < 0 {
+			return facets
+		}
+
+		start := offset + index
+		end := start + len(link)
+
+		facets = append(facets, map[string]any{
+			"index": map[string]int{"byteStart": start, "byteEnd": end},
+			"features": []map[string]string{{
+				"$type": "app.bsky.richtext.facet#link",
+				"uri":   link,
+			}},
+		})
+
+		offset = end
+	}
+}
@@ -0,0 +1,57 @@
+package crosspost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var client = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+/*
+  - Send a request with the given JSON body (if any) and bearer token
+    (if any), decoding the JSON response into 'result'.
+*/
+func postJSON(ctx context.Context, url string, token string, body any, result any) error {
+	encoded, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(encoded))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		// Services explain what went wrong in the body, which is more
+		// helpful than the bare status.
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned HTTP status %s: %s", url, resp.Status, bytes.TrimSpace(detail))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
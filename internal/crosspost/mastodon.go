@@ -0,0 +1,36 @@
+package crosspost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+/** A Mastodon account to post from. */
+type MastodonAccount struct {
+	// The instance's base URL, for example "https://mastodon.social".
+	Instance string `json:"instance"`
+
+	// An access token with the 'write:statuses' scope, created under
+	// Preferences > Development.
+	AccessToken string `json:"access_token"`
+}
+
+/** Post the given text as a public status, returning its URL. */
+func Mastodon(ctx context.Context, account MastodonAccount, text string) (string, error) {
+	if account.Instance == "" || account.AccessToken == "" {
+		return "", fmt.Errorf("The Mastodon account needs both an instance and an access_token")
+	}
+
+	var status struct {
+		URL string `json:"url"`
+	}
+
+	url := strings.TrimSuffix(account.Instance, "/") + "/api/v1/statuses"
+
+	if err := postJSON(ctx, url, account.AccessToken, map[string]string{"status": text}, &status); err != nil {
+		return "", err
+	}
+
+	return status.URL, nil
+}
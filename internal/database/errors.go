@@ -0,0 +1,35 @@
+package database
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	"github.com/michaljemala/pqerror"
+)
+
+/** The names of the unique constraints callers care to tell apart. */
+const (
+	FeedsURLKey          = "feeds_url_key"
+	FoldersUserIDNameKey = "folders_user_id_name_key"
+	ApiKeysUserIDNameKey = "api_keys_user_id_name_key"
+)
+
+/*
+  - Report whether 'err' is a violation of the given unique constraint.
+    An empty 'constraint' matches a violation of any unique constraint.
+
+    Keeping this check in one place means the rest of the code needn't
+    know which driver reports the violation, or how.
+*/
+func IsUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	if pqErr.Code != pqerror.UniqueViolation {
+		return false
+	}
+
+	return constraint == "" || pqErr.Constraint == constraint
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
@@ -23,10 +24,11 @@ WITH inserted_feed_follow AS (
             $4,
             $5
      )
+     ON CONFLICT (user_id, feed_id) DO NOTHING
 
-     RETURNING id, created_at, updated_at, user_id, feed_id
+     RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
 )
-SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, feeds.name AS feedname, users.name AS username
+SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder_id, feeds.name AS feedname, users.name AS username
 FROM inserted_feed_follow
 INNER JOIN feeds
 ON feeds.id = inserted_feed_follow.feed_id
@@ -48,6 +50,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
 	Feedname  string
 	Username  string
 }
@@ -67,6 +70,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
 		&i.Feedname,
 		&i.Username,
 	)
@@ -75,7 +79,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 
 const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows USING feeds
-WHERE feed_follows.user_id = $1 AND feeds.url = $2
+WHERE feeds.id = feed_follows.feed_id
+  AND feed_follows.user_id = $1 AND feeds.url = $2
 `
 
 type DeleteFeedFollowParams struct {
@@ -92,22 +97,37 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feeds.name AS feedname
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder_id, feeds.name AS feedname, feeds.url AS feedurl,
+       COALESCE(unread_counts.unread, 0)::bigint AS unread,
+       (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feed_follows.feed_id)::timestamp AS last_post_at,
+       folders.name AS folder,
+       COALESCE((SELECT array_agg(feed_tags.tag ORDER BY feed_tags.tag) FROM feed_tags WHERE feed_tags.feed_id = feed_follows.feed_id), '{}')::text[] AS tags
 FROM feed_follows
 INNER JOIN feeds
 ON feeds.id = feed_follows.feed_id
 INNER JOIN users
 ON users.id = feed_follows.user_id
+LEFT JOIN unread_counts
+ON unread_counts.user_id = feed_follows.user_id AND unread_counts.feed_id = feed_follows.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
 WHERE users.id = $1
+ORDER BY feeds.name
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	Feedname  string
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	FeedID     uuid.UUID
+	FolderID   uuid.NullUUID
+	Feedname   string
+	Feedurl    string
+	Unread     int64
+	LastPostAt sql.NullTime
+	Folder     sql.NullString
+	Tags       []string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -125,65 +145,13 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]Ge
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
 			&i.Feedname,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_id, feeds.id, feeds.created_at, feeds.updated_at, name, url, feeds.user_id, last_fetched_at FROM feed_follows
-INNER JOIN feeds
-ON feeds.id = feed_follows.feed_id
-ORDER BY feeds.last_fetched_at NULLS FIRST
-`
-
-type GetNextFeedToFetchRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	FeedID        uuid.UUID
-	ID_2          uuid.UUID
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	Name          string
-	Url           string
-	UserID_2      uuid.UUID
-	LastFetchedAt sql.NullTime
-}
-
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) ([]GetNextFeedToFetchRow, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedToFetch)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetNextFeedToFetchRow
-	for rows.Next() {
-		var i GetNextFeedToFetchRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.UserID,
-			&i.FeedID,
-			&i.ID_2,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.Name,
-			&i.Url,
-			&i.UserID_2,
-			&i.LastFetchedAt,
+			&i.Feedurl,
+			&i.Unread,
+			&i.LastPostAt,
+			&i.Folder,
+			pq.Array(&i.Tags),
 		); err != nil {
 			return nil, err
 		}
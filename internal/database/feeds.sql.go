@@ -7,9 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeed = `-- name: CreateFeed :one
@@ -23,7 +25,7 @@ VALUES (
        $6
 )
 
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq
 `
 
 type CreateFeedParams struct {
@@ -32,7 +34,7 @@ type CreateFeedParams struct {
 	UpdatedAt time.Time
 	Name      string
 	Url       string
-	UserID    uuid.UUID
+	UserID    uuid.NullUUID
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -53,12 +55,42 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastError,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.Seq,
+	)
+	return i, err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.LastError,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.Seq,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq FROM feeds
 WHERE url = $1
 `
 
@@ -73,12 +105,17 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.LastError,
+		&i.Etag,
+		&i.LastModified,
+		&i.FailureCount,
+		&i.Seq,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -98,6 +135,234 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.LastError,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeeds = `-- name: GetFollowedFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq FROM feeds
+WHERE EXISTS (
+      SELECT 1 FROM feed_follows
+      WHERE feed_follows.feed_id = feeds.id
+)
+AND NOT EXISTS (
+      SELECT 1 FROM feed_settings
+      WHERE feed_settings.feed_id = feeds.id AND feed_settings.paused_at IS NOT NULL
+)
+ORDER BY feeds.last_fetched_at NULLS FIRST
+`
+
+func (q *Queries) GetFollowedFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastError,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextNFeedsToFetch = `-- name: GetNextNFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id IN (
+      SELECT due.id FROM feeds AS due
+      LEFT JOIN feed_settings
+      ON feed_settings.feed_id = due.id
+      WHERE EXISTS (
+            SELECT 1 FROM feed_follows
+            WHERE feed_follows.feed_id = due.id
+      )
+        AND feed_settings.paused_at IS NULL
+        AND due.failure_count < $1::int
+        AND (due.last_fetched_at IS NULL OR due.last_fetched_at <= CURRENT_TIMESTAMP - interval '1 second' * GREATEST(
+            COALESCE(feed_settings.fetch_interval_seconds, $2::int),
+            LEAST(COALESCE(feed_settings.fetch_interval_seconds, $2::int) * power(2, LEAST(due.failure_count, 20)), 86400)
+        ))
+      ORDER BY due.last_fetched_at NULLS FIRST
+      LIMIT $3
+      FOR UPDATE OF due SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, last_error, etag, last_modified, failure_count, seq
+`
+
+type GetNextNFeedsToFetchParams struct {
+	MaxFailures     int32
+	IntervalSeconds int32
+	Limit           int32
+}
+
+func (q *Queries) GetNextNFeedsToFetch(ctx context.Context, arg GetNextNFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextNFeedsToFetch, arg.MaxFailures, arg.IntervalSeconds, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastError,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeeds = `-- name: ListFeeds :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.last_error, feeds.etag, feeds.last_modified, feeds.failure_count, feeds.seq, users.name AS username, COUNT(feed_follows.id) AS followers,
+       (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feeds.id)::timestamp AS last_post_at,
+       COALESCE((SELECT array_agg(feed_tags.tag ORDER BY feed_tags.tag) FROM feed_tags WHERE feed_tags.feed_id = feeds.id), '{}')::text[] AS tags,
+       feed_settings.paused_at
+FROM feeds
+LEFT JOIN users
+ON users.id = feeds.user_id
+LEFT JOIN feed_follows
+ON feed_follows.feed_id = feeds.id
+LEFT JOIN feed_settings
+ON feed_settings.feed_id = feeds.id
+WHERE ($1::uuid IS NULL OR feeds.user_id = $1)
+  AND ($2::text IS NULL OR EXISTS (
+      SELECT 1 FROM feed_tags
+      WHERE feed_tags.feed_id = feeds.id AND feed_tags.tag = $2
+  ))
+  AND (NOT $3::boolean OR feeds.last_error IS NOT NULL)
+  AND (NOT $4::boolean OR feed_settings.paused_at IS NOT NULL)
+  AND (NOT $5::boolean OR feeds.failure_count >= $6::int)
+GROUP BY feeds.id, users.name, feed_settings.paused_at
+ORDER BY
+  CASE WHEN $7::text = 'followers' THEN COUNT(feed_follows.id) END DESC,
+  CASE WHEN $7::text = 'activity' THEN (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feeds.id) END DESC NULLS LAST,
+  CASE WHEN $7::text = 'added' THEN feeds.created_at END DESC,
+  feeds.name
+`
+
+type ListFeedsParams struct {
+	UserID      uuid.NullUUID
+	Tag         sql.NullString
+	DeadOnly    bool
+	PausedOnly  bool
+	BrokenOnly  bool
+	MaxFailures int32
+	SortBy      string
+}
+
+type ListFeedsRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Name          string
+	Url           string
+	UserID        uuid.NullUUID
+	LastFetchedAt sql.NullTime
+	LastError     sql.NullString
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FailureCount  int32
+	Seq           int64
+	Username      sql.NullString
+	Followers     int64
+	LastPostAt    sql.NullTime
+	Tags          []string
+	PausedAt      sql.NullTime
+}
+
+func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]ListFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds,
+		arg.UserID,
+		arg.Tag,
+		arg.DeadOnly,
+		arg.PausedOnly,
+		arg.BrokenOnly,
+		arg.MaxFailures,
+		arg.SortBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeedsRow
+	for rows.Next() {
+		var i ListFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.LastError,
+			&i.Etag,
+			&i.LastModified,
+			&i.FailureCount,
+			&i.Seq,
+			&i.Username,
+			&i.Followers,
+			&i.LastPostAt,
+			pq.Array(&i.Tags),
+			&i.PausedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -123,3 +388,54 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const recordFeedError = `-- name: RecordFeedError :one
+UPDATE feeds
+SET last_error = $2,
+    failure_count = CASE WHEN $2 IS NULL THEN 0 ELSE failure_count + 1 END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id = $1
+RETURNING failure_count
+`
+
+type RecordFeedErrorParams struct {
+	ID        uuid.UUID
+	LastError sql.NullString
+}
+
+func (q *Queries) RecordFeedError(ctx context.Context, arg RecordFeedErrorParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedError, arg.ID, arg.LastError)
+	var failure_count int32
+	err := row.Scan(&failure_count)
+	return failure_count, err
+}
+
+const resetFeedFailures = `-- name: ResetFeedFailures :exec
+UPDATE feeds
+SET failure_count = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id = $1
+`
+
+func (q *Queries) ResetFeedFailures(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resetFeedFailures, id)
+	return err
+}
+
+const setFeedValidators = `-- name: SetFeedValidators :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3
+WHERE feeds.id = $1
+`
+
+type SetFeedValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) SetFeedValidators(ctx context.Context, arg SetFeedValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
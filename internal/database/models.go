@@ -6,19 +6,64 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	KeyHash    string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+type AuthorFollow struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Author    string
+}
+
+type DigestedPost struct {
+	UserID     uuid.UUID
+	PostID     uuid.UUID
+	DigestedAt time.Time
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	Name          string
 	Url           string
-	UserID        uuid.UUID
+	UserID        uuid.NullUUID
 	LastFetchedAt sql.NullTime
+	LastError     sql.NullString
+	Etag          sql.NullString
+	LastModified  sql.NullString
+	FailureCount  int32
+	Seq           int64
+}
+
+type FeedChange struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	ChangedAt time.Time
+	Field     string
+	OldValue  string
+	NewValue  string
+}
+
+type FeedChannel struct {
+	FeedID      uuid.UUID
+	Title       string
+	Description string
+	SelfUrl     string
+	UpdatedAt   time.Time
 }
 
 type FeedFollow struct {
@@ -27,22 +72,123 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+type FeedSetting struct {
+	FeedID               uuid.UUID
+	UpdatedAt            time.Time
+	MaxItemsPerFetch     sql.NullInt32
+	OverflowPolicy       string
+	PausedAt             sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	Paywalled            bool
+	CategoryTags         bool
+}
+
+type FeedSnapshot struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	FetchedAt time.Time
+	Items     json.RawMessage
+}
+
+type FeedTag struct {
+	FeedID uuid.UUID
+	Tag    string
+}
+
+type FetchJob struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FeedID    uuid.UUID
+	ClaimedBy sql.NullString
+	VisibleAt time.Time
+	Attempts  int32
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	Seq       int64
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         uuid.UUID
+	Author         string
+	Paywalled      bool
+	ReadingSeconds int32
+	Seq            int64
+	DedupeKey      string
+	CanonicalUrl   string
+}
+
+type PostArchive struct {
 	Url         string
+	SnapshotUrl string
+	ArchivedAt  time.Time
+}
+
+type PostOpen struct {
+	ID       uuid.UUID
+	PostID   uuid.UUID
+	FeedID   uuid.UUID
+	OpenedAt time.Time
+	Via      string
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostTag struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Session struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type StarredPost struct {
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
+	Url         string
+	Title       string
 	Description string
+	Author      string
+	FeedName    string
 	PublishedAt time.Time
-	FeedID      uuid.UUID
+	StarredAt   time.Time
+}
+
+type UnreadCount struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Unread int64
 }
 
 type User struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash string
 }
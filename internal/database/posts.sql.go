@@ -7,48 +7,154 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const createPosts = `-- name: CreatePosts :many
+INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, dedupe_key)
+SELECT item.id, $1, $1, item.title, item.url, item.description,
+       item.published_at, $2, item.author, item.paywalled, item.reading_seconds, item.dedupe_key
+FROM unnest($3::uuid[], $4::text[], $5::text[], $6::text[],
+            $7::timestamp[], $8::text[], $9::boolean[],
+            $10::int[], $11::text[])
+     AS item(id, title, url, description, published_at, author, paywalled, reading_seconds, dedupe_key)
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.url = item.url)
+ON CONFLICT DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, seq, dedupe_key, canonical_url
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+type CreatePostsParams struct {
+	CreatedAt      time.Time
+	FeedID         uuid.UUID
+	Ids            []uuid.UUID
+	Titles         []string
+	Urls           []string
+	Descriptions   []string
+	PublishedAts   []time.Time
+	Authors        []string
+	Paywalled      []bool
+	ReadingSeconds []int32
+	DedupeKeys     []string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
+func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, createPosts,
 		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
 		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.Authors),
+		pq.Array(arg.Paywalled),
+		pq.Array(arg.ReadingSeconds),
+		pq.Array(arg.DedupeKeys),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, seq, dedupe_key, canonical_url FROM posts
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Author,
+		&i.Paywalled,
+		&i.ReadingSeconds,
+		&i.Seq,
+		&i.DedupeKey,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const getPostBySeq = `-- name: GetPostBySeq :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, seq, dedupe_key, canonical_url FROM posts
+WHERE seq = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPostBySeq(ctx context.Context, seq int64) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostBySeq, seq)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Author,
+		&i.Paywalled,
+		&i.ReadingSeconds,
+		&i.Seq,
+		&i.DedupeKey,
+		&i.CanonicalUrl,
 	)
+	return i, err
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, seq, dedupe_key, canonical_url FROM posts
+WHERE url = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -59,42 +165,147 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Author,
+		&i.Paywalled,
+		&i.ReadingSeconds,
+		&i.Seq,
+		&i.DedupeKey,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
 
+const getPostsForFeed = `-- name: GetPostsForFeed :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, seq, dedupe_key, canonical_url FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
+`
+
+func (q *Queries) GetPostsForFeed(ctx context.Context, feedID uuid.UUID) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForFeed, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, title, url, description, published_at, posts.feed_id, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, user_id, feed_follows.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.paywalled, posts.reading_seconds, posts.seq, posts.dedupe_key, posts.canonical_url, feeds.name AS feedname,
+       COALESCE(feed_settings.paywalled, false) AS feed_paywalled
+FROM posts
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+LEFT JOIN feed_settings
+ON feed_settings.feed_id = posts.feed_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+  AND (NOT $2::boolean OR NOT EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+        AND ($3::timestamp IS NULL OR post_reads.read_at < $3)
+  ))
+  AND ($3::timestamp IS NULL OR posts.published_at < $3)
+  AND (NOT $4::boolean OR NOT (posts.paywalled OR COALESCE(feed_settings.paywalled, false)))
+  AND ($5::uuid IS NULL OR feed_follows.folder_id = $5)
+  AND ($6::text IS NULL OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.user_id = $1 AND post_tags.post_id = posts.id AND post_tags.tag = $6
+  ))
+  AND ($7::int IS NULL OR posts.reading_seconds <= $7)
+  AND ($8::timestamp IS NULL OR
+       CASE WHEN $9::text = 'added' THEN posts.created_at ELSE posts.published_at END >= $8)
+  AND (NOT $10::boolean OR posts.dedupe_key = '' OR NOT EXISTS (
+      SELECT 1 FROM posts AS earlier
+      INNER JOIN feed_follows AS earlier_follows
+      ON earlier_follows.feed_id = earlier.feed_id AND earlier_follows.user_id = $1
+      WHERE earlier.dedupe_key = posts.dedupe_key
+        AND (earlier.published_at, earlier.id) < (posts.published_at, posts.id)
+  ))
+ORDER BY CASE WHEN $9::text = 'added' THEN posts.created_at ELSE posts.published_at END DESC, posts.id
+LIMIT $11
+OFFSET $12
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID            uuid.UUID
+	UnreadOnly        bool
+	AsOf              sql.NullTime
+	HidePaywalled     bool
+	FolderID          uuid.NullUUID
+	Tag               sql.NullString
+	MaxReadingSeconds sql.NullInt32
+	Since             sql.NullTime
+	OrderBy           string
+	Dedupe            bool
+	Limit             int32
+	Offset            int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
-	ID_2        uuid.UUID
-	CreatedAt_2 time.Time
-	UpdatedAt_2 time.Time
-	UserID      uuid.UUID
-	FeedID_2    uuid.UUID
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         uuid.UUID
+	Author         string
+	Paywalled      bool
+	ReadingSeconds int32
+	Seq            int64
+	DedupeKey      string
+	CanonicalUrl   string
+	Feedname       string
+	FeedPaywalled  bool
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.AsOf,
+		arg.HidePaywalled,
+		arg.FolderID,
+		arg.Tag,
+		arg.MaxReadingSeconds,
+		arg.Since,
+		arg.OrderBy,
+		arg.Dedupe,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -111,11 +322,172 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
-			&i.ID_2,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.UserID,
-			&i.FeedID_2,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+			&i.Feedname,
+			&i.FeedPaywalled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserSince = `-- name: GetPostsForUserSince :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.paywalled, posts.reading_seconds, posts.seq, posts.dedupe_key, posts.canonical_url, feeds.name AS feedname FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $1 AND posts.published_at >= $2
+ORDER BY feeds.name, posts.published_at DESC
+`
+
+type GetPostsForUserSinceParams struct {
+	UserID      uuid.UUID
+	PublishedAt time.Time
+}
+
+type GetPostsForUserSinceRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         uuid.UUID
+	Author         string
+	Paywalled      bool
+	ReadingSeconds int32
+	Seq            int64
+	DedupeKey      string
+	CanonicalUrl   string
+	Feedname       string
+}
+
+func (q *Queries) GetPostsForUserSince(ctx context.Context, arg GetPostsForUserSinceParams) ([]GetPostsForUserSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserSince, arg.UserID, arg.PublishedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserSinceRow
+	for rows.Next() {
+		var i GetPostsForUserSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+			&i.Feedname,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRelatedPosts = `-- name: GetRelatedPosts :many
+WITH source AS (
+     SELECT posts.id, string_agg(quote_literal(lexeme), ' | ')::tsquery AS query
+     FROM posts, unnest(tsvector_to_array(to_tsvector('english', posts.title))) AS lexeme
+     WHERE posts.id = $1
+     GROUP BY posts.id
+)
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.paywalled, posts.reading_seconds, posts.seq, posts.dedupe_key, posts.canonical_url, feeds.name AS feedname,
+       ts_rank(to_tsvector('english', posts.title || ' ' || posts.description), source.query)::real AS rank
+FROM source, posts
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $2
+  AND posts.id <> source.id
+  AND to_tsvector('english', posts.title || ' ' || posts.description) @@ source.query
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $3
+`
+
+type GetRelatedPostsParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type GetRelatedPostsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         uuid.UUID
+	Author         string
+	Paywalled      bool
+	ReadingSeconds int32
+	Seq            int64
+	DedupeKey      string
+	CanonicalUrl   string
+	Feedname       string
+	Rank           float32
+}
+
+func (q *Queries) GetRelatedPosts(ctx context.Context, arg GetRelatedPostsParams) ([]GetRelatedPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRelatedPosts, arg.ID, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRelatedPostsRow
+	for rows.Next() {
+		var i GetRelatedPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+			&i.Feedname,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -129,3 +501,128 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const lockPostURLs = `-- name: LockPostURLs :exec
+SELECT pg_advisory_xact_lock(hashtext('posts'), locked.key)
+FROM (SELECT DISTINCT hashtext(url) AS key FROM unnest($1::text[]) AS url ORDER BY key) AS locked
+`
+
+func (q *Queries) LockPostURLs(ctx context.Context, urls []string) error {
+	_, err := q.db.ExecContext(ctx, lockPostURLs, pq.Array(urls))
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.paywalled, posts.reading_seconds, posts.seq, posts.dedupe_key, posts.canonical_url, feeds.name AS feedname,
+       ts_rank(to_tsvector('english', posts.title || ' ' || posts.description), websearch_to_tsquery('english', $1))::real AS rank
+FROM posts
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $2
+  AND to_tsvector('english', posts.title || ' ' || posts.description) @@ websearch_to_tsquery('english', $1)
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $3
+`
+
+type SearchPostsParams struct {
+	Query  string
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type SearchPostsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Title          string
+	Url            string
+	Description    string
+	PublishedAt    time.Time
+	FeedID         uuid.UUID
+	Author         string
+	Paywalled      bool
+	ReadingSeconds int32
+	Seq            int64
+	DedupeKey      string
+	CanonicalUrl   string
+	Feedname       string
+	Rank           float32
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts, arg.Query, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Paywalled,
+			&i.ReadingSeconds,
+			&i.Seq,
+			&i.DedupeKey,
+			&i.CanonicalUrl,
+			&i.Feedname,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostCanonicalURL = `-- name: SetPostCanonicalURL :exec
+UPDATE posts
+SET canonical_url = $2,
+    dedupe_key = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type SetPostCanonicalURLParams struct {
+	ID           uuid.UUID
+	CanonicalUrl string
+	DedupeKey    string
+}
+
+func (q *Queries) SetPostCanonicalURL(ctx context.Context, arg SetPostCanonicalURLParams) error {
+	_, err := q.db.ExecContext(ctx, setPostCanonicalURL, arg.ID, arg.CanonicalUrl, arg.DedupeKey)
+	return err
+}
+
+const setPostDescription = `-- name: SetPostDescription :exec
+UPDATE posts
+SET description = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type SetPostDescriptionParams struct {
+	ID          uuid.UUID
+	Description string
+}
+
+func (q *Queries) SetPostDescription(ctx context.Context, arg SetPostDescriptionParams) error {
+	_, err := q.db.ExecContext(ctx, setPostDescription, arg.ID, arg.Description)
+	return err
+}
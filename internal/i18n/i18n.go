@@ -0,0 +1,129 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const defaultLanguage = "en"
+
+//go:embed catalog/*.json
+var catalogFS embed.FS
+
+/** The translated strings and date conventions for a single language. */
+type Catalog struct {
+	Language string `json:"-"`
+
+	// Abbreviated names, starting with Sunday.
+	Weekdays []string `json:"weekdays"`
+
+	// Abbreviated names, starting with January.
+	Months []string `json:"months"`
+
+	// A format string taking, in order: the weekday, month, day of
+	// the month, year, and the time of day.
+	DateFormat string `json:"date_format"`
+
+	Messages map[string]string `json:"messages"`
+}
+
+/*
+  - Load the catalog matching the user's locale, as given by the usual
+    POSIX environment variables. Unsupported locales fall back to
+    English.
+*/
+func Detect() (*Catalog, error) {
+	return Load(languageFromEnv())
+}
+
+/** Load the catalog for the given language (for example, "es".) */
+func Load(language string) (*Catalog, error) {
+	data, err := catalogFS.ReadFile(fmt.Sprintf("catalog/%s.json", language))
+
+	if err != nil {
+		if language == defaultLanguage {
+			return nil, err
+		}
+
+		return Load(defaultLanguage)
+	}
+
+	catalog := &Catalog{Language: language}
+
+	if err = json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("Malformed message catalog %q: %v", language, err)
+	}
+
+	return catalog, nil
+}
+
+/** The languages with a catalog, in alphabetical order. */
+func Languages() ([]string, error) {
+	names, err := fs.Glob(catalogFS, "catalog/*.json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make([]string, 0, len(names))
+
+	for _, name := range names {
+		languages = append(languages, strings.TrimSuffix(path.Base(name), ".json"))
+	}
+
+	return languages, nil
+}
+
+/*
+  - Return the message for 'key', formatted with 'args'. A missing
+    message yields the key itself, so that gaps in a catalog are easy
+    to spot.
+*/
+func (catalog *Catalog) T(key string, args ...any) string {
+	message, ok := catalog.Messages[key]
+
+	if !ok {
+		return key
+	}
+
+	return fmt.Sprintf(message, args...)
+}
+
+/** Format 't' according to the catalog's date conventions. */
+func (catalog *Catalog) FormatDate(t time.Time) string {
+	weekday := catalog.Weekdays[t.Weekday()]
+	month := catalog.Months[t.Month()-1]
+
+	return fmt.Sprintf(catalog.DateFormat, weekday, month, t.Day(), t.Year(), t.Format("15:04"))
+}
+
+/*
+  - Determine the language from LC_ALL, LC_MESSAGES, or LANG (in that
+    order of precedence), so that "es_MX.UTF-8" becomes "es".
+*/
+func languageFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+
+		if value == "" {
+			continue
+		}
+
+		if value == "C" || value == "POSIX" {
+			return defaultLanguage
+		}
+
+		language, _, _ := strings.Cut(value, "_")
+		language, _, _ = strings.Cut(language, ".")
+
+		return strings.ToLower(language)
+	}
+
+	return defaultLanguage
+}
@@ -0,0 +1,119 @@
+package ics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+/** The layout iCalendar uses for UTC date-times. */
+const dateTimeLayout = "20060102T150405Z"
+
+/** iCalendar content lines are folded past this many octets. */
+const maxLineLength = 75
+
+/** A single calendar event. */
+type Event struct {
+	// A globally unique, stable identifier, so that re-importing the
+	// calendar updates events rather than duplicating them.
+	UID string
+
+	Start       time.Time
+	Duration    time.Duration
+	Summary     string
+	Description string
+	URL         string
+}
+
+/*
+  - Write the given events as an iCalendar (RFC 5545) document named
+    'name'.
+*/
+func Write(w io.Writer, name string, events []Event) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//gator//gator//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:" + escape(name),
+	}
+
+	stamp := time.Now().UTC().Format(dateTimeLayout)
+
+	for _, event := range events {
+		start := event.Start.UTC()
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escape(event.UID),
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+start.Format(dateTimeLayout),
+			"DTEND:"+start.Add(event.Duration).Format(dateTimeLayout),
+			"SUMMARY:"+escape(event.Summary),
+		)
+
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escape(event.Description))
+		}
+
+		if event.URL != "" {
+			lines = append(lines, "URL:"+event.URL)
+		}
+
+		lines = append(lines, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := fmt.Fprint(w, fold(line)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/** Escape the characters which are special inside a TEXT value. */
+func escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+/*
+  - Terminate a content line with CRLF, splitting it into continuation
+    lines (which begin with a space) if it's too long. Splits never
+    fall inside a multi-byte character.
+*/
+func fold(line string) string {
+	var builder strings.Builder
+
+	limit := maxLineLength
+
+	for len(line) > limit {
+		cut := limit
+
+		// Back up to the start of a UTF-8 sequence.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		builder.WriteString(line[:cut])
+		builder.WriteString("\r\n ")
+		line = line[cut:]
+
+		// Continuation lines lose one octet to the leading space.
+		limit = maxLineLength - 1
+	}
+
+	builder.WriteString(line)
+	builder.WriteString("\r\n")
+
+	return builder.String()
+}
@@ -0,0 +1,94 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+/** Makes the IDs of new rows. */
+type Generator interface {
+	New() uuid.UUID
+}
+
+/** Random (version 4) UUIDs, as gator has always used. */
+type Random struct{}
+
+func (Random) New() uuid.UUID {
+	return uuid.New()
+}
+
+/*
+  - ULIDs: a 48-bit timestamp, in milliseconds, followed by 80 random
+    bits, stored in a UUID column like any other ID. Since they sort by
+    when they were made, rows made one after the other land next to
+    each other in an index, rather than anywhere at all. Within a
+    millisecond, each ID is one more than the last, so that they stay
+    in order.
+*/
+type ULID struct {
+	mutex sync.Mutex
+	last  uuid.UUID
+}
+
+func (generator *ULID) New() uuid.UUID {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+
+	var id uuid.UUID
+
+	binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixMilli())<<16)
+
+	if millis(id) <= millis(generator.last) {
+		id = generator.last
+
+		// Carry the increment through the random bits; should they
+		// ever overflow, the timestamp moves on by a millisecond.
+		for i := len(id) - 1; i >= 0; i-- {
+			id[i]++
+
+			if id[i] != 0 {
+				break
+			}
+		}
+	} else if _, err := rand.Read(id[6:]); err != nil {
+		panic(err)
+	}
+
+	generator.last = id
+
+	return id
+}
+
+func millis(id uuid.UUID) uint64 {
+	return binary.BigEndian.Uint64(id[:8]) >> 16
+}
+
+/** The generator used for new rows; see 'SetFormat'. */
+var Default Generator = Random{}
+
+/** Make a new ID with the default generator. */
+func New() uuid.UUID {
+	return Default.New()
+}
+
+/*
+  - Choose the default generator by name: "uuid" (the default) or
+    "ulid". An empty name leaves it as it is.
+*/
+func SetFormat(name string) error {
+	switch name {
+	case "":
+	case "uuid":
+		Default = Random{}
+	case "ulid":
+		Default = &ULID{}
+	default:
+		return fmt.Errorf("Unknown ID format %q (expected \"uuid\" or \"ulid\")", name)
+	}
+
+	return nil
+}
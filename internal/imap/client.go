@@ -0,0 +1,224 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  - A minimal IMAP4rev1 (RFC 3501) client: just enough to log in, pick a
+    mailbox, search it, and fetch whole messages, without modifying
+    anything.
+*/
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+/*
+  - Connect to the given server ("host:port"), over TLS unless
+    'plaintext' is set, and read its greeting.
+*/
+func Dial(ctx context.Context, addr string, plaintext bool) (*Client, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+
+	if plaintext {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Honour the caller's deadline for the whole conversation.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client := &Client{conn: conn, reader: bufio.NewReader(conn)}
+
+	greeting, _, err := client.readLine()
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("Unexpected IMAP greeting %q", greeting)
+	}
+
+	return client, nil
+}
+
+func (client *Client) Close() error {
+	return client.conn.Close()
+}
+
+func (client *Client) Login(user string, password string) error {
+	_, err := client.command("LOGIN %s %s", quote(user), quote(password))
+	return err
+}
+
+/** Open the given mailbox read-only. */
+func (client *Client) Examine(mailbox string) error {
+	_, err := client.command("EXAMINE %s", quote(mailbox))
+	return err
+}
+
+/** Return the UIDs of the messages sent from the given address. */
+func (client *Client) SearchFrom(from string) ([]uint32, error) {
+	criteria := "ALL"
+
+	if from != "" {
+		criteria = "FROM " + quote(from)
+	}
+
+	responses, err := client.command("UID SEARCH %s", criteria)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]uint32, 0)
+
+	for _, response := range responses {
+		if !strings.HasPrefix(response.line, "* SEARCH") {
+			continue
+		}
+
+		for _, field := range strings.Fields(strings.TrimPrefix(response.line, "* SEARCH")) {
+			uid, err := strconv.ParseUint(field, 10, 32)
+
+			if err != nil {
+				return nil, fmt.Errorf("Malformed IMAP search result %q", response.line)
+			}
+
+			uids = append(uids, uint32(uid))
+		}
+	}
+
+	return uids, nil
+}
+
+/** Return the raw (RFC 5322) message with the given UID, without marking it seen. */
+func (client *Client) Fetch(uid uint32) ([]byte, error) {
+	responses, err := client.command("UID FETCH %d BODY.PEEK[]", uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, response := range responses {
+		if strings.Contains(response.line, "FETCH") && response.literal != nil {
+			return response.literal, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No message with UID %d", uid)
+}
+
+func (client *Client) Logout() error {
+	_, err := client.command("LOGOUT")
+	return err
+}
+
+/** An untagged response, along with the literal it carried, if any. */
+type response struct {
+	line    string
+	literal []byte
+}
+
+/*
+  - Send a tagged command, and collect the untagged responses up to its
+    completion, failing unless it completed with OK.
+*/
+func (client *Client) command(format string, args ...any) ([]response, error) {
+	client.tag++
+	tag := fmt.Sprintf("g%d", client.tag)
+
+	if _, err := fmt.Fprintf(client.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	responses := make([]response, 0)
+
+	for {
+		line, literal, err := client.readLine()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("IMAP server said: %s", status)
+			}
+
+			return responses, nil
+		}
+
+		responses = append(responses, response{line: line, literal: literal})
+	}
+}
+
+/*
+  - Read a single response line. A line announcing a literal ("{N}")
+    is followed by N bytes of data and then the rest of the line; the
+    literal is returned separately.
+*/
+func (client *Client) readLine() (string, []byte, error) {
+	var line strings.Builder
+	var literal []byte
+
+	for {
+		part, err := client.reader.ReadString('\n')
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		part = strings.TrimRight(part, "\r\n")
+		line.WriteString(part)
+
+		open := strings.LastIndex(part, "{")
+
+		if open < 0 || !strings.HasSuffix(part, "}") {
+			return line.String(), literal, nil
+		}
+
+		size, err := strconv.Atoi(part[open+1 : len(part)-1])
+
+		if err != nil {
+			return line.String(), literal, nil
+		}
+
+		literal = make([]byte, size)
+
+		if _, err = io.ReadFull(client.reader, literal); err != nil {
+			return "", nil, err
+		}
+	}
+}
+
+/** Quote a string argument, escaping as IMAP requires. */
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,135 @@
+package imap
+
+import (
+	"context"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"net"
+	"net/url"
+	"strings"
+)
+
+/** How many of a newsletter's most recent issues are read per fetch. */
+const DefaultMaxMessages = 50
+
+/*
+  - Turns a mailbox into a feed, so that email newsletters can be
+    followed like any other. Feed URLs take the form
+
+    imaps://USER@HOST[:PORT]/MAILBOX?from=SENDER
+
+    where MAILBOX defaults to INBOX and, if 'from' is given, only
+    messages from SENDER are included; each newsletter is thus a feed
+    of its own. Use "imap://" for servers without TLS.
+*/
+type Fetcher struct {
+	// Passwords, keyed by "USER@HOST".
+	Passwords map[string]string
+
+	// The most messages read per fetch; zero means the default.
+	MaxMessages int
+}
+
+func (fetcher Fetcher) Fetch(ctx context.Context, feedURL string) (*rss.Document, error) {
+	parsed, err := url.Parse(feedURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, fmt.Errorf("The mailbox URL %q needs a USER@ part", feedURL)
+	}
+
+	user := parsed.User.Username()
+	account := fmt.Sprintf("%s@%s", user, parsed.Hostname())
+	password, ok := fetcher.Passwords[account]
+
+	if !ok {
+		return nil, fmt.Errorf("No IMAP password is configured for %s", account)
+	}
+
+	plaintext := parsed.Scheme == "imap"
+	addr := parsed.Host
+
+	if parsed.Port() == "" {
+		port := "993"
+
+		if plaintext {
+			port = "143"
+		}
+
+		addr = net.JoinHostPort(parsed.Hostname(), port)
+	}
+
+	mailbox := strings.TrimPrefix(parsed.Path, "/")
+
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+
+	from := parsed.Query().Get("from")
+
+	client, err := Dial(ctx, addr, plaintext)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Close()
+
+	if err = client.Login(user, password); err != nil {
+		return nil, err
+	}
+
+	if err = client.Examine(mailbox); err != nil {
+		return nil, err
+	}
+
+	uids, err := client.SearchFrom(from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// UIDs ascend with arrival, so the newest messages come last.
+	limit := fetcher.MaxMessages
+
+	if limit == 0 {
+		limit = DefaultMaxMessages
+	}
+
+	if len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+
+	title := from
+
+	if from == "" {
+		title = mailbox
+	}
+
+	rssItems := make([]rss.RSSItem, 0, len(uids))
+
+	for _, uid := range uids {
+		raw, err := client.Fetch(uid)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rssItem, err := parseNewsletter(raw)
+
+		// A message that can't be made sense of shouldn't hold up the
+		// rest of the newsletter.
+		if err != nil {
+			continue
+		}
+
+		rssItems = append(rssItems, rssItem)
+	}
+
+	client.Logout()
+
+	return rss.NewDocument(title, feedURL, rssItems)
+}
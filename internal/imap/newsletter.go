@@ -0,0 +1,142 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+  - Convert a raw email into a feed item. Since emails have no URL of
+    their own, each is linked by its Message-ID (as a "mid:" URL, per
+    RFC 2392), which also keeps it from being saved twice.
+*/
+func parseNewsletter(raw []byte) (rss.RSSItem, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+
+	if err != nil {
+		return rss.RSSItem{}, err
+	}
+
+	messageID := strings.Trim(message.Header.Get("Message-Id"), "<> ")
+
+	if messageID == "" {
+		return rss.RSSItem{}, fmt.Errorf("Message has no Message-ID")
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(message.Header.Get("Subject"))
+
+	if err != nil {
+		subject = message.Header.Get("Subject")
+	}
+
+	rssItem := rss.RSSItem{
+		Title: subject,
+		Link:  "mid:" + messageID,
+	}
+
+	if date, err := message.Header.Date(); err == nil {
+		rssItem.PubDate = date.Format(time.RFC1123Z)
+	}
+
+	if from, err := message.Header.AddressList("From"); err == nil && len(from) > 0 {
+		rssItem.Creator = from[0].Name
+
+		if rssItem.Creator == "" {
+			rssItem.Creator = from[0].Address
+		}
+	}
+
+	body, err := messageText(message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), message.Body)
+
+	if err != nil {
+		return rss.RSSItem{}, err
+	}
+
+	rssItem.Description = strings.Join(strings.Fields(body), " ")
+
+	return rssItem, nil
+}
+
+/** Tags, for reducing HTML-only newsletters to text. */
+var tagPattern = regexp.MustCompile(`(?s)<style.*?</style>|<[^>]*>`)
+
+/*
+  - Return the text of a message (or of one part of it), preferring a
+    plain text version to an HTML one.
+*/
+func messageText(contentType string, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		html := ""
+
+		for {
+			part, err := reader.NextPart()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return "", err
+			}
+
+			text, err := messageText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+
+			if err != nil {
+				continue
+			}
+
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+
+			if partType == "text/plain" && text != "" {
+				return text, nil
+			}
+
+			if html == "" {
+				html = text
+			}
+		}
+
+		return html, nil
+	}
+
+	if !strings.HasPrefix(mediaType, "text/") {
+		return "", nil
+	}
+
+	switch strings.ToLower(transferEncoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	decoded, err := io.ReadAll(body)
+
+	if err != nil {
+		return "", err
+	}
+
+	if mediaType == "text/html" {
+		return tagPattern.ReplaceAllString(string(decoded), " "), nil
+	}
+
+	return string(decoded), nil
+}
@@ -0,0 +1,290 @@
+package jsonfeed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  - Turns a JSON API into a feed, for services (weather forecasts,
+    status pages, and the like) which offer no RSS. 'Items' is a path
+    (see 'Path') leading to the values which become posts; the other
+    fields are picked out of each of those.
+
+    Each field is either a path, such as ".headline", or a template
+    with paths in braces, such as "{.temperature}°C, {.summary}". Dates
+    may be strings (RFC 3339 or thereabouts) or Unix timestamps. Items
+    without a link are given one of their own, made from the
+    endpoint's URL and their contents, so that each distinct item
+    makes a post of its own.
+*/
+type Fetcher struct {
+	Client  *http.Client
+	Headers map[string]string
+
+	Items       string
+	Title       string
+	Link        string
+	Date        string
+	Description string
+}
+
+/** Check that each of the fetcher's paths and templates parses. */
+func (fetcher Fetcher) Validate() error {
+	if _, err := ParsePath(fetcher.Items); err != nil {
+		return err
+	}
+
+	for _, field := range []string{fetcher.Title, fetcher.Link, fetcher.Date, fetcher.Description} {
+		if _, err := parseField(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (fetcher Fetcher) Fetch(ctx context.Context, feedURL string) (*rss.Document, error) {
+	document, err := fetcher.get(ctx, feedURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	itemsPath, err := ParsePath(fetcher.Items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]field)
+
+	for name, expression := range map[string]string{
+		"title":       fetcher.Title,
+		"link":        fetcher.Link,
+		"date":        fetcher.Date,
+		"description": fetcher.Description,
+	} {
+		if fields[name], err = parseField(expression); err != nil {
+			return nil, err
+		}
+	}
+
+	base, err := url.Parse(feedURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := time.Now().UTC().Format(time.RFC3339)
+	rssItems := make([]rss.RSSItem, 0)
+
+	for _, item := range itemsPath.Evaluate(document) {
+		if item == nil {
+			continue
+		}
+
+		rssItem := rss.RSSItem{
+			Title:       fields["title"].render(item),
+			Description: fields["description"].render(item),
+			PubDate:     normalizeDate(fields["date"].value(item)),
+		}
+
+		if link := fields["link"].render(item); link != "" {
+			if resolved, err := base.Parse(link); err == nil {
+				rssItem.Link = resolved.String()
+			}
+		}
+
+		if rssItem.Link == "" {
+			sum := sha256.Sum256([]byte(rssItem.Title + "\n" + rssItem.PubDate + "\n" + rssItem.Description))
+			rssItem.Link = feedURL + "#item-" + hex.EncodeToString(sum[:6])
+		}
+
+		// Undated items count as published when first seen.
+		if rssItem.PubDate == "" {
+			rssItem.PubDate = seen
+		}
+
+		rssItems = append(rssItems, rssItem)
+	}
+
+	return rss.NewDocument(base.Host+base.Path, feedURL, rssItems)
+}
+
+/** GET the endpoint, decoding its JSON. */
+func (fetcher Fetcher) get(ctx context.Context, endpoint string) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/json")
+
+	for name, value := range fetcher.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := fetcher.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP status %s from %q", resp.Status, endpoint)
+	}
+
+	var document any
+
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, 64<<20))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("Malformed JSON from %q: %w", endpoint, err)
+	}
+
+	return document, nil
+}
+
+/** The paths in braces within a template. */
+var placeholderPattern = regexp.MustCompile(`\{(\.[^{}]*)\}`)
+
+/*
+  - A field of each item: either a single path, or a template with
+    paths embedded in it. An empty field renders as nothing.
+*/
+type field struct {
+	path     *Path
+	template string
+	paths    map[string]Path
+}
+
+func parseField(expression string) (field, error) {
+	if strings.HasPrefix(expression, ".") {
+		path, err := ParsePath(expression)
+
+		if err != nil {
+			return field{}, err
+		}
+
+		return field{path: &path}, nil
+	}
+
+	f := field{template: expression, paths: make(map[string]Path)}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(expression, -1) {
+		path, err := ParsePath(match[1])
+
+		if err != nil {
+			return field{}, err
+		}
+
+		f.paths[match[1]] = path
+	}
+
+	return f, nil
+}
+
+/** The field's raw value within the given item, for a lone path. */
+func (f field) value(item any) any {
+	if f.path != nil {
+		return f.path.First(item)
+	}
+
+	return f.render(item)
+}
+
+/** The field's value within the given item, as text. */
+func (f field) render(item any) string {
+	if f.path != nil {
+		return text(f.path.First(item))
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(f.template, func(placeholder string) string {
+		return text(f.paths[placeholder[1:len(placeholder)-1]].First(item))
+	})
+}
+
+/** Render a JSON value as text, with null as nothing. */
+func text(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+}
+
+/** The string date layouts understood, besides RFC 3339. */
+var dateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+/*
+  - Turn a date into RFC 3339, as the scraper expects. Numbers are Unix
+    timestamps, in seconds, or in milliseconds if they're too large to
+    be seconds. Dates which can't be made sense of are left alone.
+*/
+func normalizeDate(value any) string {
+	if number, ok := value.(json.Number); ok {
+		timestamp, err := number.Float64()
+
+		if err != nil {
+			return number.String()
+		}
+
+		if timestamp > 1e11 {
+			timestamp /= 1000
+		}
+
+		seconds, fraction := math.Modf(timestamp)
+
+		return time.Unix(int64(seconds), int64(fraction*1e9)).UTC().Format(time.RFC3339)
+	}
+
+	date := strings.TrimSpace(text(value))
+
+	if _, err := time.Parse(time.RFC3339, date); err == nil || date == "" {
+		return date
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+
+	return date
+}
@@ -0,0 +1,213 @@
+package jsonfeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/** The kinds of step a path takes. */
+const (
+	stepKey = iota
+	stepIndex
+	stepIterate
+)
+
+/** One step of a path, such as ".name", "[0]", or "[]". */
+type step struct {
+	kind  int
+	key   string
+	index int
+}
+
+/*
+  - A path into a JSON document, written in a small subset of jq's
+    syntax: "." is the document itself, ".key" (or ."some key", or
+    .["some key"]) a member of an object, "[N]" an element of an array
+    (counting from the end if negative), and "[]" every element of an
+    array (or value of an object) in turn. Steps chain, as in
+    ".data.alerts[].headline".
+*/
+type Path struct {
+	steps []step
+}
+
+/** Parse a path, as described under 'Path'. */
+func ParsePath(expression string) (Path, error) {
+	path := Path{}
+	rest := strings.TrimSpace(expression)
+
+	if !strings.HasPrefix(rest, ".") {
+		return path, fmt.Errorf("Path %q must start with '.'", expression)
+	}
+
+	for rest != "" {
+		var err error
+
+		switch {
+		case strings.HasPrefix(rest, "["):
+			rest, err = path.parseBracket(rest[1:])
+		case strings.HasPrefix(rest, `."`):
+			var key string
+
+			key, rest, err = parseQuoted(rest[1:])
+			path.steps = append(path.steps, step{kind: stepKey, key: key})
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+
+			if end < 0 {
+				end = len(rest)
+			}
+
+			// A lone "." (or one followed by a bracket) steps nowhere.
+			if end > 0 {
+				path.steps = append(path.steps, step{kind: stepKey, key: rest[:end]})
+			}
+
+			rest = rest[end:]
+		default:
+			err = fmt.Errorf("Unexpected %q", rest)
+		}
+
+		if err != nil {
+			return Path{}, fmt.Errorf("Malformed path %q: %w", expression, err)
+		}
+	}
+
+	return path, nil
+}
+
+/** Parse the inside of a bracketed step, returning what follows it. */
+func (path *Path) parseBracket(rest string) (string, error) {
+	if after, ok := strings.CutPrefix(rest, "]"); ok {
+		path.steps = append(path.steps, step{kind: stepIterate})
+		return after, nil
+	}
+
+	if strings.HasPrefix(rest, `"`) {
+		key, after, err := parseQuoted(rest)
+
+		if err != nil {
+			return "", err
+		}
+
+		after, ok := strings.CutPrefix(after, "]")
+
+		if !ok {
+			return "", fmt.Errorf("Missing ']'")
+		}
+
+		path.steps = append(path.steps, step{kind: stepKey, key: key})
+
+		return after, nil
+	}
+
+	inside, after, ok := strings.Cut(rest, "]")
+
+	if !ok {
+		return "", fmt.Errorf("Missing ']'")
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(inside))
+
+	if err != nil {
+		return "", fmt.Errorf("Bad index %q", inside)
+	}
+
+	path.steps = append(path.steps, step{kind: stepIndex, index: index})
+
+	return after, nil
+}
+
+/** Parse a double-quoted key, returning it along with what follows. */
+func parseQuoted(rest string) (string, string, error) {
+	end := 1
+
+	for end < len(rest) && rest[end] != '"' {
+		// Skip over escaped characters, quotes included.
+		if rest[end] == '\\' {
+			end++
+		}
+
+		end++
+	}
+
+	if end >= len(rest) {
+		return "", "", fmt.Errorf("Unterminated string")
+	}
+
+	var key string
+
+	if err := json.Unmarshal([]byte(rest[:end+1]), &key); err != nil {
+		return "", "", err
+	}
+
+	return key, rest[end+1:], nil
+}
+
+/*
+  - Return every value the path leads to within 'document' (as decoded
+    by encoding/json.) As in jq, a missing member is null, rather than
+    an error.
+*/
+func (path Path) Evaluate(document any) []any {
+	values := []any{document}
+
+	for _, step := range path.steps {
+		next := make([]any, 0, len(values))
+
+		for _, value := range values {
+			switch step.kind {
+			case stepKey:
+				object, _ := value.(map[string]any)
+				next = append(next, object[step.key])
+			case stepIndex:
+				array, _ := value.([]any)
+				index := step.index
+
+				if index < 0 {
+					index += len(array)
+				}
+
+				if index >= 0 && index < len(array) {
+					next = append(next, array[index])
+				} else {
+					next = append(next, nil)
+				}
+			case stepIterate:
+				switch value := value.(type) {
+				case []any:
+					next = append(next, value...)
+				case map[string]any:
+					keys := make([]string, 0, len(value))
+
+					for key := range value {
+						keys = append(keys, key)
+					}
+
+					sort.Strings(keys)
+
+					for _, key := range keys {
+						next = append(next, value[key])
+					}
+				}
+			}
+		}
+
+		values = next
+	}
+
+	return values
+}
+
+/** Return the first value the path leads to, or nil if there's none. */
+func (path Path) First(document any) any {
+	if values := path.Evaluate(document); len(values) > 0 {
+		return values[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+/** The Wayback Machine's "Save Page Now" endpoint. */
+const waybackSaveURL = "https://web.archive.org/save/"
+
+/** Saving a page can take the Wayback Machine a while. */
+var archiveClient = &http.Client{
+	Timeout: 2 * time.Minute,
+}
+
+/*
+  - Ask the Internet Archive's Wayback Machine to take a snapshot of
+    the given URL, returning the snapshot's own URL.
+*/
+func Archive(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", waybackSaveURL+url, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := archiveClient.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("Wayback Machine returned HTTP status %s", resp.Status)
+	}
+
+	// The snapshot's location is reported in a header, or else
+	// reached by following the redirects.
+	if location := resp.Header.Get("Content-Location"); location != "" {
+		return "https://web.archive.org" + location, nil
+	}
+
+	return resp.Request.URL.String(), nil
+}
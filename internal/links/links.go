@@ -0,0 +1,136 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/** The outcome of checking a single link. */
+type Status int
+
+const (
+	StatusAlive Status = iota
+
+	// The server says the page is gone for good (404 or 410).
+	StatusGone
+
+	// The page couldn't be fetched for some other reason, which may
+	// well be temporary.
+	StatusUnreachable
+)
+
+func (status Status) String() string {
+	switch status {
+	case StatusAlive:
+		return "alive"
+	case StatusGone:
+		return "gone"
+	case StatusUnreachable:
+		return "unreachable"
+	default:
+		return "unknown"
+	}
+}
+
+type Check struct {
+	URL    string
+	Status Status
+
+	// Why the link isn't alive, if it isn't.
+	Err error
+}
+
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+/*
+  - Probe the given URL. A HEAD request is tried first, since only the
+    status matters; servers which refuse HEAD get a GET instead.
+*/
+func CheckLink(ctx context.Context, url string) Check {
+	check := Check{
+		URL:    url,
+		Status: StatusUnreachable,
+	}
+
+	resp, err := request(ctx, "HEAD", url)
+
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = request(ctx, "GET", url)
+	}
+
+	if err != nil {
+		check.Err = err
+		return check
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		check.Status = StatusGone
+		check.Err = fmt.Errorf("HTTP status %s", resp.Status)
+	case resp.StatusCode >= 400:
+		check.Err = fmt.Errorf("HTTP status %s", resp.Status)
+	default:
+		check.Status = StatusAlive
+	}
+
+	return check
+}
+
+/*
+  - Check the given URLs concurrently, with at most 'workers' requests
+    in flight at once. The results are returned in the same order as
+    'urls'.
+
+    If 'onDone' is non-nil, it's called once per URL as soon as its
+    check completes (possibly from several goroutines at once.)
+*/
+func CheckLinks(ctx context.Context, urls []string, workers int, onDone func(Check)) []Check {
+	if workers < 1 {
+		workers = 1
+	}
+
+	checks := make([]Check, len(urls))
+	semaphore := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+
+	for i, url := range urls {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			checks[i] = CheckLink(ctx, url)
+
+			if onDone != nil {
+				onDone(checks[i])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+func request(ctx context.Context, method string, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	return client.Do(req)
+}
@@ -0,0 +1,387 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+  - A single schema migration, as found in a file named after its
+    version, such as "031_feed_changes.sql". The file is in goose's
+    format: its "-- +goose Up" section applies it, and its
+    "-- +goose Down" section undoes it.
+*/
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+/** A migration, along with when it was applied, if it has been. */
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+/*
+  - Serializes migrations across every gator process using the same
+    database, so that two starting at once don't both apply the same
+    migration. (The number itself is arbitrary.)
+*/
+const lockID = 7346812594
+
+/** Read the migrations in the given directory, in order of version. */
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string)
+
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+
+		if !ok {
+			return nil, fmt.Errorf("Migration %q isn't named VERSION_NAME.sql", name)
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Migration %q isn't named VERSION_NAME.sql", name)
+		}
+
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("Migrations %q and %q have the same version", other, name)
+		}
+
+		seen[version] = name
+
+		contents, err := fs.ReadFile(fsys, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := split(string(contents))
+
+		if err != nil {
+			return nil, fmt.Errorf("Migration %q: %v", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(name, ".sql"),
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+/** Split a migration into its Up and Down sections. */
+func split(contents string) (string, string, error) {
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &up
+			continue
+		case "-- +goose Down":
+			section = &down
+			continue
+		}
+
+		if section != nil {
+			section.WriteString(line)
+		}
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("No '-- +goose Up' section")
+	}
+
+	return up.String(), down.String(), nil
+}
+
+/*
+  - Report which of the given migrations have been applied to the
+    database, and when.
+*/
+func List(ctx context.Context, db *sql.DB, migrations []Migration) ([]Status, error) {
+	applied, err := appliedVersions(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+
+	for _, migration := range migrations {
+		status := Status{Migration: migration}
+
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+/** Return those of the given migrations which haven't been applied yet. */
+func Pending(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+/*
+  - Apply every migration not yet applied, in order, all in a single
+    transaction, so that a failing migration leaves the database as it
+    was. The migrations applied are returned.
+*/
+func Up(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	tx, err := begin(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// Now that we hold the lock, see what's left to do; another
+	// process may have done it in the meantime.
+	applied, err := appliedVersions(ctx, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, migration.Up); err != nil {
+			return nil, fmt.Errorf("Failed to apply migration %s: %v", migration.Name, err)
+		}
+
+		if _, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)", migration.Version, time.Now()); err != nil {
+			return nil, err
+		}
+
+		done = append(done, migration)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return done, nil
+}
+
+/*
+  - Undo the most recently applied of the given migrations, returning
+    it, or nil if none has been applied.
+*/
+func Down(ctx context.Context, db *sql.DB, migrations []Migration) (*Migration, error) {
+	tx, err := begin(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	applied, err := appliedVersions(ctx, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, migration.Down); err != nil {
+			return nil, fmt.Errorf("Failed to undo migration %s: %v", migration.Name, err)
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+			return nil, err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		return &migration, nil
+	}
+
+	return nil, nil
+}
+
+/*
+  - Start a transaction holding the migration lock, with the
+    'schema_migrations' table in place to record what's applied.
+*/
+func begin(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	exists, err := tableExists(ctx, tx, "schema_migrations")
+
+	if err == nil && !exists {
+		err = createTable(ctx, tx)
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+/*
+  - Create the 'schema_migrations' table. A database set up with goose
+    beforehand already has migrations applied, as recorded in goose's
+    own table, so those are carried over.
+*/
+func createTable(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE schema_migrations(
+       version BIGINT PRIMARY KEY,
+       applied_at TIMESTAMP NOT NULL
+)`); err != nil {
+		return err
+	}
+
+	fromGoose, err := tableExists(ctx, tx, "goose_db_version")
+
+	if err != nil || !fromGoose {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) "+gooseVersions)
+
+	return err
+}
+
+/*
+  - The versions goose has applied, and when. Goose logs each applying
+    and undoing of a migration, so the latest entry for each version
+    says whether it's in effect.
+*/
+const gooseVersions = `SELECT version_id, tstamp
+FROM (
+    SELECT DISTINCT ON (version_id) version_id, tstamp, is_applied
+    FROM goose_db_version
+    WHERE version_id > 0
+    ORDER BY version_id, id DESC
+) latest
+WHERE is_applied`
+
+/** Whatever migrations can be looked up through: the database, or a transaction. */
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func tableExists(ctx context.Context, db querier, table string) (bool, error) {
+	var exists bool
+
+	err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists)
+
+	return exists, err
+}
+
+/*
+  - Return the versions applied so far, and when. Before the
+    'schema_migrations' table exists, that's none, unless goose was
+    used.
+*/
+func appliedVersions(ctx context.Context, db querier) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+	table := "schema_migrations"
+	query := "SELECT version, applied_at FROM schema_migrations"
+
+	exists, err := tableExists(ctx, db, table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		table = "goose_db_version"
+		query = gooseVersions
+
+		if exists, err = tableExists(ctx, db, table); err != nil || !exists {
+			return applied, err
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
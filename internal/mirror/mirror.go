@@ -0,0 +1,211 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/** The largest image downloaded, in bytes. */
+const maxImageSize = 10 << 20
+
+/** An <img> tag, and the src and srcset attributes within one. */
+var (
+	imgPattern    = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	srcPattern    = regexp.MustCompile(`(?i)(\ssrc\s*=\s*)("[^"]*"|'[^']*'|[^\s>]+)`)
+	srcsetPattern = regexp.MustCompile(`(?i)\ssrcset\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+/*
+  - Keeps local copies of the images in posts' content, so that posts
+    can be read without network access. Each image is saved once
+    under 'Dir', named after a hash of its URL, however many posts
+    refer to it.
+*/
+type Mirror struct {
+	Dir    string
+	Client *http.Client
+}
+
+/*
+  - Download the images the given HTML refers to, returning the HTML
+    with each image pointing at its local copy instead, along with how
+    many images now do. Relative links are resolved against
+    'baseURL'. Images which can't be downloaded keep their original
+    links. Since alternative sizes given by srcset would still be
+    fetched from the network, srcset attributes are dropped from
+    mirrored images.
+*/
+func (mirror Mirror) Rewrite(ctx context.Context, baseURL string, content string) (string, int) {
+	base, _ := url.Parse(baseURL)
+	mirrored := 0
+
+	rewritten := imgPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		match := srcPattern.FindStringSubmatch(tag)
+
+		if match == nil {
+			return tag
+		}
+
+		src := strings.Trim(match[2], `"'`)
+		imageURL, err := url.Parse(src)
+
+		if err != nil {
+			return tag
+		}
+
+		if base != nil {
+			imageURL = base.ResolveReference(imageURL)
+		}
+
+		if imageURL.Scheme != "http" && imageURL.Scheme != "https" {
+			return tag
+		}
+
+		local, err := mirror.Save(ctx, imageURL.String())
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't mirror image %s: %v\n", imageURL, err)
+			return tag
+		}
+
+		mirrored++
+
+		localURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(local)}).String()
+		tag = srcsetPattern.ReplaceAllString(tag, "")
+
+		return srcPattern.ReplaceAllLiteralString(tag, match[1]+`"`+localURL+`"`)
+	})
+
+	return rewritten, mirrored
+}
+
+/*
+  - Download the image at the given URL into the mirror's directory,
+    unless it's there already, and return the path to the local copy.
+*/
+func (mirror Mirror) Save(ctx context.Context, imageURL string) (string, error) {
+	dir, err := filepath.Abs(mirror.Dir)
+
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(imageURL))
+	name := hex.EncodeToString(sum[:16])
+
+	// The extension depends on what the server says the image is, so
+	// look for a copy under any extension.
+	if existing, _ := filepath.Glob(filepath.Join(dir, name+".*")); len(existing) > 0 {
+		return existing[0], nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	client := mirror.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("Not an image (%q)", mediaType)
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first, so that an interrupted download
+	// isn't mistaken for a complete copy.
+	temp, err := os.CreateTemp(dir, name+"-*.partial")
+
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, io.LimitReader(resp.Body, maxImageSize+1))
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if written > maxImageSize {
+		return "", fmt.Errorf("Larger than %d bytes", maxImageSize)
+	}
+
+	local := filepath.Join(dir, name+extension(mediaType, imageURL))
+
+	if err = os.Rename(temp.Name(), local); err != nil {
+		return "", err
+	}
+
+	return local, nil
+}
+
+/** Extensions for common image types, where several would do. */
+var preferredExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"image/avif":    ".avif",
+}
+
+/** A file extension for an image, going by its URL or else its type. */
+func extension(mediaType string, imageURL string) string {
+	if parsed, err := url.Parse(imageURL); err == nil {
+		ext := strings.ToLower(path.Ext(parsed.Path))
+
+		if byExt, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext)); ext != "" && byExt == mediaType {
+			return ext
+		}
+	}
+
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+
+	if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+		return extensions[0]
+	}
+
+	return ".img"
+}
@@ -0,0 +1,144 @@
+package nitter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/** Where post links point, whichever instance they were fetched from. */
+const canonicalBase = "https://x.com/"
+
+/** What an account's handle may consist of. */
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+
+/*
+  - Follows X (formerly Twitter) accounts through Nitter, which offers
+    an RSS feed for each. Feed URLs take the form "nitter://HANDLE".
+    Nitter instances (given as base URLs, or just host names) come and
+    go, so several can be given: they're tried in turn until one
+    answers, and whichever last did is tried first next time.
+
+    Post links are rewritten to point at x.com, so that the same post
+    fetched through different instances is saved only once.
+*/
+type Fetcher struct {
+	Client    *http.Client
+	Instances []string
+
+	// The index into 'Instances' of the one which last answered.
+	mutex     sync.Mutex
+	preferred int
+}
+
+func (fetcher *Fetcher) Fetch(ctx context.Context, feedURL string) (*rss.Document, error) {
+	handle := strings.Trim(strings.TrimPrefix(feedURL, "nitter://"), "/@")
+
+	if !handlePattern.MatchString(handle) {
+		return nil, fmt.Errorf("%q isn't an account's handle", handle)
+	}
+
+	if len(fetcher.Instances) == 0 {
+		return nil, fmt.Errorf("No Nitter instances are configured (see 'nitter_instances')")
+	}
+
+	fetcher.mutex.Lock()
+	first := fetcher.preferred
+	fetcher.mutex.Unlock()
+
+	failures := make([]string, 0, len(fetcher.Instances))
+
+	for i := range fetcher.Instances {
+		index := (first + i) % len(fetcher.Instances)
+		instance := strings.TrimSuffix(fetcher.Instances[index], "/")
+
+		if !strings.Contains(instance, "://") {
+			instance = "https://" + instance
+		}
+
+		feed, err := fetcher.fetchFrom(ctx, instance, handle)
+
+		if err != nil {
+			// Running out of time isn't the instance's fault.
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			failures = append(failures, fmt.Sprintf("%s: %v", instance, err))
+			continue
+		}
+
+		fetcher.mutex.Lock()
+		fetcher.preferred = index
+		fetcher.mutex.Unlock()
+
+		return &rss.Document{
+			Body:        io.NopCloser(bytes.NewReader(canonicalizeLinks(feed, instance))),
+			FinalURL:    feedURL,
+			ContentType: "application/rss+xml",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Every Nitter instance failed (%s)", strings.Join(failures, "; "))
+}
+
+/** Fetch an account's feed from the given instance. */
+func (fetcher *Fetcher) fetchFrom(ctx context.Context, instance string, handle string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", instance+"/"+url.PathEscape(handle)+"/rss", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	client := fetcher.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	feed, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Instances which are rate limited, or have been shut down, tend to
+	// answer with an HTML page rather than an error status.
+	if !bytes.Contains(feed, []byte("<rss")) {
+		return nil, fmt.Errorf("Not an RSS feed")
+	}
+
+	return feed, nil
+}
+
+/*
+  - Point the feed's item links and GUIDs at x.com rather than at the
+    instance, dropping Nitter's "#m" suffix. Other links (such as to
+    images, which are proxied by the instance) are left alone.
+*/
+func canonicalizeLinks(feed []byte, instance string) []byte {
+	pattern := regexp.MustCompile(`(<(?:link|guid)\b[^>]*>)\s*` + regexp.QuoteMeta(instance) + `/([^<#]*)(?:#m)?`)
+
+	return pattern.ReplaceAll(feed, []byte("${1}"+canonicalBase+"${2}"))
+}
@@ -0,0 +1,99 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+/*
+  - Separators which commonly set off a site's name at the end of a
+    title, as in "Some Post | Example Blog".
+*/
+var suffixSeparators = []string{" | ", " - ", " – ", " — ", " · "}
+
+/** Collapse every run of whitespace (including newlines) to one space. */
+func Whitespace(text string) string {
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+/*
+  - Clean up a post title: collapse its whitespace, drop a trailing
+    "| Site Name" when it matches 'siteName', and convert it to title
+    case if it's written in all capitals.
+*/
+func Title(title string, siteName string) string {
+	title = Whitespace(title)
+	title = trimSiteName(title, siteName)
+
+	if isShouting(title) {
+		title = titleCase(title)
+	}
+
+	return title
+}
+
+/*
+  - Remove a site-name suffix from 'title'. Only suffixes matching the
+    site's name are removed, since dashes in particular also appear in
+    ordinary titles.
+*/
+func trimSiteName(title string, siteName string) string {
+	siteName = Whitespace(siteName)
+
+	if siteName == "" {
+		return title
+	}
+
+	for _, separator := range suffixSeparators {
+		index := strings.LastIndex(title, separator)
+
+		if index <= 0 {
+			continue
+		}
+
+		if strings.EqualFold(title[index+len(separator):], siteName) {
+			return strings.TrimSpace(title[:index])
+		}
+	}
+
+	return title
+}
+
+/** Whether 'text' has at least a few letters, all of them capitals. */
+func isShouting(text string) bool {
+	letters := 0
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		if unicode.IsLower(r) {
+			return false
+		}
+
+		letters++
+	}
+
+	return letters > 3
+}
+
+/** Capitalize the first letter of each word, lowercasing the rest. */
+func titleCase(text string) string {
+	words := strings.Split(strings.ToLower(text), " ")
+
+	for i, word := range words {
+		runes := []rune(word)
+
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,80 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+/** A struct for unmarshalling an OPML subscription list. */
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title string `xml:"title"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []Outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+/*
+  - A single OPML outline. Outlines can be nested (for example, as
+    folders), in which case only the leaves carry an 'xmlUrl'.
+*/
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr"`
+	Type     string    `xml:"type,attr"`
+	XMLURL   string    `xml:"xmlUrl,attr"`
+	HTMLURL  string    `xml:"htmlUrl,attr"`
+	Outlines []Outline `xml:"outline"`
+}
+
+/** Decode an OPML document from the given reader. */
+func Parse(r io.Reader) (*OPML, error) {
+	doc := &OPML{}
+
+	if err := xml.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+/** Return every outline in the document that points to a feed. */
+func (doc *OPML) Feeds() []Outline {
+	feeds := make([]Outline, 0)
+
+	var walk func([]Outline)
+
+	walk = func(outlines []Outline) {
+		for _, outline := range outlines {
+			if outline.XMLURL != "" {
+				feeds = append(feeds, outline)
+			}
+
+			walk(outline.Outlines)
+		}
+	}
+
+	walk(doc.Body.Outlines)
+
+	return feeds
+}
+
+/*
+  - The display name of an outline. OPML producers disagree on whether
+    'title' or 'text' is authoritative, so fall back through both, and
+    finally to the feed URL itself.
+*/
+func (outline Outline) Name() string {
+	if outline.Title != "" {
+		return outline.Title
+	}
+
+	if outline.Text != "" {
+		return outline.Text
+	}
+
+	return outline.XMLURL
+}
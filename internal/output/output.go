@@ -0,0 +1,64 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+/** Write 'rows' as a plain-text table with aligned columns. */
+func Table(w io.Writer, headers []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+/*
+  - Write 'v' as JSON if 'asJSON' is set, and otherwise call 'text' to
+    write it in human-readable form. Commands report their results
+    through this, so that the global '--json' flag applies to all of
+    them alike.
+*/
+func Print(w io.Writer, asJSON bool, v any, text func(io.Writer) error) error {
+	if asJSON {
+		return JSON(w, v)
+	}
+
+	return text(w)
+}
+
+/*
+  - Like 'Print', for commands whose result is a one-line message (such
+    as "Feed added") rather than a listing: 'v' is written as JSON if
+    'asJSON' is set, and otherwise the message.
+*/
+func Message(w io.Writer, asJSON bool, v any, format string, args ...any) error {
+	return Print(w, asJSON, v, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, format+"\n", args...)
+		return err
+	})
+}
+
+/*
+  - A 'text' function for 'Print' which writes nothing, for commands
+    which only report their result when asked for JSON.
+*/
+func Nothing(io.Writer) error {
+	return nil
+}
+
+/** Write 'v' as an indented JSON document. */
+func JSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
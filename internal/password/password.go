@@ -0,0 +1,25 @@
+package password
+
+import "golang.org/x/crypto/bcrypt"
+
+/*
+  - How expensive each hash is to make (and so to guess); bcrypt's
+    cost is the base-2 logarithm of its number of rounds.
+*/
+const Cost = 12
+
+/** Hash the given password with bcrypt, which salts it and records its cost. */
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), Cost)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+/** Whether the given password is the one 'hash' was made from. */
+func Verify(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,68 @@
+package paywall
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+  - Phrases publishers put in place of (or after a teaser of) content
+    kept for subscribers. They're matched case-insensitively, with
+    whitespace collapsed.
+*/
+var markers = []string{
+	"subscribe to continue reading",
+	"subscribe to read",
+	"subscribe to keep reading",
+	"subscribe now to read",
+	"subscribers only",
+	"subscriber-only",
+	"for subscribers only",
+	"this article is for subscribers",
+	"this article is for paid subscribers",
+	"this post is for paid subscribers",
+	"this post is for paying subscribers",
+	"available to paid subscribers",
+	"become a paid subscriber to",
+	"upgrade to paid to read",
+	"to continue reading, subscribe",
+	"sign in to continue reading",
+	"log in to continue reading",
+	"register to continue reading",
+	"you've reached your free article limit",
+	"you have reached your free article limit",
+	"members-only",
+	"members only content",
+	"unlock this article",
+	"read the full story with a subscription",
+}
+
+/*
+  - Titles some publications mark subscriber-only posts with, such as
+    LWN's "[$]".
+*/
+var titlePattern = regexp.MustCompile(`^\s*(\[\$\]|\[paywall\]|\(paywall\)|🔒)`)
+
+/** Whether a post's title or content suggest it's behind a paywall. */
+func Detect(title string, content string) bool {
+	if titlePattern.MatchString(strings.ToLower(title)) {
+		return true
+	}
+
+	text := strings.ToLower(strings.Join(strings.Fields(stripTags(content)), " "))
+
+	for _, marker := range markers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+/** Remove HTML tags, so that markers split up by markup are still found. */
+func stripTags(content string) string {
+	return tagPattern.ReplaceAllString(content, " ")
+}
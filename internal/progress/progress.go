@@ -0,0 +1,127 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	barWidth = 30
+
+	// How often a progress line is printed when the output isn't a
+	// terminal.
+	logInterval = 2 * time.Second
+)
+
+/*
+  - A progress indicator for long-running operations. When writing to
+    a terminal, it renders as a bar redrawn in place; otherwise (for
+    example, when output is piped into a log file) it falls back to
+    printing a plain line every few seconds.
+
+    It's safe to call 'Increment' from multiple goroutines.
+*/
+type Bar struct {
+	label string
+	total int
+	done  int
+	out   io.Writer
+	mu    sync.Mutex
+
+	// Whether to draw an in-place bar, as opposed to log lines.
+	interactive bool
+
+	// When the last log line was printed (non-interactive mode only.)
+	lastLogged time.Time
+}
+
+/** Create a new progress indicator writing to 'out'. */
+func New(out io.Writer, label string, total int) *Bar {
+	bar := &Bar{
+		label:       label,
+		total:       total,
+		out:         out,
+		interactive: isTerminal(out),
+		lastLogged:  time.Now(),
+	}
+
+	if bar.interactive {
+		bar.render()
+	}
+
+	return bar
+}
+
+/** Mark one more unit of work as complete. */
+func (bar *Bar) Increment() {
+	bar.mu.Lock()
+	defer bar.mu.Unlock()
+
+	if bar.done < bar.total {
+		bar.done++
+	}
+
+	if bar.interactive {
+		bar.render()
+	} else if time.Since(bar.lastLogged) >= logInterval {
+		bar.log()
+	}
+}
+
+/** Terminate the progress indicator. */
+func (bar *Bar) Finish() {
+	bar.mu.Lock()
+	defer bar.mu.Unlock()
+
+	if bar.interactive {
+		fmt.Fprintln(bar.out)
+	} else {
+		bar.log()
+	}
+}
+
+// Callers must hold 'bar.mu'.
+func (bar *Bar) render() {
+	filled := barWidth
+
+	if bar.total > 0 {
+		filled = bar.done * barWidth / bar.total
+	}
+
+	meter := strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled)
+
+	fmt.Fprintf(bar.out, "\r%s [%s] %d/%d", bar.label, meter, bar.done, bar.total)
+}
+
+// Callers must hold 'bar.mu'.
+func (bar *Bar) log() {
+	percent := 100
+
+	if bar.total > 0 {
+		percent = bar.done * 100 / bar.total
+	}
+
+	fmt.Fprintf(bar.out, "%s: %d/%d (%d%%)\n", bar.label, bar.done, bar.total, percent)
+	bar.lastLogged = time.Now()
+}
+
+/** Report whether 'out' is an interactive terminal. */
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
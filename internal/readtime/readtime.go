@@ -0,0 +1,29 @@
+package readtime
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/** A typical adult's silent reading speed, for non-fiction. */
+const WordsPerMinute = 238
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+/*
+  - Estimate how long the given post content (HTML, or plain text)
+    takes to read, going by its words, rounded up to the second.
+*/
+func Estimate(content string) time.Duration {
+	words := len(strings.Fields(html.UnescapeString(tagPattern.ReplaceAllString(content, " "))))
+	seconds := (words*60 + WordsPerMinute - 1) / WordsPerMinute
+
+	return time.Duration(seconds) * time.Second
+}
+
+/** The reading time in whole minutes, rounded up, and at least one. */
+func Minutes(d time.Duration) int {
+	return max(1, int((d+time.Minute-1)/time.Minute))
+}
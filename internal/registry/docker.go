@@ -0,0 +1,263 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+/** How many pages of tags are read from a registry, at most. */
+const maxTagPages = 20
+
+/*
+  - Turns a container image's tags into a feed, with a post for each
+    tag. Feed URLs take the form "docker://IMAGE", where IMAGE is
+    written as for 'docker pull' (such as "nginx",
+    "grafana/grafana", or "ghcr.io/OWNER/IMAGE"), less any tag.
+
+    Docker Hub reports when each tag was last pushed; other registries
+    (which are asked through the standard registry API) don't, so
+    their tags count as published when first seen.
+*/
+type ImageFetcher struct {
+	Client *http.Client
+}
+
+func (fetcher ImageFetcher) Fetch(ctx context.Context, feedURL string) (*rss.Document, error) {
+	image := strings.Trim(strings.TrimPrefix(feedURL, "docker://"), "/")
+	host, name := splitImage(image)
+
+	if name == "" {
+		return nil, fmt.Errorf("No image named in %q", feedURL)
+	}
+
+	var rssItems []rss.RSSItem
+	var err error
+
+	if host == "docker.io" {
+		rssItems, err = fetcher.dockerHubTags(ctx, image, name)
+	} else {
+		rssItems, err = fetcher.registryTags(ctx, image, host, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rss.NewDocument(image, feedURL, rssItems)
+}
+
+/*
+  - Split an image reference into its registry's host and the image's
+    name there. Official Docker Hub images live under "library/".
+*/
+func splitImage(image string) (string, string) {
+	host := "docker.io"
+	name := image
+
+	// As with 'docker pull', a first component that looks like a host
+	// name is one.
+	if first, rest, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host = first
+		name = rest
+	}
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "hub.docker.com":
+		host = "docker.io"
+	}
+
+	if host == "docker.io" && name != "" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	return host, name
+}
+
+/** The parts of Docker Hub's tag listing we use. */
+type dockerHubTagList struct {
+	Results []struct {
+		Name        string `json:"name"`
+		LastUpdated string `json:"last_updated"`
+		Digest      string `json:"digest"`
+	} `json:"results"`
+}
+
+/** List an image's tags on Docker Hub, most recently pushed first. */
+func (fetcher ImageFetcher) dockerHubTags(ctx context.Context, image string, name string) ([]rss.RSSItem, error) {
+	var list dockerHubTagList
+
+	listURL := fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/tags?page_size=100&ordering=last_updated", name)
+
+	if _, err := getJSON(ctx, fetcher.Client, listURL, nil, &list); err != nil {
+		return nil, err
+	}
+
+	// Official images have pages of their own.
+	page := "https://hub.docker.com/r/" + name
+
+	if repo, ok := strings.CutPrefix(name, "library/"); ok {
+		page = "https://hub.docker.com/_/" + repo
+	}
+
+	seen := time.Now().UTC().Format(time.RFC3339)
+	rssItems := make([]rss.RSSItem, 0, len(list.Results))
+
+	for _, tag := range list.Results {
+		rssItem := rss.RSSItem{
+			Title:   image + ":" + tag.Name,
+			Link:    page + "/tags?name=" + url.QueryEscape(tag.Name),
+			PubDate: tag.LastUpdated,
+		}
+
+		if rssItem.PubDate == "" {
+			rssItem.PubDate = seen
+		}
+
+		if tag.Digest != "" {
+			rssItem.Description = "Digest: " + tag.Digest
+		}
+
+		rssItems = append(rssItems, rssItem)
+	}
+
+	return rssItems, nil
+}
+
+/** A page of tags, as listed by the standard registry API. */
+type registryTagList struct {
+	Tags []string `json:"tags"`
+}
+
+/*
+  - List an image's tags through the standard (OCI distribution)
+    registry API, authenticating anonymously if the registry asks.
+    Tags are listed in lexical order, so they're reversed, which tends
+    to put the latest versions first.
+*/
+func (fetcher ImageFetcher) registryTags(ctx context.Context, image string, host string, name string) ([]rss.RSSItem, error) {
+	base := &url.URL{Scheme: "https", Host: host}
+	next := base.JoinPath("v2", name, "tags", "list").String()
+	headers := make(map[string]string)
+	tags := make([]string, 0)
+
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		var list registryTagList
+
+		resp, err := getJSON(ctx, fetcher.Client, next, headers, &list)
+
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized && headers["Authorization"] == "" {
+			var token string
+
+			token, err = fetcher.anonymousToken(ctx, resp.Header.Get("WWW-Authenticate"))
+
+			if err != nil {
+				return nil, err
+			}
+
+			headers["Authorization"] = "Bearer " + token
+			resp, err = getJSON(ctx, fetcher.Client, next, headers, &list)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, list.Tags...)
+		next = nextPage(base, resp.Header.Get("Link"))
+	}
+
+	slices.Reverse(tags)
+
+	seen := time.Now().UTC().Format(time.RFC3339)
+	rssItems := make([]rss.RSSItem, 0, len(tags))
+
+	for _, tag := range tags {
+		// Signatures and attestations are stored as tags too.
+		if strings.HasPrefix(tag, "sha256-") {
+			continue
+		}
+
+		rssItems = append(rssItems, rss.RSSItem{
+			Title:   image + ":" + tag,
+			Link:    fmt.Sprintf("https://%s/%s:%s", host, name, tag),
+			PubDate: seen,
+		})
+	}
+
+	return rssItems, nil
+}
+
+/** The parameters of a WWW-Authenticate challenge. */
+var challengePattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+/*
+  - Obtain an anonymous pull token, as described by the registry's
+    "Bearer" challenge, for registries (such as GHCR) which require one
+    even for public images.
+*/
+func (fetcher ImageFetcher) anonymousToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("The registry requires authentication (%q)", challenge)
+	}
+
+	params := make(map[string]string)
+
+	for _, match := range challengePattern.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+
+	realm, err := url.Parse(params["realm"])
+
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("Malformed registry challenge %q", challenge)
+	}
+
+	query := realm.Query()
+
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	realm.RawQuery = query.Encode()
+
+	var response struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if _, err = getJSON(ctx, fetcher.Client, realm.String(), nil, &response); err != nil {
+		return "", err
+	}
+
+	if response.Token == "" {
+		return response.AccessToken, nil
+	}
+
+	return response.Token, nil
+}
+
+/** Return the URL of the next page of a listing, per its Link header, if any. */
+func nextPage(base *url.URL, link string) string {
+	target, params, ok := strings.Cut(link, ";")
+
+	if !ok || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+
+	next, err := base.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+
+	if err != nil {
+		return ""
+	}
+
+	return next.String()
+}
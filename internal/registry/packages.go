@@ -0,0 +1,335 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/BrandonIrizarry/gator/internal/rss"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** The most releases of a package presented, newest first. */
+const maxReleases = 100
+
+/*
+  - Go modules' versions are undated until each is looked up, so only
+    this many of the highest are.
+*/
+const maxGoVersions = 20
+
+/** A release of a package. */
+type release struct {
+	version   string
+	published time.Time
+}
+
+/** A package registry: how to list a package's releases, and where each is described. */
+type ecosystem struct {
+	releases func(ctx context.Context, client *http.Client, name string) ([]release, error)
+	page     func(name string, version string) string
+}
+
+/** The registries understood, by the scheme of their feed URLs. */
+var ecosystems = map[string]ecosystem{
+	"npm": {
+		releases: npmReleases,
+		page: func(name string, version string) string {
+			return fmt.Sprintf("https://www.npmjs.com/package/%s/v/%s", name, version)
+		},
+	},
+	"pypi": {
+		releases: pypiReleases,
+		page: func(name string, version string) string {
+			return fmt.Sprintf("https://pypi.org/project/%s/%s/", name, version)
+		},
+	},
+	"crates": {
+		releases: cratesReleases,
+		page: func(name string, version string) string {
+			return fmt.Sprintf("https://crates.io/crates/%s/%s", name, version)
+		},
+	},
+	"go": {
+		releases: goReleases,
+		page: func(name string, version string) string {
+			return fmt.Sprintf("https://pkg.go.dev/%s@%s", name, version)
+		},
+	},
+}
+
+/** The feed URL schemes of the package registries understood. */
+func PackageSchemes() []string {
+	schemes := make([]string, 0, len(ecosystems))
+
+	for scheme := range ecosystems {
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+/*
+  - Turns a package's releases into a feed, with a post for each
+    release. Feed URLs take the form "ECOSYSTEM://NAME", as in
+    "npm://react", "pypi://requests", "crates://serde", or
+    "go://golang.org/x/net".
+*/
+type PackageFetcher struct {
+	Client *http.Client
+}
+
+func (fetcher PackageFetcher) Fetch(ctx context.Context, feedURL string) (*rss.Document, error) {
+	scheme, name, _ := strings.Cut(feedURL, "://")
+	name = strings.Trim(name, "/")
+	source, ok := ecosystems[scheme]
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown package registry %q", scheme)
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("No package named in %q", feedURL)
+	}
+
+	releases, err := source.releases(ctx, fetcher.Client, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].published.After(releases[j].published)
+	})
+
+	if len(releases) > maxReleases {
+		releases = releases[:maxReleases]
+	}
+
+	rssItems := make([]rss.RSSItem, 0, len(releases))
+
+	for _, release := range releases {
+		rssItems = append(rssItems, rss.RSSItem{
+			Title:   name + " " + release.version,
+			Link:    source.page(name, release.version),
+			PubDate: release.published.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return rss.NewDocument(scheme+":"+name, feedURL, rssItems)
+}
+
+/*
+  - List an npm package's releases. Scoped packages ("@scope/name")
+    keep their slash escaped in the registry's URLs.
+*/
+func npmReleases(ctx context.Context, client *http.Client, name string) ([]release, error) {
+	var document struct {
+		Time map[string]string `json:"time"`
+	}
+
+	documentURL := "https://registry.npmjs.org/" + strings.ReplaceAll(name, "/", "%2F")
+
+	if _, err := getJSON(ctx, client, documentURL, nil, &document); err != nil {
+		return nil, err
+	}
+
+	releases := make([]release, 0, len(document.Time))
+
+	for version, published := range document.Time {
+		// Alongside the versions are when the package was created and
+		// last modified.
+		if version == "created" || version == "modified" {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339, published); err == nil {
+			releases = append(releases, release{version: version, published: t})
+		}
+	}
+
+	return releases, nil
+}
+
+/** List a PyPI project's releases, each dated by its first upload. */
+func pypiReleases(ctx context.Context, client *http.Client, name string) ([]release, error) {
+	var document struct {
+		Releases map[string][]struct {
+			UploadTime string `json:"upload_time_iso_8601"`
+		} `json:"releases"`
+	}
+
+	if _, err := getJSON(ctx, client, fmt.Sprintf("https://pypi.org/pypi/%s/json", url.PathEscape(name)), nil, &document); err != nil {
+		return nil, err
+	}
+
+	releases := make([]release, 0, len(document.Releases))
+
+	for version, files := range document.Releases {
+		var first time.Time
+
+		for _, file := range files {
+			t, err := time.Parse(time.RFC3339, file.UploadTime)
+
+			if err == nil && (first.IsZero() || t.Before(first)) {
+				first = t
+			}
+		}
+
+		// A release without files was never really published.
+		if !first.IsZero() {
+			releases = append(releases, release{version: version, published: first})
+		}
+	}
+
+	return releases, nil
+}
+
+/** List a crate's releases, less any yanked ones. */
+func cratesReleases(ctx context.Context, client *http.Client, name string) ([]release, error) {
+	var document struct {
+		Versions []struct {
+			Num       string `json:"num"`
+			CreatedAt string `json:"created_at"`
+			Yanked    bool   `json:"yanked"`
+		} `json:"versions"`
+	}
+
+	if _, err := getJSON(ctx, client, fmt.Sprintf("https://crates.io/api/v1/crates/%s/versions", url.PathEscape(name)), nil, &document); err != nil {
+		return nil, err
+	}
+
+	releases := make([]release, 0, len(document.Versions))
+
+	for _, version := range document.Versions {
+		if version.Yanked {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339, version.CreatedAt); err == nil {
+			releases = append(releases, release{version: version.Num, published: t})
+		}
+	}
+
+	return releases, nil
+}
+
+/*
+  - List a Go module's releases through the module proxy. The proxy
+    lists versions without dates, so only the highest few are looked
+    up individually.
+*/
+func goReleases(ctx context.Context, client *http.Client, name string) ([]release, error) {
+	base := "https://proxy.golang.org/" + escapeModulePath(name) + "/@v/"
+	resp, err := get(ctx, client, base+"list", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	versions := make([]string, 0)
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		if version := strings.TrimSpace(scanner.Text()); version != "" {
+			versions = append(versions, version)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return compareSemver(versions[i], versions[j]) > 0
+	})
+
+	if len(versions) > maxGoVersions {
+		versions = versions[:maxGoVersions]
+	}
+
+	releases := make([]release, 0, len(versions))
+
+	for _, version := range versions {
+		var info struct {
+			Time time.Time `json:"Time"`
+		}
+
+		if _, err = getJSON(ctx, client, base+version+".info", nil, &info); err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, release{version: version, published: info.Time})
+	}
+
+	return releases, nil
+}
+
+/*
+  - Escape a module path as the module proxy expects, with each capital
+    letter written as "!" and its lowercase form.
+*/
+func escapeModulePath(path string) string {
+	var builder strings.Builder
+
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			builder.WriteByte('!')
+			r += 'a' - 'A'
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+/*
+  - Compare two semantic versions ("v1.2.3", optionally with a
+    "-prerelease" suffix), returning a positive number if 'a' is the
+    higher. Build metadata is ignored, and prerelease identifiers are
+    compared as plain strings, which is plenty for ordering releases.
+*/
+func compareSemver(a string, b string) int {
+	coreA, preA, _ := strings.Cut(strings.SplitN(strings.TrimPrefix(a, "v"), "+", 2)[0], "-")
+	coreB, preB, _ := strings.Cut(strings.SplitN(strings.TrimPrefix(b, "v"), "+", 2)[0], "-")
+
+	partsA := strings.Split(coreA, ".")
+	partsB := strings.Split(coreB, ".")
+
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var numA, numB int
+
+		if i < len(partsA) {
+			numA, _ = strconv.Atoi(partsA[i])
+		}
+
+		if i < len(partsB) {
+			numB, _ = strconv.Atoi(partsB[i])
+		}
+
+		if numA != numB {
+			return numA - numB
+		}
+	}
+
+	// A prerelease comes before the release itself.
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	default:
+		return strings.Compare(preA, preB)
+	}
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+/** The client used when a fetcher isn't given one. */
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func clientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return defaultClient
+	}
+
+	return client
+}
+
+/*
+  - GET the given URL, with any headers given added to the request.
+    Error statuses count as failures; the response is returned even
+    then (with its body closed), for its status and headers.
+*/
+func get(ctx context.Context, client *http.Client, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := clientOrDefault(client).Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return resp, fmt.Errorf("HTTP status %s from %q", resp.Status, url)
+	}
+
+	return resp, nil
+}
+
+/** Like 'get', but decode the response's JSON into 'v'. */
+func getJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v any) (*http.Response, error) {
+	withAccept := map[string]string{"Accept": "application/json"}
+
+	for name, value := range headers {
+		withAccept[name] = value
+	}
+
+	resp, err := get(ctx, client, url, withAccept)
+
+	if err != nil {
+		return resp, err
+	}
+
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 64<<20)).Decode(v); err != nil {
+		return resp, fmt.Errorf("Malformed response from %q: %w", url, err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,161 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/** An Atom (RFC 4287) document, as published by many blogs instead of RSS. */
+type atomFeed struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+/*
+  - Return the link pointing at the resource itself (rel="alternate",
+    which is also what a missing 'rel' means), falling back to the
+    first link of any kind.
+*/
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+
+	if len(links) > 0 {
+		return links[0].Href
+	}
+
+	return ""
+}
+
+/** Convert an Atom entry into the item representation shared with RSS. */
+func (entry atomEntry) toItem() RSSItem {
+	rssItem := RSSItem{
+		Title:       strings.TrimSpace(entry.Title),
+		Link:        alternateLink(entry.Links),
+		Description: entry.Summary,
+		PubDate:     entry.Published,
+		GUID:        strings.TrimSpace(entry.ID),
+	}
+
+	if rssItem.Description == "" {
+		rssItem.Description = entry.Content
+	}
+
+	if rssItem.PubDate == "" {
+		rssItem.PubDate = entry.Updated
+	}
+
+	names := make([]string, 0, len(entry.Authors))
+
+	for _, author := range entry.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	rssItem.Creator = strings.Join(names, ", ")
+
+	for _, category := range entry.Categories {
+		if term := strings.TrimSpace(category.Term); term != "" {
+			rssItem.Categories = append(rssItem.Categories, term)
+		}
+	}
+
+	return rssItem
+}
+
+/** Convert an Atom document into the representation shared with RSS. */
+func (feed atomFeed) toRSS() *RSSFeed {
+	rssFeed := &RSSFeed{}
+	rssFeed.Channel.Title = feed.Title
+	rssFeed.Channel.Link = alternateLink(feed.Links)
+	rssFeed.Channel.Description = feed.Subtitle
+	rssFeed.Channel.Item = make([]RSSItem, 0, len(feed.Entries))
+
+	for _, entry := range feed.Entries {
+		rssFeed.Channel.Item = append(rssFeed.Channel.Item, entry.toItem())
+	}
+
+	return rssFeed
+}
+
+/*
+  - Parse the given document as either Atom or RSS, telling them apart
+    by their root element. Anything other than an Atom <feed> is taken
+    to be RSS.
+*/
+func parseFeed(xmlBytes []byte) (*RSSFeed, error) {
+	root, err := rootElement(xmlBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if root == "feed" {
+		feed := atomFeed{}
+
+		if err = xml.Unmarshal(xmlBytes, &feed); err != nil {
+			return nil, err
+		}
+
+		return feed.toRSS(), nil
+	}
+
+	rssFeed := &RSSFeed{}
+
+	if err = xml.Unmarshal(xmlBytes, rssFeed); err != nil {
+		return nil, err
+	}
+
+	return rssFeed, nil
+}
+
+/** Return the local name of the document's root element. */
+func rootElement(xmlBytes []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlBytes))
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			return "", fmt.Errorf("Empty feed document")
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/** Returned, without fetching anything, for hosts whose circuit is open. */
+var ErrCircuitOpen = errors.New("circuit open")
+
+/*
+  - A circuit breaker per host. Once fetches from a host have failed
+    'Threshold' times in a row, its circuit opens for 'Cooldown', and
+    fetches from it fail straight away, rather than each waiting to
+    time out while the host is down. After the cooldown, one fetch is
+    let through to try the host again: if it succeeds, the circuit
+    closes, and if not, it opens for another cooldown.
+
+    A zero 'Threshold' disables the breaker.
+*/
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mutex sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+/** What the breaker knows of a single host. */
+type hostCircuit struct {
+	failures  int
+	openUntil time.Time
+
+	// Whether a fetch is trying the host again, after a cooldown.
+	probing bool
+}
+
+/** The breaker used by 'DefaultFetcher'. */
+var DefaultBreaker = &Breaker{
+	Threshold: 5,
+	Cooldown:  10 * time.Minute,
+}
+
+/*
+  - Ask whether the given URL's host may be fetched from now, returning
+    an error wrapping ErrCircuitOpen if not.
+*/
+func (breaker *Breaker) Allow(feedURL string) error {
+	host := hostOf(feedURL)
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.Threshold <= 0 || breaker.hosts[host] == nil {
+		return nil
+	}
+
+	circuit := breaker.hosts[host]
+
+	if circuit.failures < breaker.Threshold {
+		return nil
+	}
+
+	if circuit.probing || time.Now().Before(circuit.openUntil) {
+		return fmt.Errorf("Host %s failed %d times in a row; not trying it again until %s (%w)",
+			host, circuit.failures, circuit.openUntil.Format(time.TimeOnly), ErrCircuitOpen)
+	}
+
+	circuit.probing = true
+
+	return nil
+}
+
+/*
+  - Record how fetching the given URL went: 'failed' says whether its
+    host seems to be down (as opposed to the feed merely being
+    missing, say.)
+*/
+func (breaker *Breaker) Record(feedURL string, failed bool) {
+	host := hostOf(feedURL)
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.Threshold <= 0 {
+		return
+	}
+
+	if !failed {
+		delete(breaker.hosts, host)
+		return
+	}
+
+	if breaker.hosts == nil {
+		breaker.hosts = make(map[string]*hostCircuit)
+	}
+
+	circuit := breaker.hosts[host]
+
+	if circuit == nil {
+		circuit = &hostCircuit{}
+		breaker.hosts[host] = circuit
+	}
+
+	circuit.failures++
+	circuit.probing = false
+
+	if circuit.failures >= breaker.Threshold {
+		circuit.openUntil = time.Now().Add(breaker.Cooldown)
+	}
+}
+
+/*
+  - Whether a fetch failing with the given error (or status code, if it
+    got as far as a response) suggests that its host is down. Being
+    interrupted doesn't, but timing out does.
+*/
+func hostDown(ctx context.Context, err error, status int) bool {
+	if err != nil {
+		return !errors.Is(ctx.Err(), context.Canceled)
+	}
+
+	return status >= 500 || status == 429
+}
+
+/** The host a URL is fetched from, for telling circuits apart. */
+func hostOf(feedURL string) string {
+	parsed, err := url.Parse(feedURL)
+
+	if err != nil {
+		return feedURL
+	}
+
+	return strings.ToLower(parsed.Host)
+}
@@ -0,0 +1,146 @@
+package rss
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+/** A response on the way to a document: a redirect, or the final one. */
+type Hop struct {
+	URL    string
+	Status int
+}
+
+/** Everything learned about a feed URL while fetching it, for diagnosing it. */
+type Inspection struct {
+	URL string
+
+	// The responses received, in order, for URLs fetched over HTTP:
+	// each redirect, then the final response.
+	Hops []Hop
+
+	ContentType string
+
+	// The kind of document: "rss", "atom" or "rdf" (RSS 1.0.)
+	Format string
+
+	// The feed, if it parsed.
+	Feed *RSSFeed
+
+	// Why the feed couldn't be fetched or parsed, if it couldn't.
+	Err error
+}
+
+/*
+  - Fetch the given feed as 'agg' would, except that a host's circuit
+    breaker is ignored, and report on what came back at each step.
+    The inspection ends at the first step to fail, which is recorded
+    in its 'Err'.
+*/
+func Inspect(ctx context.Context, feedURL string) Inspection {
+	inspection := Inspection{URL: feedURL}
+
+	var document *Document
+	var err error
+
+	if fetcher, ok := FetcherFor(feedURL).(HTTPFetcher); ok {
+		document, err = inspectHTTP(ctx, *fetcher.Client, &inspection)
+	} else {
+		document, err = fetch(ctx, feedURL)
+	}
+
+	if err != nil {
+		inspection.Err = err
+		return inspection
+	}
+
+	defer document.Body.Close()
+
+	inspection.ContentType = document.ContentType
+	body := bufio.NewReader(document.Body)
+
+	if err = checkFeed(document, body); err != nil {
+		inspection.Err = err
+		return inspection
+	}
+
+	xmlBytes, err := io.ReadAll(body)
+
+	if err != nil {
+		inspection.Err = err
+		return inspection
+	}
+
+	root, err := rootElement(xmlBytes)
+
+	if err != nil {
+		inspection.Err = fmt.Errorf("Not a valid RSS or Atom document: %w", err)
+		return inspection
+	}
+
+	inspection.Format = map[string]string{"feed": "atom", "RDF": "rdf"}[root]
+
+	if inspection.Format == "" {
+		inspection.Format = "rss"
+	}
+
+	feed, err := parseFeed(xmlBytes)
+
+	if err != nil {
+		inspection.Err = fmt.Errorf("Not a valid RSS or Atom document: %w", err)
+		return inspection
+	}
+
+	inspection.Feed = feed
+
+	return inspection
+}
+
+/** Fetch a feed over HTTP, recording each redirect along the way. */
+func inspectHTTP(ctx context.Context, client http.Client, inspection *Inspection) (*Document, error) {
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		inspection.Hops = append(inspection.Hops, Hop{
+			URL:    req.Response.Request.URL.String(),
+			Status: req.Response.StatusCode,
+		})
+
+		if len(via) >= 10 {
+			return fmt.Errorf("Stopped after 10 redirects")
+		}
+
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", inspection.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	inspection.Hops = append(inspection.Hops, Hop{
+		URL:    resp.Request.URL.String(),
+		Status: resp.StatusCode,
+	})
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	return &Document{
+		Body:        resp.Body,
+		FinalURL:    resp.Request.URL.String(),
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
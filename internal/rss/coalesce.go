@@ -0,0 +1,171 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+/*
+  - Which feed URLs turn out to be the same document: say, the http and
+    https URLs of a feed, or a FeedBurner URL and the native one, not
+    yet merged with 'mergefeeds'. This is learned from where each
+    fetch ends up, following redirects, so a URL's first fetch always
+    goes ahead on its own.
+*/
+type Aliases struct {
+	mutex sync.Mutex
+
+	// Where each URL was last found.
+	finalURLs map[string]string
+
+	// The URLs found at each final URL.
+	found map[string]map[string]bool
+}
+
+func NewAliases() *Aliases {
+	return &Aliases{
+		finalURLs: make(map[string]string),
+		found:     make(map[string]map[string]bool),
+	}
+}
+
+/** Record that fetching 'feedURL' ended up at 'finalURL'. */
+func (aliases *Aliases) learn(feedURL string, finalURL string) {
+	aliases.mutex.Lock()
+	defer aliases.mutex.Unlock()
+
+	if previous, ok := aliases.finalURLs[feedURL]; ok {
+		delete(aliases.found[previous], feedURL)
+	}
+
+	if aliases.found[finalURL] == nil {
+		aliases.found[finalURL] = make(map[string]bool)
+	}
+
+	aliases.finalURLs[feedURL] = finalURL
+	aliases.found[finalURL][feedURL] = true
+}
+
+/** Where 'feedURL' was last found, if some other URL was found there too. */
+func (aliases *Aliases) shared(feedURL string) (string, bool) {
+	aliases.mutex.Lock()
+	defer aliases.mutex.Unlock()
+
+	finalURL, ok := aliases.finalURLs[feedURL]
+
+	return finalURL, ok && len(aliases.found[finalURL]) > 1
+}
+
+/*
+  - A round of fetches sharing downloads: the first fetch of a document
+    known to have aliases downloads it, and the rest (whether made at
+    the same time or later in the round) get a copy. Such documents are
+    held in memory for the rest of the round, rather than streamed.
+*/
+type fetchRound struct {
+	aliases *Aliases
+	mutex   sync.Mutex
+	flights map[flightKey]*flight
+}
+
+/*
+  - What a download is shared by: its final URL, and the validators it
+    was asked for with, since a feed whose copy is out of date mustn't
+    be told that nothing has changed.
+*/
+type flightKey struct {
+	finalURL   string
+	validators Validators
+}
+
+/** A shared download, available to all once 'done' is closed. */
+type flight struct {
+	done     chan struct{}
+	document Document
+	body     []byte
+	err      error
+}
+
+type fetchRoundKey struct{}
+
+/*
+  - Share the downloads of the fetches made under the returned context
+    between the URLs 'aliases' knows lead to the same document, so that
+    none is downloaded twice. Each call starts a new round.
+*/
+func CoalesceFetches(ctx context.Context, aliases *Aliases) context.Context {
+	return context.WithValue(ctx, fetchRoundKey{}, &fetchRound{
+		aliases: aliases,
+		flights: make(map[flightKey]*flight),
+	})
+}
+
+/** Fetch the given document, sharing its download with its aliases. */
+func (round *fetchRound) fetch(ctx context.Context, feedURL string) (*Document, error) {
+	finalURL, shared := round.aliases.shared(feedURL)
+
+	if !shared {
+		document, err := fetchDirectly(ctx, feedURL)
+
+		if err == nil {
+			round.aliases.learn(feedURL, document.FinalURL)
+		}
+
+		return document, err
+	}
+
+	validators, _ := ctx.Value(validatorsKey{}).(Validators)
+	key := flightKey{finalURL: finalURL, validators: validators}
+
+	round.mutex.Lock()
+	current, joined := round.flights[key]
+
+	if !joined {
+		current = &flight{done: make(chan struct{})}
+		round.flights[key] = current
+	}
+
+	round.mutex.Unlock()
+
+	if joined {
+		select {
+		case <-current.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else {
+		current.download(ctx, feedURL)
+
+		if current.err == nil {
+			round.aliases.learn(feedURL, current.document.FinalURL)
+		}
+	}
+
+	if current.err != nil {
+		return nil, current.err
+	}
+
+	document := current.document
+	document.Body = io.NopCloser(bytes.NewReader(current.body))
+
+	return &document, nil
+}
+
+/** Download the document for everyone waiting on the flight. */
+func (current *flight) download(ctx context.Context, feedURL string) {
+	defer close(current.done)
+
+	document, err := fetchDirectly(ctx, feedURL)
+
+	if err != nil {
+		current.err = err
+		return
+	}
+
+	defer document.Body.Close()
+
+	current.document = *document
+	current.body, current.err = io.ReadAll(document.Body)
+}
@@ -0,0 +1,194 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+/** How much of a document is looked at to tell what it is. */
+const sniffLength = 512
+
+/** How much of an HTML page is searched for links to feeds. */
+const maxPageLength = 1 << 20
+
+/*
+  - Returned when a URL yields something other than a feed, such as a
+    web page or a JSON document. It says what was found instead, so
+    that the mistake can be put right, rather than leaving a parser's
+    complaint about the first character it didn't expect.
+*/
+type NotAFeedError struct {
+	URL string
+
+	// The document's media type, as the server gave it, or else as
+	// guessed from the document itself.
+	ContentType string
+
+	// What the document looks like: "html", "json", or "other".
+	Kind string
+
+	// For an HTML page, the feeds it links to (with <link
+	// rel="alternate">), if any.
+	Alternates []Alternate
+
+	// Whether a JSON document is a JSON Feed, which gator only reads
+	// through 'json_endpoints', like any other JSON.
+	JSONFeed bool
+}
+
+func (err *NotAFeedError) Error() string {
+	switch {
+	case err.Kind == "html" && len(err.Alternates) == 1:
+		return fmt.Sprintf("%s is an HTML page (%s), not a feed; it links to the feed %s, so try that instead",
+			err.URL, err.ContentType, err.Alternates[0].URL)
+	case err.Kind == "html" && len(err.Alternates) > 1:
+		return fmt.Sprintf("%s is an HTML page (%s), not a feed; it links to these feeds, so try one of them instead: %s",
+			err.URL, err.ContentType, strings.Join(alternateURLs(err.Alternates), ", "))
+	case err.Kind == "html":
+		return fmt.Sprintf("%s is an HTML page (%s), not a feed, and doesn't link to one; look on the site for an RSS or Atom link",
+			err.URL, err.ContentType)
+	case err.JSONFeed:
+		return fmt.Sprintf("%s is a JSON Feed (%s), not an RSS or Atom feed; look for an RSS or Atom version of it, or read it through 'json_endpoints'",
+			err.URL, err.ContentType)
+	case err.Kind == "json":
+		return fmt.Sprintf("%s is a JSON document (%s), not a feed; to follow a JSON API, set it up under 'json_endpoints'",
+			err.URL, err.ContentType)
+	default:
+		return fmt.Sprintf("%s returned a document of type %s, not a feed", err.URL, err.ContentType)
+	}
+}
+
+/** Signs that a document is a feed after all, whatever else it looks like. */
+var (
+	feedRootPattern = regexp.MustCompile(`<(rss|feed|rdf:RDF)\b`)
+	jsonFeedPattern = regexp.MustCompile(`"version"\s*:\s*"https?://jsonfeed\.org/version/`)
+)
+
+/*
+  - Check that the given document at least looks like XML, returning a
+    'NotAFeedError' if it's plainly something else. 'body' is peeked
+    at rather than consumed, except that an HTML page is read (in
+    part) for links to feeds, since it won't be parsed anyway.
+*/
+func checkFeed(document *Document, body *bufio.Reader) error {
+	peek, _ := body.Peek(sniffLength)
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(peek, []byte("\ufeff")))
+
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	sniffed := http.DetectContentType(peek)
+	contentType, _, _ := mime.ParseMediaType(document.ContentType)
+
+	if contentType == "" {
+		contentType, _, _ = mime.ParseMediaType(sniffed)
+	}
+
+	notAFeed := &NotAFeedError{
+		URL:         document.FinalURL,
+		ContentType: contentType,
+	}
+
+	switch {
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		notAFeed.Kind = "json"
+		notAFeed.JSONFeed = jsonFeedPattern.Match(peek)
+	case strings.HasPrefix(sniffed, "text/html") && !feedRootPattern.Match(peek):
+		page, _ := io.ReadAll(io.LimitReader(body, maxPageLength))
+
+		notAFeed.Kind = "html"
+		notAFeed.Alternates = alternates(document.FinalURL, page)
+	case trimmed[0] == '<':
+		// Close enough to XML for the parser to have its say.
+		return nil
+	default:
+		notAFeed.Kind = "other"
+	}
+
+	return notAFeed
+}
+
+/** A <link> tag, and the attributes which matter within one. */
+var (
+	linkPattern      = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)\s(rel|type|href|title)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+/** The feed types a page may link to which gator can read. */
+var feedTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/rdf+xml",
+	"application/xml",
+	"text/xml",
+}
+
+/** A feed linked to by a web page, along with the title the link gives it, if any. */
+type Alternate struct {
+	URL   string
+	Title string
+}
+
+/*
+  - Return the feeds the given HTML page links to with <link
+    rel="alternate">, as absolute URLs, in the order they appear.
+*/
+func alternates(pageURL string, page []byte) []Alternate {
+	base, _ := url.Parse(pageURL)
+	found := make([]Alternate, 0)
+
+	for _, tag := range linkPattern.FindAll(page, -1) {
+		attrs := make(map[string]string)
+
+		for _, match := range attributePattern.FindAllSubmatch(tag, -1) {
+			value := strings.Trim(string(match[2]), `"'`)
+			attrs[strings.ToLower(string(match[1]))] = html.UnescapeString(value)
+		}
+
+		rels := strings.Fields(strings.ToLower(attrs["rel"]))
+		feedType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+
+		if !slices.Contains(rels, "alternate") || !slices.Contains(feedTypes, feedType) || attrs["href"] == "" {
+			continue
+		}
+
+		href, err := url.Parse(attrs["href"])
+
+		if err != nil {
+			continue
+		}
+
+		if base != nil {
+			href = base.ResolveReference(href)
+		}
+
+		if !slices.Contains(alternateURLs(found), href.String()) {
+			found = append(found, Alternate{
+				URL:   href.String(),
+				Title: strings.TrimSpace(attrs["title"]),
+			})
+		}
+	}
+
+	return found
+}
+
+func alternateURLs(alternates []Alternate) []string {
+	urls := make([]string, 0, len(alternates))
+
+	for _, alternate := range alternates {
+		urls = append(urls, alternate.URL)
+	}
+
+	return urls
+}
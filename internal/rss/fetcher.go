@@ -0,0 +1,293 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+/** A fetched feed document, along with what's known about it. */
+type Document struct {
+	// The document itself. The caller must close it.
+	Body io.ReadCloser
+
+	// Where the document was finally found (for example, after
+	// following redirects.)
+	FinalURL string
+
+	// The document's media type, if the transport reports one.
+	ContentType string
+
+	// The document's validators, if the transport reports any.
+	Validators Validators
+}
+
+/*
+  - What a server said identifies the version of a document it sent
+    (its ETag and Last-Modified headers.) Handing these back on the
+    next fetch lets the server answer that nothing has changed, rather
+    than sending the whole document again.
+*/
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+/*
+  - Make a document out of items gathered some other way than from a
+    feed (from a mailbox, say, or a registry's API), so that they can
+    be handled like any feed's.
+*/
+func NewDocument(title string, link string, rssItems []RSSItem) (*Document, error) {
+	document := synthesizedFeed{Version: "2.0"}
+	document.Channel.Title = title
+	document.Channel.Link = link
+	document.Channel.Items = rssItems
+
+	encoded, err := xml.Marshal(document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Body:        io.NopCloser(bytes.NewReader(encoded)),
+		FinalURL:    link,
+		ContentType: "application/rss+xml",
+	}, nil
+}
+
+/** The RSS document 'NewDocument' produces. */
+type synthesizedFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title string    `xml:"title"`
+		Link  string    `xml:"link"`
+		Items []RSSItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+/** Returned when a conditional fetch finds the document unchanged. */
+var ErrNotModified = errors.New("Feed not modified")
+
+type validatorsKey struct{}
+
+/*
+  - Ask for the document only if it has changed since it carried the
+    given validators; fetchers which understand validators report
+    ErrNotModified if it hasn't.
+*/
+func withValidators(ctx context.Context, validators Validators) context.Context {
+	return context.WithValue(ctx, validatorsKey{}, validators)
+}
+
+/*
+  - A way of getting hold of a feed document given its URL. HTTP is
+    the default; others can be registered for particular URLs (see
+    'Register'), for example to read local files, or to render pages
+    which only come together under JavaScript.
+*/
+type Fetcher interface {
+	Fetch(ctx context.Context, feedURL string) (*Document, error)
+}
+
+/*
+  - Fetches over HTTP(S), treating error statuses as failures. With a
+    'Breaker', hosts which keep failing are left alone for a while.
+*/
+type HTTPFetcher struct {
+	Client  *http.Client
+	Breaker *Breaker
+}
+
+func (fetcher HTTPFetcher) Fetch(ctx context.Context, feedURL string) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fetcher.Breaker != nil {
+		if err = fetcher.Breaker.Allow(feedURL); err != nil {
+			return nil, err
+		}
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	if validators, ok := ctx.Value(validatorsKey{}).(Validators); ok {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	resp, err := fetcher.Client.Do(req)
+
+	if fetcher.Breaker != nil {
+		status := 0
+
+		if resp != nil {
+			status = resp.StatusCode
+		}
+
+		fetcher.Breaker.Record(feedURL, hostDown(ctx, err, status))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, ErrNotModified
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	return &Document{
+		Body:        resp.Body,
+		FinalURL:    resp.Request.URL.String(),
+		ContentType: resp.Header.Get("Content-Type"),
+		Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+/** Reads file:// URLs from the local filesystem. */
+type FileFetcher struct{}
+
+func (FileFetcher) Fetch(ctx context.Context, feedURL string) (*Document, error) {
+	parsed, err := url.Parse(feedURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(parsed.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{Body: file, FinalURL: feedURL}, nil
+}
+
+/*
+  - Runs an external command and takes its standard output as the
+    document. Any "{url}" among the arguments is replaced by the feed's
+    URL, so that, for example, a headless browser can render the page,
+    or a remote host can fetch it over ssh.
+*/
+type CommandFetcher struct {
+	Command []string
+}
+
+func (fetcher CommandFetcher) Fetch(ctx context.Context, feedURL string) (*Document, error) {
+	if len(fetcher.Command) == 0 {
+		return nil, fmt.Errorf("No command given to fetch %q with", feedURL)
+	}
+
+	args := make([]string, len(fetcher.Command)-1)
+
+	for i, arg := range fetcher.Command[1:] {
+		args[i] = strings.ReplaceAll(arg, "{url}", feedURL)
+	}
+
+	// The whole output is collected up front, so that a failing
+	// command is reported as such rather than as a malformed feed.
+	output, err := exec.CommandContext(ctx, fetcher.Command[0], args...).Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("Fetching %q with %q: %w", feedURL, fetcher.Command[0], err)
+	}
+
+	return &Document{
+		Body:     io.NopCloser(bytes.NewReader(output)),
+		FinalURL: feedURL,
+	}, nil
+}
+
+/** The fetcher used for URLs no registered fetcher claims. */
+var DefaultFetcher Fetcher = HTTPFetcher{
+	Client:  &http.Client{Timeout: 5 * time.Second},
+	Breaker: DefaultBreaker,
+}
+
+/** A fetcher, along with the URLs it handles. */
+type registration struct {
+	prefix  string
+	fetcher Fetcher
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = []registration{
+		{prefix: "file://", fetcher: FileFetcher{}},
+	}
+)
+
+/*
+  - Have URLs starting with 'prefix' fetched with 'fetcher'. Where
+    several registered prefixes match a URL, the longest wins.
+*/
+func Register(prefix string, fetcher Fetcher) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry = append(registry, registration{prefix: prefix, fetcher: fetcher})
+}
+
+/** Return the fetcher responsible for the given URL. */
+func FetcherFor(feedURL string) Fetcher {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	fetcher := DefaultFetcher
+	longest := -1
+
+	for _, entry := range registry {
+		if strings.HasPrefix(feedURL, entry.prefix) && len(entry.prefix) > longest {
+			fetcher = entry.fetcher
+			longest = len(entry.prefix)
+		}
+	}
+
+	return fetcher
+}
+
+/*
+  - Fetch the given feed document with whichever fetcher handles it,
+    sharing the download with its aliases if asked to (see
+    'CoalesceFetches'.)
+*/
+func fetch(ctx context.Context, feedURL string) (*Document, error) {
+	if round, ok := ctx.Value(fetchRoundKey{}).(*fetchRound); ok {
+		return round.fetch(ctx, feedURL)
+	}
+
+	return fetchDirectly(ctx, feedURL)
+}
+
+func fetchDirectly(ctx context.Context, feedURL string) (*Document, error) {
+	return FetcherFor(feedURL).Fetch(ctx, feedURL)
+}
@@ -0,0 +1,124 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/** How many items the benchmark feeds carry, as a busy feed might. */
+const benchItems = 500
+
+/** An RSS document with 'items' items, each with a paragraph of content. */
+func rssDocument(items int) []byte {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>`)
+	b.WriteString(`<title>Benchmark Feed</title><link>https://example.com/</link><description>A feed for benchmarks</description>`)
+
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range items {
+		fmt.Fprintf(&b, `<item><title>Post number %d &amp; counting</title>`, i)
+		fmt.Fprintf(&b, `<link>https://example.com/posts/%d</link><guid>https://example.com/posts/%d</guid>`, i, i)
+		fmt.Fprintf(&b, `<pubDate>%s</pubDate><dc:creator>Author %d</dc:creator>`, published.Add(time.Duration(i)*time.Hour).Format(time.RFC1123Z), i%7)
+		fmt.Fprintf(&b, `<category>Topic %d</category>`, i%5)
+		fmt.Fprintf(&b, `<description>&lt;p&gt;%s&lt;/p&gt;</description></item>`, strings.Repeat("Lorem ipsum dolor sit amet. ", 40))
+	}
+
+	b.WriteString(`</channel></rss>`)
+
+	return []byte(b.String())
+}
+
+/** An Atom document with 'items' entries, like 'rssDocument'. */
+func atomDocument(items int) []byte {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom"><title>Benchmark Feed</title>`)
+	b.WriteString(`<link href="https://example.com/"/><subtitle>A feed for benchmarks</subtitle>`)
+
+	published := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range items {
+		fmt.Fprintf(&b, `<entry><id>urn:example:%d</id><title>Post number %d</title>`, i, i)
+		fmt.Fprintf(&b, `<link href="https://example.com/posts/%d"/><published>%s</published>`, i, published.Add(time.Duration(i)*time.Hour).Format(time.RFC3339))
+		fmt.Fprintf(&b, `<author><name>Author %d</name></author><category term="topic-%d"/>`, i%7, i%5)
+		fmt.Fprintf(&b, `<summary type="html">&lt;p&gt;%s&lt;/p&gt;</summary></entry>`, strings.Repeat("Lorem ipsum dolor sit amet. ", 40))
+	}
+
+	b.WriteString(`</feed>`)
+
+	return []byte(b.String())
+}
+
+func BenchmarkParseRSS(b *testing.B) {
+	document := rssDocument(benchItems)
+	b.SetBytes(int64(len(document)))
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := parseFeed(document); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseAtom(b *testing.B) {
+	document := atomDocument(benchItems)
+	b.SetBytes(int64(len(document)))
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := parseFeed(document); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+/*
+  - Stream a feed from disk in batches, as 'agg' reads one, so that the
+    decoding and batching are measured without the network.
+*/
+func BenchmarkStreamFeed(b *testing.B) {
+	for _, format := range []struct {
+		name     string
+		document []byte
+	}{
+		{"rss", rssDocument(benchItems)},
+		{"atom", atomDocument(benchItems)},
+	} {
+		b.Run(format.name, func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "feed.xml")
+
+			if err := os.WriteFile(path, format.document, 0o644); err != nil {
+				b.Fatal(err)
+			}
+
+			feedURL := "file://" + path
+			options := StreamOptions{BatchSize: 50}
+			b.SetBytes(int64(len(format.document)))
+			b.ResetTimer()
+
+			for range b.N {
+				count, err := StreamFeed(context.Background(), feedURL, options, func(batch []RSSItem) error {
+					return nil
+				})
+
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if count != benchItems {
+					b.Fatalf("Streamed %d items, expected %d", count, benchItems)
+				}
+			}
+		})
+	}
+}
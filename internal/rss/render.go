@@ -0,0 +1,281 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+  - How to get items out of a page which only comes together under
+    JavaScript: the page is loaded in headless Chrome (or Chromium),
+    found on the PATH unless 'Browser' names it, and once an element
+    matching 'Item' has appeared, the selectors pick the items out of
+    the rendered page.
+
+    Selectors are CSS selectors. 'Title', 'Link', and 'Date' are
+    matched within each element matched by 'Item'; the first match of
+    each is used. Without a 'Link' selector, the first <a> is used,
+    and without a 'Title' selector, the link's text.
+*/
+type Renderer struct {
+	Browser string
+	Item    string
+	Title   string
+	Link    string
+	Date    string
+}
+
+/*
+  - How long a page is given to render, and its items to appear,
+    however long the command's own deadline (if it has one.)
+*/
+const renderTimeout = 30 * time.Second
+
+var (
+	renderersMutex sync.RWMutex
+	renderers      = make(map[string]Renderer)
+)
+
+/*
+  - Have feeds whose URLs start with 'prefix' rendered with 'renderer'
+    whenever fetching them normally yields no items.
+*/
+func RegisterRenderer(prefix string, renderer Renderer) {
+	renderersMutex.Lock()
+	defer renderersMutex.Unlock()
+
+	renderers[prefix] = renderer
+}
+
+/** Return the renderer for the given URL, if one was registered. */
+func rendererFor(feedURL string) (Renderer, bool) {
+	renderersMutex.RLock()
+	defer renderersMutex.RUnlock()
+
+	var found Renderer
+	longest := -1
+
+	for prefix, renderer := range renderers {
+		if strings.HasPrefix(feedURL, prefix) && len(prefix) > longest {
+			found = renderer
+			longest = len(prefix)
+		}
+	}
+
+	return found, longest >= 0
+}
+
+/*
+  - If fetching the given feed normally came to nothing (that is, no
+    items, or an error), and a renderer is registered for it, render
+    the page and extract its items instead. Otherwise, the original
+    outcome stands.
+*/
+func renderFallback(ctx context.Context, feedURL string, found int, fetchErr error) ([]RSSItem, bool, error) {
+	renderer, ok := rendererFor(feedURL)
+
+	if !ok || found > 0 {
+		return nil, false, fetchErr
+	}
+
+	rssItems, err := renderer.Render(ctx, feedURL)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return rssItems, true, nil
+}
+
+/** Render the page at the given URL, and extract its items. */
+func (renderer Renderer) Render(ctx context.Context, pageURL string) ([]RSSItem, error) {
+	item, err := parseSelector(renderer.Item)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a selector of its own, the link is the first <a>.
+	linkSelector := renderer.Link
+
+	if linkSelector == "" {
+		linkSelector = "a"
+	}
+
+	link, err := parseSelector(linkSelector)
+
+	if err != nil {
+		return nil, err
+	}
+
+	title, err := optionalSelector(renderer.Title)
+
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := optionalSelector(renderer.Date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(pageURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := renderer.render(ctx, pageURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := html.Parse(strings.NewReader(page))
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse the rendering of %q: %w", pageURL, err)
+	}
+
+	rssItems := make([]RSSItem, 0)
+	seen := time.Now().UTC().Format(time.RFC3339)
+
+	for _, element := range cascadia.QueryAll(root, item) {
+		rssItem := RSSItem{}
+
+		if found := cascadia.Query(element, link); found != nil {
+			if href, err := base.Parse(attribute(found, "href")); err == nil {
+				rssItem.Link = href.String()
+			}
+
+			rssItem.Title = nodeText(found)
+		}
+
+		if title != nil {
+			if found := cascadia.Query(element, title); found != nil {
+				rssItem.Title = nodeText(found)
+			}
+		}
+
+		// A <time> element carries a machine-readable date.
+		if date != nil {
+			if found := cascadia.Query(element, date); found != nil {
+				rssItem.PubDate = attribute(found, "datetime")
+
+				if rssItem.PubDate == "" {
+					rssItem.PubDate = nodeText(found)
+				}
+			}
+		}
+
+		// Undated items count as published when first seen.
+		if rssItem.PubDate == "" {
+			rssItem.PubDate = seen
+		}
+
+		if rssItem.Link != "" {
+			rssItems = append(rssItems, rssItem)
+		}
+	}
+
+	return rssItems, nil
+}
+
+/*
+  - Load the page in a headless browser of its own, and return its
+    HTML once something matching 'Item' has appeared in it.
+*/
+func (renderer Renderer) render(ctx context.Context, pageURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	options := chromedp.DefaultExecAllocatorOptions[:]
+
+	if renderer.Browser != "" {
+		options = append(options, chromedp.ExecPath(renderer.Browser))
+	}
+
+	allocatorCtx, cancelAllocator := chromedp.NewExecAllocator(ctx, options...)
+	defer cancelAllocator()
+
+	browserCtx, cancelBrowser := chromedp.NewContext(allocatorCtx)
+	defer cancelBrowser()
+
+	var page string
+
+	err := chromedp.Run(browserCtx,
+		chromedp.Navigate(pageURL),
+		chromedp.WaitReady(renderer.Item, chromedp.ByQuery),
+		chromedp.OuterHTML("html", &page, chromedp.ByQuery),
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("Rendering %q: %w", pageURL, err)
+	}
+
+	return page, nil
+}
+
+/** Parse a CSS selector, naming it if it's malformed. */
+func parseSelector(selector string) (cascadia.Sel, error) {
+	sel, err := cascadia.Parse(selector)
+
+	if err != nil {
+		return nil, fmt.Errorf("Bad selector %q: %w", selector, err)
+	}
+
+	return sel, nil
+}
+
+/** Like 'parseSelector', but with no selector given, there's nothing to match. */
+func optionalSelector(selector string) (cascadia.Sel, error) {
+	if selector == "" {
+		return nil, nil
+	}
+
+	return parseSelector(selector)
+}
+
+/** The value of the element's attribute, or "" if it has none. */
+func attribute(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+/** The node's text, with whitespace collapsed, leaving out scripts and styles. */
+func nodeText(node *html.Node) string {
+	var builder strings.Builder
+
+	var walk func(*html.Node)
+
+	walk = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			builder.WriteString(node.Data)
+			builder.WriteString(" ")
+		case node.Type == html.ElementNode && (node.Data == "script" || node.Data == "style"):
+			return
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	walk(node)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
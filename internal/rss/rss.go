@@ -1,15 +1,13 @@
 package rss
 
 import (
+	"bufio"
 	"context"
-	"encoding/xml"
 	"fmt"
 	"html"
 	"io"
-	"net/http"
 	"os"
 	"strings"
-	"time"
 )
 
 type RSSFeed struct {
@@ -26,6 +24,19 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+
+	// An ID for the item; Atom's 'id' is read into this too.
+	GUID string `xml:"guid,omitempty"`
+
+	// RSS's own author element holds an email address (optionally
+	// followed by a name in parentheses), so many feeds use Dublin
+	// Core's 'creator' instead.
+	Author  string `xml:"author"`
+	Creator string `xml:"creator"`
+
+	// How the publisher files the item; Atom's 'category' terms are
+	// read into this too.
+	Categories []string `xml:"category,omitempty"`
 }
 
 func (rssFeed RSSFeed) String() string {
@@ -55,40 +66,53 @@ func (rssItem RSSItem) String() string {
 }
 
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	// Make the HTTP GET request to the feedURL.
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	rssFeed, err := fetchDocument(ctx, feedURL)
+	found := 0
 
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "From 'http.NewRequestWithContext'\n")
-		return nil, err
+	if err == nil {
+		found = len(rssFeed.Channel.Item)
 	}
 
-	req.Header.Set("User-Agent", "gator")
+	rendered, ok, err := renderFallback(ctx, feedURL, found, err)
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+	if !ok {
+		return rssFeed, err
 	}
 
-	resp, err := client.Do(req)
+	rssFeed = &RSSFeed{}
+	rssFeed.Channel.Link = feedURL
+	rssFeed.Channel.Item = rendered
+
+	return rssFeed, nil
+}
+
+/** Fetch and parse the feed document itself; see 'FetchFeed'. */
+func fetchDocument(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	document, err := fetch(ctx, feedURL)
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "From 'client.Do'\n")
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	defer document.Body.Close()
+
+	body := bufio.NewReader(document.Body)
+
+	if err = checkFeed(document, body); err != nil {
+		return nil, err
+	}
 
 	// Populate the RSSFeed struct.
-	xmlBytes, err := io.ReadAll(resp.Body)
+	xmlBytes, err := io.ReadAll(body)
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "From 'io.ReadAll'\n")
 		return nil, err
 	}
 
-	rssFeed := &RSSFeed{}
+	rssFeed, err := parseFeed(xmlBytes)
 
-	if err = xml.Unmarshal(xmlBytes, rssFeed); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
@@ -97,11 +121,39 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	rssFeed.Channel.Description = html.UnescapeString(rssFeed.Channel.Description)
 
 	for i := range rssFeed.Channel.Item {
-		rssItem := &rssFeed.Channel.Item[i]
-
-		rssItem.Title = html.UnescapeString(rssItem.Title)
-		rssItem.Description = html.UnescapeString(rssItem.Description)
+		unescapeItem(&rssFeed.Channel.Item[i])
 	}
 
 	return rssFeed, nil
 }
+
+/*
+  - Return the name of the item's author, or the empty string if the
+    feed doesn't say.
+*/
+func (rssItem RSSItem) AuthorName() string {
+	if creator := strings.TrimSpace(rssItem.Creator); creator != "" {
+		return creator
+	}
+
+	author := strings.TrimSpace(rssItem.Author)
+
+	// Prefer the name in "jane@example.com (Jane Doe)".
+	if open := strings.Index(author, "("); open >= 0 && strings.HasSuffix(author, ")") {
+		return strings.TrimSpace(author[open+1 : len(author)-1])
+	}
+
+	return author
+}
+
+/** Decode escaped HTML entities in the item's text fields. */
+func unescapeItem(rssItem *RSSItem) {
+	rssItem.Title = html.UnescapeString(rssItem.Title)
+	rssItem.Description = html.UnescapeString(rssItem.Description)
+	rssItem.Author = html.UnescapeString(rssItem.Author)
+	rssItem.Creator = html.UnescapeString(rssItem.Creator)
+
+	for i, category := range rssItem.Categories {
+		rssItem.Categories[i] = html.UnescapeString(category)
+	}
+}
@@ -0,0 +1,244 @@
+package rss
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+/** Limits applied while streaming a feed's items. */
+type StreamOptions struct {
+	// How many items are handed to the callback at once.
+	BatchSize int
+
+	// The most items read from a single feed; any beyond this are
+	// ignored. Zero means no limit.
+	MaxItems int
+
+	// If set, the feed is only fetched if it has changed since it
+	// carried these validators (otherwise, ErrNotModified is
+	// returned), and they're replaced with those of the new version.
+	Validators *Validators
+
+	// If set, filled in with what the feed says about itself.
+	Channel *Channel
+}
+
+/*
+  - The namespaces of the feed formats themselves (RSS 2.0, Atom, and
+    RSS 1.0), as opposed to those of extensions (such as iTunes's),
+    whose titles and descriptions are something else.
+*/
+var channelSpaces = []string{"", "http://www.w3.org/2005/Atom", "http://purl.org/rss/1.0/"}
+
+/** What a feed says about itself, as opposed to its items. */
+type Channel struct {
+	Title       string
+	Description string
+
+	// The URL the feed gives as its own (with <link rel="self">),
+	// which changes when the feed moves.
+	SelfURL string
+}
+
+/*
+  - Fetch the given feed, decoding its items one at a time and handing
+    them to 'handleBatch' in groups of 'options.BatchSize'. Unlike
+    'FetchFeed', the document is never held in memory as a whole, so
+    feeds shipping their entire archive can't balloon memory.
+
+    Returns the number of items handled. If 'handleBatch' returns an
+    error, streaming stops and that error is returned.
+*/
+func StreamFeed(ctx context.Context, feedURL string, options StreamOptions, handleBatch func([]RSSItem) error) (int, error) {
+	if options.BatchSize < 1 {
+		options.BatchSize = 1
+	}
+
+	count, err := streamDocument(ctx, feedURL, options, handleBatch)
+
+	// An unchanged feed has nothing new to render either.
+	if errors.Is(err, ErrNotModified) {
+		return 0, err
+	}
+
+	rendered, ok, err := renderFallback(ctx, feedURL, count, err)
+
+	if !ok {
+		return count, err
+	}
+
+	if options.MaxItems > 0 && len(rendered) > options.MaxItems {
+		rendered = rendered[:options.MaxItems]
+	}
+
+	for start := 0; start < len(rendered); start += options.BatchSize {
+		end := min(start+options.BatchSize, len(rendered))
+
+		if err = handleBatch(rendered[start:end]); err != nil {
+			return end, err
+		}
+	}
+
+	return len(rendered), nil
+}
+
+/** Stream the items of the feed document itself; see 'StreamFeed'. */
+func streamDocument(ctx context.Context, feedURL string, options StreamOptions, handleBatch func([]RSSItem) error) (int, error) {
+	if options.Validators != nil {
+		ctx = withValidators(ctx, *options.Validators)
+	}
+
+	document, err := fetch(ctx, feedURL)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer document.Body.Close()
+
+	if options.Validators != nil {
+		*options.Validators = document.Validators
+	}
+
+	body := bufio.NewReader(document.Body)
+
+	if err = checkFeed(document, body); err != nil {
+		return 0, err
+	}
+
+	decoder := xml.NewDecoder(body)
+	batch := make([]RSSItem, 0, options.BatchSize)
+	count := 0
+
+	var channel Channel
+
+	if options.Channel != nil {
+		defer func() { *options.Channel = channel }()
+	}
+
+	for options.MaxItems == 0 || count < options.MaxItems {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return count, fmt.Errorf("Malformed feed %q: %w", feedURL, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok {
+			continue
+		}
+
+		// RSS items and Atom entries are both accepted, so that either
+		// kind of feed can be streamed. Whatever else isn't inside one
+		// of them describes the feed itself.
+		var rssItem RSSItem
+
+		switch start.Name.Local {
+		case "title", "description", "subtitle":
+			if !slices.Contains(channelSpaces, start.Name.Space) {
+				continue
+			}
+
+			if err = decodeChannelText(decoder, &start, &channel); err != nil {
+				return count, fmt.Errorf("Malformed feed %q: %w", feedURL, err)
+			}
+
+			continue
+		case "link":
+			if attrValue(start, "rel") == "self" && channel.SelfURL == "" {
+				channel.SelfURL = strings.TrimSpace(attrValue(start, "href"))
+			}
+
+			continue
+		case "image", "textinput", "textInput":
+			// These have titles and descriptions of their own.
+			if err = decoder.Skip(); err != nil {
+				return count, fmt.Errorf("Malformed feed %q: %w", feedURL, err)
+			}
+
+			continue
+		case "item":
+			if err = decoder.DecodeElement(&rssItem, &start); err != nil {
+				return count, fmt.Errorf("Malformed item in feed %q: %w", feedURL, err)
+			}
+		case "entry":
+			var entry atomEntry
+
+			if err = decoder.DecodeElement(&entry, &start); err != nil {
+				return count, fmt.Errorf("Malformed entry in feed %q: %w", feedURL, err)
+			}
+
+			rssItem = entry.toItem()
+		default:
+			continue
+		}
+
+		unescapeItem(&rssItem)
+		batch = append(batch, rssItem)
+		count++
+
+		if len(batch) == options.BatchSize {
+			if err = handleBatch(batch); err != nil {
+				return count, err
+			}
+
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err = handleBatch(batch); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+/*
+  - Decode the text of one of the feed's own elements into 'channel',
+    unless an earlier element has already given it.
+*/
+func decodeChannelText(decoder *xml.Decoder, start *xml.StartElement, channel *Channel) error {
+	var text string
+
+	if err := decoder.DecodeElement(&text, start); err != nil {
+		return err
+	}
+
+	text = strings.TrimSpace(html.UnescapeString(text))
+	field := &channel.Description
+
+	if start.Name.Local == "title" {
+		field = &channel.Title
+	}
+
+	if *field == "" {
+		*field = text
+	}
+
+	return nil
+}
+
+/** The value of the given attribute of an element, or "" if it has none. */
+func attrValue(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
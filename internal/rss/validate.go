@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/** The outcome of validating a single feed URL. */
+type ValidationStatus int
+
+const (
+	StatusOK ValidationStatus = iota
+	StatusRedirected
+	StatusDead
+)
+
+func (status ValidationStatus) String() string {
+	switch status {
+	case StatusOK:
+		return "ok"
+	case StatusRedirected:
+		return "redirected"
+	case StatusDead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
+
+type Validation struct {
+	// The URL as originally given.
+	URL string
+
+	// The URL after following any redirects.
+	FinalURL string
+
+	Status ValidationStatus
+
+	// Why the feed was classified as dead, if it was.
+	Err error
+}
+
+/*
+  - Fetch the given feed URL and classify it as ok, redirected, or
+    dead. A feed is dead if it can't be fetched, returns an error
+    status, or doesn't parse as RSS.
+*/
+func ValidateFeed(ctx context.Context, feedURL string) Validation {
+	validation := Validation{
+		URL:      feedURL,
+		FinalURL: feedURL,
+		Status:   StatusDead,
+	}
+
+	document, err := fetch(ctx, feedURL)
+
+	if err != nil {
+		validation.Err = err
+		return validation
+	}
+
+	defer document.Body.Close()
+
+	body := bufio.NewReader(document.Body)
+
+	if err = checkFeed(document, body); err != nil {
+		validation.Err = err
+		return validation
+	}
+
+	xmlBytes, err := io.ReadAll(body)
+
+	if err != nil {
+		validation.Err = err
+		return validation
+	}
+
+	if _, err = parseFeed(xmlBytes); err != nil {
+		validation.Err = fmt.Errorf("Not a valid RSS or Atom document: %w", err)
+		return validation
+	}
+
+	validation.Err = nil
+	validation.FinalURL = document.FinalURL
+
+	if validation.FinalURL != feedURL {
+		validation.Status = StatusRedirected
+	} else {
+		validation.Status = StatusOK
+	}
+
+	return validation
+}
+
+/*
+  - Validate the given feed URLs concurrently, with at most 'workers'
+    requests in flight at once. The results are returned in the same
+    order as 'feedURLs'.
+
+    If 'onDone' is non-nil, it's called once per URL as soon as its
+    validation completes (possibly from several goroutines at once.)
+*/
+func ValidateFeeds(ctx context.Context, feedURLs []string, workers int, onDone func(Validation)) []Validation {
+	if workers < 1 {
+		workers = 1
+	}
+
+	validations := make([]Validation, len(feedURLs))
+	semaphore := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+
+	for i, feedURL := range feedURLs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			validations[i] = ValidateFeed(ctx, feedURL)
+
+			if onDone != nil {
+				onDone(validations[i])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return validations
+}
@@ -0,0 +1,165 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/** How much of a watched value is kept, in characters. */
+const maxWatchedValue = 500
+
+/*
+  - Watches a value on a web page (a price, say, or a status), with a
+    post each time it changes. Feed URLs take the form
+    "watch+https://PAGE#css=SELECTOR" or "watch+https://PAGE#regex=PATTERN":
+    the selector (as understood by renderers) picks out the element
+    whose text is watched, while the pattern is matched against the
+    page's source, with its first group (or else the whole match)
+    watched. Either may be percent-encoded.
+
+    The value last seen is kept as the feed's ETag, so that a fetch
+    finding it unchanged reports ErrNotModified, like an HTTP server
+    would.
+*/
+type WatchFetcher struct {
+	Client *http.Client
+}
+
+func (fetcher WatchFetcher) Fetch(ctx context.Context, feedURL string) (*Document, error) {
+	pageURL, extraction, _ := strings.Cut(strings.TrimPrefix(feedURL, "watch+"), "#")
+	method, expression, _ := strings.Cut(extraction, "=")
+
+	if unescaped, err := url.PathUnescape(expression); err == nil {
+		expression = unescaped
+	}
+
+	if expression == "" || (method != "css" && method != "regex") {
+		return nil, fmt.Errorf("Nothing to watch in %q (expected #css=SELECTOR or #regex=PATTERN)", feedURL)
+	}
+
+	// The previous value is ours, not the page's ETag, so it mustn't
+	// reach the page's server.
+	previous, _ := ctx.Value(validatorsKey{}).(Validators)
+
+	page, err := HTTPFetcher{Client: fetcher.Client}.Fetch(withValidators(ctx, Validators{}), pageURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer page.Body.Close()
+
+	source, err := io.ReadAll(io.LimitReader(page.Body, 16<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := extractValue(source, method, expression)
+
+	if err != nil {
+		return nil, fmt.Errorf("Watching %q: %w", pageURL, err)
+	}
+
+	if previous.ETag == value {
+		return nil, ErrNotModified
+	}
+
+	// Each change is a post of its own, even if the value reverts to
+	// an earlier one.
+	now := time.Now().UTC()
+
+	rssItem := RSSItem{
+		Title:   value,
+		Link:    pageURL + "#changed-" + now.Format("20060102T150405.000000000Z"),
+		PubDate: now.Format(time.RFC3339),
+	}
+
+	if previous.ETag != "" {
+		rssItem.Description = "Previously: " + previous.ETag
+	}
+
+	title := pageURL
+
+	if parsed, err := url.Parse(pageURL); err == nil {
+		title = parsed.Host + parsed.Path
+	}
+
+	document, err := NewDocument(title, pageURL, []RSSItem{rssItem})
+
+	if err != nil {
+		return nil, err
+	}
+
+	document.FinalURL = feedURL
+	document.Validators = Validators{ETag: value}
+
+	return document, nil
+}
+
+/** Pick the watched value out of a page, with whitespace collapsed. */
+func extractValue(source []byte, method string, expression string) (string, error) {
+	var value string
+
+	switch method {
+	case "css":
+		sel, err := parseSelector(expression)
+
+		if err != nil {
+			return "", err
+		}
+
+		root, err := html.Parse(bytes.NewReader(source))
+
+		if err != nil {
+			return "", err
+		}
+
+		element := cascadia.Query(root, sel)
+
+		if element == nil {
+			return "", fmt.Errorf("Nothing matches selector %q", expression)
+		}
+
+		value = nodeText(element)
+	case "regex":
+		pattern, err := regexp.Compile(expression)
+
+		if err != nil {
+			return "", fmt.Errorf("Bad pattern %q: %w", expression, err)
+		}
+
+		match := pattern.FindSubmatch(source)
+
+		if match == nil {
+			return "", fmt.Errorf("Nothing matches pattern %q", expression)
+		}
+
+		value = string(match[0])
+
+		if len(match) > 1 {
+			value = string(match[1])
+		}
+	}
+
+	value = strings.Join(strings.Fields(value), " ")
+
+	if value == "" {
+		return "", fmt.Errorf("The watched value is empty")
+	}
+
+	if runes := []rune(value); len(runes) > maxWatchedValue {
+		value = string(runes[:maxWatchedValue])
+	}
+
+	return value, nil
+}
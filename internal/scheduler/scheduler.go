@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"container/heap"
+	"github.com/BrandonIrizarry/gator/internal/database"
+	"github.com/google/uuid"
+	"time"
+)
+
+/** A feed waiting in the queue, along with when it's next due. */
+type entry struct {
+	feed database.Feed
+	due  time.Time
+
+	// The entry's position in the heap, maintained by 'feedHeap'.
+	index int
+}
+
+/** A min-heap of entries ordered by due time (see container/heap.) */
+type feedHeap []*entry
+
+func (h feedHeap) Len() int           { return len(h) }
+func (h feedHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+
+func (h feedHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *feedHeap) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *feedHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return e
+}
+
+/*
+  - A priority queue of feeds keyed by when each is next due to be
+    fetched. Each feed appears in the queue at most once.
+*/
+type Queue struct {
+	entries feedHeap
+	byID    map[uuid.UUID]*entry
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		entries: make(feedHeap, 0),
+		byID:    make(map[uuid.UUID]*entry),
+	}
+}
+
+func (q *Queue) Len() int {
+	return len(q.entries)
+}
+
+func (q *Queue) Contains(feedID uuid.UUID) bool {
+	_, ok := q.byID[feedID]
+	return ok
+}
+
+/*
+  - Schedule 'feed' to be fetched at 'due'. If the feed is already
+    queued, it's moved to its new position.
+*/
+func (q *Queue) Schedule(feed database.Feed, due time.Time) {
+	if e, ok := q.byID[feed.ID]; ok {
+		e.feed = feed
+		e.due = due
+		heap.Fix(&q.entries, e.index)
+
+		return
+	}
+
+	e := &entry{feed: feed, due: due}
+	heap.Push(&q.entries, e)
+	q.byID[feed.ID] = e
+}
+
+/** Return the queued copy of the given feed, if it's queued. */
+func (q *Queue) Get(feedID uuid.UUID) (database.Feed, bool) {
+	e, ok := q.byID[feedID]
+
+	if !ok {
+		return database.Feed{}, false
+	}
+
+	return e.feed, true
+}
+
+/** Drop the given feed from the queue, if present. */
+func (q *Queue) Remove(feedID uuid.UUID) {
+	e, ok := q.byID[feedID]
+
+	if !ok {
+		return
+	}
+
+	heap.Remove(&q.entries, e.index)
+	delete(q.byID, feedID)
+}
+
+/*
+  - Return the feed due soonest, along with when it's due, without
+    removing it from the queue. The final return value is false if the
+    queue is empty.
+*/
+func (q *Queue) Peek() (database.Feed, time.Time, bool) {
+	if len(q.entries) == 0 {
+		return database.Feed{}, time.Time{}, false
+	}
+
+	e := q.entries[0]
+
+	return e.feed, e.due, true
+}
+
+/** The IDs of every queued feed, in no particular order. */
+func (q *Queue) IDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(q.byID))
+
+	for id := range q.byID {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
@@ -0,0 +1,281 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/** Turns text into speech, in the given audio format (such as "mp3"). */
+type Speaker interface {
+	Speak(ctx context.Context, text string, voice string, format string, out io.Writer) error
+}
+
+/*
+  - Speaks by running a local program, such as piper. The text is
+    given on standard input. In the command's arguments, "{voice}" and
+    "{format}" stand for the voice and format asked for, and
+    "{output}" for a file to write the audio to; without "{output}",
+    the audio is read from standard output instead. For example:
+
+    ["piper", "--model", "{voice}", "--output_file", "{output}"]
+*/
+type Command struct {
+	Command []string
+}
+
+func (command Command) Speak(ctx context.Context, text string, voice string, format string, out io.Writer) error {
+	if len(command.Command) == 0 {
+		return fmt.Errorf("No text-to-speech command given")
+	}
+
+	var outputPath string
+
+	args := make([]string, len(command.Command)-1)
+
+	for i, arg := range command.Command[1:] {
+		if strings.Contains(arg, "{output}") && outputPath == "" {
+			temp, err := os.CreateTemp("", "gator-tts-*."+format)
+
+			if err != nil {
+				return err
+			}
+
+			temp.Close()
+			defer os.Remove(temp.Name())
+
+			outputPath = temp.Name()
+		}
+
+		args[i] = strings.NewReplacer(
+			"{voice}", voice,
+			"{format}", format,
+			"{output}", outputPath,
+		).Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, command.Command[0], args...)
+	cmd.Stdin = strings.NewReader(text)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if outputPath == "" {
+		cmd.Stdout = out
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Running %q: %w: %s", command.Command[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	if outputPath == "" {
+		return nil
+	}
+
+	audio, err := os.Open(outputPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer audio.Close()
+
+	_, err = io.Copy(out, audio)
+
+	return err
+}
+
+/** The defaults for a speech API which doesn't give its own. */
+const (
+	defaultAPIURL   = "https://api.openai.com/v1/audio/speech"
+	defaultAPIModel = "tts-1"
+	defaultAPIVoice = "alloy"
+	defaultMaxInput = 4096
+)
+
+/*
+  - Speaks through a cloud API compatible with OpenAI's speech
+    endpoint. Such APIs limit how much text one request may carry, so
+    longer texts are spoken a piece at a time (split between
+    sentences where possible), and the pieces' audio joined, which
+    suits streaming formats such as mp3.
+*/
+type API struct {
+	// The speech endpoint; defaults to OpenAI's.
+	URL    string `json:"url,omitempty"`
+	APIKey string `json:"api_key"`
+	Model  string `json:"model,omitempty"`
+
+	// The most characters sent per request; defaults to 4096.
+	MaxInput int `json:"max_input,omitempty"`
+}
+
+var client = &http.Client{
+	Timeout: 2 * time.Minute,
+}
+
+func (api API) Speak(ctx context.Context, text string, voice string, format string, out io.Writer) error {
+	endpoint := api.URL
+
+	if endpoint == "" {
+		endpoint = defaultAPIURL
+	}
+
+	model := api.Model
+
+	if model == "" {
+		model = defaultAPIModel
+	}
+
+	if voice == "" {
+		voice = defaultAPIVoice
+	}
+
+	maxInput := api.MaxInput
+
+	if maxInput <= 0 {
+		maxInput = defaultMaxInput
+	}
+
+	for _, piece := range split(text, maxInput) {
+		if err := api.speakPiece(ctx, endpoint, map[string]string{
+			"model":           model,
+			"input":           piece,
+			"voice":           voice,
+			"response_format": format,
+		}, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/** Send a single request's worth of text, copying the audio to 'out'. */
+func (api API) speakPiece(ctx context.Context, endpoint string, body map[string]string, out io.Writer) error {
+	encoded, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(encoded))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+
+	if api.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+api.APIKey)
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned HTTP status %s: %s", endpoint, resp.Status, bytes.TrimSpace(detail))
+	}
+
+	_, err = io.Copy(out, resp.Body)
+
+	return err
+}
+
+/** Where a text may be split: after a sentence, or failing that, a word. */
+var (
+	sentenceEnd = regexp.MustCompile(`[.!?…]["')\]]*\s+`)
+	wordEnd     = regexp.MustCompile(`\s+`)
+)
+
+/** Split 'text' into pieces of at most 'limit' bytes. */
+func split(text string, limit int) []string {
+	pieces := make([]string, 0)
+
+	for len(text) > limit {
+		cut := lastBoundary(sentenceEnd, text[:limit])
+
+		if cut <= 0 {
+			cut = lastBoundary(wordEnd, text[:limit])
+		}
+
+		// A single word longer than the limit is cut wherever a
+		// character ends.
+		if cut <= 0 {
+			cut = limit
+
+			for cut > 0 && !isRuneStart(text[cut]) {
+				cut--
+			}
+		}
+
+		pieces = append(pieces, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+
+	if text != "" {
+		pieces = append(pieces, text)
+	}
+
+	return pieces
+}
+
+/** The end of the last match of 'pattern' in 'text', or -1. */
+func lastBoundary(pattern *regexp.Regexp, text string) int {
+	matches := pattern.FindAllStringIndex(text, -1)
+
+	if len(matches) == 0 {
+		return -1
+	}
+
+	return matches[len(matches)-1][1]
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+/** Tags after which a pause is due, and tags in general. */
+var (
+	blockPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/blockquote)\b[^>]*>`)
+	tagPattern   = regexp.MustCompile(`<[^>]*>`)
+	spacePattern = regexp.MustCompile(`[ \t\r\f\v]+`)
+	breakPattern = regexp.MustCompile(`\s*\n\s*`)
+
+	// Dropping a tag can leave a space before punctuation, as in
+	// "<b>welcome</b>."
+	punctuationPattern = regexp.MustCompile(` ([.,;:!?])`)
+)
+
+/*
+  - Turn a post's HTML into text fit to be read aloud: tags are
+    dropped (with block-level elements ending in a line break, for a
+    pause), entities decoded, and whitespace collapsed.
+*/
+func PlainText(content string) string {
+	text := blockPattern.ReplaceAllString(content, "\n")
+	text = tagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = spacePattern.ReplaceAllString(text, " ")
+	text = breakPattern.ReplaceAllString(text, "\n")
+	text = punctuationPattern.ReplaceAllString(text, "$1")
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,35 @@
+package web
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+)
+
+/** The pages' templates, and the files served as they are (such as the stylesheet.) */
+//go:embed templates/*.html static/*
+var files embed.FS
+
+var templates = template.Must(template.ParseFS(files, "templates/*.html"))
+
+/*
+  - Render the page with the given name (such as "index.html"), filling
+    it in from 'data'.
+*/
+func Render(w io.Writer, name string, data any) error {
+	return templates.ExecuteTemplate(w, name, data)
+}
+
+/** Serve the static files, named relative to the 'static' directory. */
+func Static() http.Handler {
+	static, err := fs.Sub(files, "static")
+
+	if err != nil {
+		// The directory is embedded, so it's there.
+		panic(err)
+	}
+
+	return http.FileServerFS(static)
+}
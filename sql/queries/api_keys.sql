@@ -0,0 +1,25 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, key_hash, created_at)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5
+)
+RETURNING *;
+
+-- name: GetAPIKeysForUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE user_id = $1 AND name = $2;
+
+-- name: UseAPIKey :one
+UPDATE api_keys
+SET last_used_at = $2
+WHERE key_hash = $1
+RETURNING user_id;
@@ -0,0 +1,61 @@
+-- name: CreateAuthorFollow :one
+INSERT INTO author_follows (id, created_at, user_id, author)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4
+)
+ON CONFLICT (user_id, author) DO NOTHING
+RETURNING *;
+
+-- name: DeleteAuthorFollow :execrows
+DELETE FROM author_follows
+WHERE user_id = $1 AND author = $2;
+
+-- name: GetAuthorFollowsForUser :many
+SELECT author_follows.*,
+       (SELECT COUNT(*) FROM posts WHERE lower(posts.author) = lower(author_follows.author)) AS posts
+FROM author_follows
+WHERE author_follows.user_id = $1
+ORDER BY author_follows.author;
+
+-- name: GetPostsByFollowedAuthors :many
+SELECT posts.*, feeds.name AS feedname,
+       COALESCE(feed_settings.paywalled, false) AS feed_paywalled
+FROM posts
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+INNER JOIN author_follows
+ON lower(author_follows.author) = lower(posts.author)
+LEFT JOIN feed_settings
+ON feed_settings.feed_id = posts.feed_id
+WHERE author_follows.user_id = @user_id
+  AND (NOT @unread_only::boolean OR NOT EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+        AND (sqlc.narg('as_of')::timestamp IS NULL OR post_reads.read_at < sqlc.narg('as_of'))
+  ))
+  AND (sqlc.narg('as_of')::timestamp IS NULL OR posts.published_at < sqlc.narg('as_of'))
+  AND (NOT @hide_paywalled::boolean OR NOT (posts.paywalled OR COALESCE(feed_settings.paywalled, false)))
+  AND (sqlc.narg('folder_id')::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM feed_follows
+      WHERE feed_follows.user_id = @user_id AND feed_follows.feed_id = posts.feed_id
+        AND feed_follows.folder_id = sqlc.narg('folder_id')
+  ))
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.user_id = @user_id AND post_tags.post_id = posts.id AND post_tags.tag = sqlc.narg('tag')
+  ))
+  AND (sqlc.narg('max_reading_seconds')::int IS NULL OR posts.reading_seconds <= sqlc.narg('max_reading_seconds'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR
+       CASE WHEN @order_by::text = 'added' THEN posts.created_at ELSE posts.published_at END >= sqlc.narg('since'))
+  AND (NOT @dedupe::boolean OR posts.dedupe_key = '' OR NOT EXISTS (
+      SELECT 1 FROM posts AS earlier
+      WHERE earlier.dedupe_key = posts.dedupe_key
+        AND lower(earlier.author) = lower(posts.author)
+        AND (earlier.published_at, earlier.id) < (posts.published_at, posts.id)
+  ))
+ORDER BY CASE WHEN @order_by::text = 'added' THEN posts.created_at ELSE posts.published_at END DESC, posts.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
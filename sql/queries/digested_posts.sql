@@ -0,0 +1,29 @@
+-- name: GetPostsForDigest :many
+SELECT posts.*, feeds.name AS feedname, feed_tag.tag FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+LEFT JOIN LATERAL (
+      SELECT MIN(feed_tags.tag) AS tag FROM feed_tags
+      WHERE feed_tags.feed_id = posts.feed_id
+) AS feed_tag ON true
+WHERE feed_follows.user_id = @user_id AND posts.published_at >= @published_at
+  AND (@include_previous::boolean OR NOT EXISTS (
+      SELECT 1 FROM digested_posts
+      WHERE digested_posts.user_id = @user_id AND digested_posts.post_id = posts.id
+  ))
+ORDER BY feed_tag.tag NULLS LAST, feeds.name, posts.published_at DESC;
+
+-- name: MarkPostsDigested :exec
+INSERT INTO digested_posts (user_id, post_id, digested_at)
+SELECT @user_id, post_id, @digested_at
+FROM unnest(@post_ids::uuid[]) AS post_id
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetDigestedPostsForUser :many
+SELECT posts.url, digested_posts.digested_at FROM digested_posts
+INNER JOIN posts
+ON posts.id = digested_posts.post_id
+WHERE digested_posts.user_id = $1
+ORDER BY digested_posts.digested_at, posts.url;
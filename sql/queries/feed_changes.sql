@@ -0,0 +1,40 @@
+-- name: GetFeedChannel :one
+SELECT * FROM feed_channels
+WHERE feed_id = $1;
+
+-- name: SetFeedChannel :exec
+INSERT INTO feed_channels (feed_id, title, description, self_url, updated_at)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    self_url = EXCLUDED.self_url,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: CreateFeedChange :exec
+INSERT INTO feed_changes (id, feed_id, changed_at, field, old_value, new_value)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5,
+       $6
+);
+
+-- name: GetFeedChangesForUser :many
+SELECT feed_changes.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM feed_changes
+INNER JOIN feeds
+ON feeds.id = feed_changes.feed_id
+INNER JOIN feed_follows
+ON feed_follows.feed_id = feed_changes.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('since')::timestamp IS NULL OR feed_changes.changed_at >= sqlc.narg('since'))
+ORDER BY feed_changes.changed_at DESC;
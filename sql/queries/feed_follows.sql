@@ -8,6 +8,7 @@ WITH inserted_feed_follow AS (
             $4,
             $5
      )
+     ON CONFLICT (user_id, feed_id) DO NOTHING
 
      RETURNING *
 )
@@ -19,21 +20,25 @@ INNER JOIN users
 ON users.id = inserted_feed_follow.user_id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, feeds.name AS feedname
+SELECT feed_follows.*, feeds.name AS feedname, feeds.url AS feedurl,
+       COALESCE(unread_counts.unread, 0)::bigint AS unread,
+       (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feed_follows.feed_id)::timestamp AS last_post_at,
+       folders.name AS folder,
+       COALESCE((SELECT array_agg(feed_tags.tag ORDER BY feed_tags.tag) FROM feed_tags WHERE feed_tags.feed_id = feed_follows.feed_id), '{}')::text[] AS tags
 FROM feed_follows
 INNER JOIN feeds
 ON feeds.id = feed_follows.feed_id
 INNER JOIN users
 ON users.id = feed_follows.user_id
-WHERE users.id = $1;
+LEFT JOIN unread_counts
+ON unread_counts.user_id = feed_follows.user_id AND unread_counts.feed_id = feed_follows.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+WHERE users.id = $1
+ORDER BY feeds.name;
 
 -- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows USING feeds
-WHERE feed_follows.user_id = $1 AND feeds.url = $2;
-
--- name: GetNextFeedToFetch :many
-SELECT * FROM feed_follows
-INNER JOIN feeds
-ON feeds.id = feed_follows.feed_id
-ORDER BY feeds.last_fetched_at NULLS FIRST;
+WHERE feeds.id = feed_follows.feed_id
+  AND feed_follows.user_id = $1 AND feeds.url = $2;
 
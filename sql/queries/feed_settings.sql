@@ -0,0 +1,64 @@
+-- name: GetFeedFetchIntervals :many
+SELECT feed_id, fetch_interval_seconds FROM feed_settings
+WHERE fetch_interval_seconds IS NOT NULL;
+
+-- name: GetFeedSettings :one
+SELECT * FROM feed_settings
+WHERE feed_id = $1;
+
+-- name: SetFeedCategoryTags :exec
+INSERT INTO feed_settings (feed_id, updated_at, category_tags)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    category_tags = EXCLUDED.category_tags;
+
+-- name: SetFeedFetchInterval :exec
+INSERT INTO feed_settings (feed_id, updated_at, fetch_interval_seconds)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    fetch_interval_seconds = EXCLUDED.fetch_interval_seconds;
+
+-- name: SetFeedItemLimit :exec
+INSERT INTO feed_settings (feed_id, updated_at, max_items_per_fetch, overflow_policy)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    max_items_per_fetch = EXCLUDED.max_items_per_fetch,
+    overflow_policy = EXCLUDED.overflow_policy;
+
+-- name: SetFeedPaused :exec
+INSERT INTO feed_settings (feed_id, updated_at, paused_at)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    paused_at = EXCLUDED.paused_at;
+
+-- name: SetFeedPaywalled :exec
+INSERT INTO feed_settings (feed_id, updated_at, paywalled)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    paywalled = EXCLUDED.paywalled;
@@ -0,0 +1,23 @@
+-- name: CreateFeedSnapshot :exec
+INSERT INTO feed_snapshots (id, feed_id, fetched_at, items)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4
+);
+
+-- name: PruneFeedSnapshots :exec
+DELETE FROM feed_snapshots
+WHERE feed_snapshots.feed_id = $1 AND feed_snapshots.id NOT IN (
+      SELECT kept.id FROM feed_snapshots AS kept
+      WHERE kept.feed_id = $1
+      ORDER BY kept.fetched_at DESC
+      LIMIT 2
+);
+
+-- name: GetLatestFeedSnapshots :many
+SELECT * FROM feed_snapshots
+WHERE feed_id = $1
+ORDER BY fetched_at DESC
+LIMIT 2;
@@ -0,0 +1,7 @@
+-- name: AddFeedTag :exec
+INSERT INTO feed_tags (feed_id, tag)
+VALUES (
+       $1,
+       $2
+)
+ON CONFLICT (feed_id, tag) DO NOTHING;
@@ -23,3 +23,90 @@ UPDATE feeds
 SET last_fetched_at = CURRENT_TIMESTAMP,
     updated_at = CURRENT_TIMESTAMP
 WHERE feeds.id = $1;
+
+-- name: RecordFeedError :one
+UPDATE feeds
+SET last_error = $2,
+    failure_count = CASE WHEN $2 IS NULL THEN 0 ELSE failure_count + 1 END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id = $1
+RETURNING failure_count;
+
+-- name: ResetFeedFailures :exec
+UPDATE feeds
+SET failure_count = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id = $1;
+
+-- name: GetFollowedFeeds :many
+SELECT * FROM feeds
+WHERE EXISTS (
+      SELECT 1 FROM feed_follows
+      WHERE feed_follows.feed_id = feeds.id
+)
+AND NOT EXISTS (
+      SELECT 1 FROM feed_settings
+      WHERE feed_settings.feed_id = feeds.id AND feed_settings.paused_at IS NOT NULL
+)
+ORDER BY feeds.last_fetched_at NULLS FIRST;
+
+-- name: GetNextNFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.id IN (
+      SELECT due.id FROM feeds AS due
+      LEFT JOIN feed_settings
+      ON feed_settings.feed_id = due.id
+      WHERE EXISTS (
+            SELECT 1 FROM feed_follows
+            WHERE feed_follows.feed_id = due.id
+      )
+        AND feed_settings.paused_at IS NULL
+        AND due.failure_count < @max_failures::int
+        AND (due.last_fetched_at IS NULL OR due.last_fetched_at <= CURRENT_TIMESTAMP - interval '1 second' * GREATEST(
+            COALESCE(feed_settings.fetch_interval_seconds, @interval_seconds::int),
+            LEAST(COALESCE(feed_settings.fetch_interval_seconds, @interval_seconds::int) * power(2, LEAST(due.failure_count, 20)), 86400)
+        ))
+      ORDER BY due.last_fetched_at NULLS FIRST
+      LIMIT sqlc.arg('limit')
+      FOR UPDATE OF due SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds
+WHERE id = $1;
+
+-- name: ListFeeds :many
+SELECT feeds.*, users.name AS username, COUNT(feed_follows.id) AS followers,
+       (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feeds.id)::timestamp AS last_post_at,
+       COALESCE((SELECT array_agg(feed_tags.tag ORDER BY feed_tags.tag) FROM feed_tags WHERE feed_tags.feed_id = feeds.id), '{}')::text[] AS tags,
+       feed_settings.paused_at
+FROM feeds
+LEFT JOIN users
+ON users.id = feeds.user_id
+LEFT JOIN feed_follows
+ON feed_follows.feed_id = feeds.id
+LEFT JOIN feed_settings
+ON feed_settings.feed_id = feeds.id
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR feeds.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+      SELECT 1 FROM feed_tags
+      WHERE feed_tags.feed_id = feeds.id AND feed_tags.tag = sqlc.narg('tag')
+  ))
+  AND (NOT @dead_only::boolean OR feeds.last_error IS NOT NULL)
+  AND (NOT @paused_only::boolean OR feed_settings.paused_at IS NOT NULL)
+  AND (NOT @broken_only::boolean OR feeds.failure_count >= @max_failures::int)
+GROUP BY feeds.id, users.name, feed_settings.paused_at
+ORDER BY
+  CASE WHEN @sort_by::text = 'followers' THEN COUNT(feed_follows.id) END DESC,
+  CASE WHEN @sort_by::text = 'activity' THEN (SELECT MAX(posts.published_at) FROM posts WHERE posts.feed_id = feeds.id) END DESC NULLS LAST,
+  CASE WHEN @sort_by::text = 'added' THEN feeds.created_at END DESC,
+  feeds.name;
+
+-- name: SetFeedValidators :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3
+WHERE feeds.id = $1;
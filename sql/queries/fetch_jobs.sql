@@ -0,0 +1,28 @@
+-- name: EnqueueFetchJob :exec
+INSERT INTO fetch_jobs (id, created_at, updated_at, feed_id)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4
+)
+ON CONFLICT (feed_id) DO NOTHING;
+
+-- name: ClaimFetchJob :one
+UPDATE fetch_jobs
+SET claimed_by = $1,
+    visible_at = $2,
+    attempts = attempts + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+      SELECT id FROM fetch_jobs
+      WHERE fetch_jobs.visible_at <= CURRENT_TIMESTAMP
+      ORDER BY fetch_jobs.visible_at
+      LIMIT 1
+      FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteFetchJob :exec
+DELETE FROM fetch_jobs
+WHERE id = $1;
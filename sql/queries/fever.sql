@@ -0,0 +1,73 @@
+-- name: GetFeverFeeds :many
+SELECT feeds.seq, feeds.name, feeds.url, feeds.last_fetched_at,
+       folders.seq AS folder_seq
+FROM feed_follows
+INNER JOIN feeds
+ON feeds.id = feed_follows.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.name;
+
+-- name: GetFeverItems :many
+SELECT posts.seq, feeds.seq AS feed_seq, posts.title, posts.author,
+       posts.description, posts.url, posts.published_at,
+       EXISTS (
+           SELECT 1 FROM post_reads
+           WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+       ) AS is_read,
+       EXISTS (
+           SELECT 1 FROM starred_posts
+           WHERE starred_posts.user_id = @user_id AND starred_posts.post_id = posts.id
+       ) AS is_saved
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('since_seq')::bigint IS NULL OR posts.seq > sqlc.narg('since_seq'))
+  AND (sqlc.narg('max_seq')::bigint IS NULL OR posts.seq < sqlc.narg('max_seq'))
+  AND (sqlc.narg('seqs')::bigint[] IS NULL OR posts.seq = ANY(sqlc.narg('seqs')::bigint[]))
+ORDER BY CASE WHEN sqlc.narg('max_seq')::bigint IS NULL THEN posts.seq ELSE -posts.seq END
+LIMIT sqlc.arg('limit');
+
+-- name: CountFeverItems :one
+SELECT COUNT(*) FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1;
+
+-- name: GetUnreadPostSeqs :many
+SELECT posts.seq FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+  AND NOT EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = $1 AND post_reads.post_id = posts.id
+  )
+ORDER BY posts.seq;
+
+-- name: GetStarredPostSeqs :many
+SELECT posts.seq FROM starred_posts
+INNER JOIN posts
+ON posts.id = starred_posts.post_id
+WHERE starred_posts.user_id = $1
+ORDER BY posts.seq;
+
+-- name: MarkPostsReadBefore :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, @read_at::timestamp
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = @user_id
+  AND posts.created_at <= @before
+  AND (sqlc.narg('feed_seq')::bigint IS NULL OR feeds.seq = sqlc.narg('feed_seq'))
+  AND (sqlc.narg('folder_seq')::bigint IS NULL OR folders.seq = sqlc.narg('folder_seq'))
+ON CONFLICT (user_id, post_id) DO NOTHING;
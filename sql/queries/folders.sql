@@ -0,0 +1,31 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5
+)
+RETURNING *;
+
+-- name: GetFolder :one
+SELECT * FROM folders
+WHERE user_id = $1 AND name = $2;
+
+-- name: ListFolders :many
+SELECT folders.*, COUNT(feed_follows.id) AS feeds
+FROM folders
+LEFT JOIN feed_follows
+ON feed_follows.folder_id = folders.id
+WHERE folders.user_id = $1
+GROUP BY folders.id
+ORDER BY folders.name;
+
+-- name: AssignFeedToFolder :execrows
+UPDATE feed_follows
+SET folder_id = $3,
+    updated_at = CURRENT_TIMESTAMP
+FROM feeds
+WHERE feeds.id = feed_follows.feed_id
+  AND feed_follows.user_id = $1 AND feeds.url = $2;
@@ -0,0 +1,80 @@
+-- name: CountOrphanedFeedFollows :one
+SELECT COUNT(*) FROM feed_follows
+WHERE NOT EXISTS (SELECT 1 FROM users WHERE users.id = feed_follows.user_id)
+   OR NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = feed_follows.feed_id);
+
+-- name: DeleteOrphanedFeedFollows :execrows
+DELETE FROM feed_follows
+WHERE NOT EXISTS (SELECT 1 FROM users WHERE users.id = feed_follows.user_id)
+   OR NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = feed_follows.feed_id);
+
+-- name: CountOrphanedPosts :one
+SELECT COUNT(*) FROM posts
+WHERE NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = posts.feed_id);
+
+-- name: DeleteOrphanedPosts :execrows
+DELETE FROM posts
+WHERE NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = posts.feed_id);
+
+-- name: CountOrphanedFetchJobs :one
+SELECT COUNT(*) FROM fetch_jobs
+WHERE NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = fetch_jobs.feed_id);
+
+-- name: DeleteOrphanedFetchJobs :execrows
+DELETE FROM fetch_jobs
+WHERE NOT EXISTS (SELECT 1 FROM feeds WHERE feeds.id = fetch_jobs.feed_id);
+
+-- name: CountFeedsWithMissingUser :one
+SELECT COUNT(*) FROM feeds
+WHERE feeds.user_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM users WHERE users.id = feeds.user_id);
+
+-- name: ClearFeedsWithMissingUser :execrows
+UPDATE feeds
+SET user_id = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feeds.user_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM users WHERE users.id = feeds.user_id);
+
+-- name: CountOrphanedPostReads :one
+SELECT COUNT(*) FROM post_reads
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_reads.post_id);
+
+-- name: DeleteOrphanedPostReads :execrows
+DELETE FROM post_reads
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_reads.post_id);
+
+-- name: CountOrphanedPostTags :one
+SELECT COUNT(*) FROM post_tags
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_tags.post_id);
+
+-- name: DeleteOrphanedPostTags :execrows
+DELETE FROM post_tags
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_tags.post_id);
+
+-- name: CountOrphanedPostOpens :one
+SELECT COUNT(*) FROM post_opens
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_opens.post_id);
+
+-- name: DeleteOrphanedPostOpens :execrows
+DELETE FROM post_opens
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_opens.post_id);
+
+-- name: CountOrphanedDigestedPosts :one
+SELECT COUNT(*) FROM digested_posts
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = digested_posts.post_id);
+
+-- name: DeleteOrphanedDigestedPosts :execrows
+DELETE FROM digested_posts
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = digested_posts.post_id);
+
+-- name: CountStarsWithMissingPost :one
+SELECT COUNT(*) FROM starred_posts
+WHERE starred_posts.post_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = starred_posts.post_id);
+
+-- name: ClearStarsWithMissingPost :execrows
+UPDATE starred_posts
+SET post_id = NULL
+WHERE starred_posts.post_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = starred_posts.post_id);
@@ -0,0 +1,42 @@
+-- name: GetReaderItems :many
+SELECT posts.seq, posts.title, posts.url, posts.canonical_url, posts.description,
+       posts.author, posts.published_at, posts.created_at,
+       feeds.url AS feed_url, feeds.name AS feed_name, folders.name AS folder,
+       EXISTS (
+           SELECT 1 FROM post_reads
+           WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+       ) AS is_read,
+       EXISTS (
+           SELECT 1 FROM starred_posts
+           WHERE starred_posts.user_id = @user_id AND starred_posts.post_id = posts.id
+       ) AS is_starred
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+LEFT JOIN folders
+ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url'))
+  AND (sqlc.narg('folder')::text IS NULL OR folders.name = sqlc.narg('folder'))
+  AND (sqlc.narg('seqs')::bigint[] IS NULL OR posts.seq = ANY(sqlc.narg('seqs')::bigint[]))
+  AND (NOT @starred_only::boolean OR EXISTS (
+      SELECT 1 FROM starred_posts
+      WHERE starred_posts.user_id = @user_id AND starred_posts.post_id = posts.id
+  ))
+  AND (NOT @read_only::boolean OR EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+  ))
+  AND (NOT @unread_only::boolean OR NOT EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+  ))
+  AND (sqlc.narg('newer_than')::timestamp IS NULL OR posts.created_at >= sqlc.narg('newer_than'))
+  AND (sqlc.narg('older_than')::timestamp IS NULL OR posts.created_at < sqlc.narg('older_than'))
+  AND (sqlc.narg('continuation')::bigint IS NULL OR
+       CASE WHEN @oldest_first::boolean THEN posts.seq > sqlc.narg('continuation')
+            ELSE posts.seq < sqlc.narg('continuation') END)
+ORDER BY CASE WHEN @oldest_first::boolean THEN posts.seq ELSE -posts.seq END
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,34 @@
+-- name: MoveFeedFollows :execrows
+UPDATE feed_follows
+SET feed_id = @dst_feed_id, updated_at = CURRENT_TIMESTAMP
+WHERE feed_follows.feed_id = @src_feed_id
+  AND NOT EXISTS (
+      SELECT 1 FROM feed_follows AS existing
+      WHERE existing.user_id = feed_follows.user_id
+        AND existing.feed_id = @dst_feed_id
+);
+
+-- name: DeleteDuplicatePosts :execrows
+DELETE FROM posts
+WHERE posts.feed_id = @src_feed_id
+  AND EXISTS (
+      SELECT 1 FROM posts AS kept
+      WHERE kept.feed_id = @dst_feed_id
+        AND (regexp_replace(kept.url, '^https?://', '') = regexp_replace(posts.url, '^https?://', '')
+             OR (kept.title = posts.title AND kept.published_at = posts.published_at))
+);
+
+-- name: MovePosts :execrows
+UPDATE posts
+SET feed_id = @dst_feed_id, updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = @src_feed_id;
+
+-- name: CopyFeedTags :exec
+INSERT INTO feed_tags (feed_id, tag)
+SELECT @dst_feed_id, tag FROM feed_tags
+WHERE feed_tags.feed_id = @src_feed_id
+ON CONFLICT (feed_id, tag) DO NOTHING;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
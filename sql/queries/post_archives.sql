@@ -0,0 +1,20 @@
+-- name: SavePostArchive :one
+INSERT INTO post_archives (url, snapshot_url, archived_at)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (url) DO UPDATE
+SET snapshot_url = EXCLUDED.snapshot_url,
+    archived_at = EXCLUDED.archived_at
+RETURNING *;
+
+-- name: GetPostArchivesForUser :many
+SELECT post_archives.*, posts.title FROM post_archives
+INNER JOIN posts
+ON posts.url = post_archives.url
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY post_archives.archived_at DESC;
@@ -0,0 +1,19 @@
+-- name: RecordPostOpen :exec
+INSERT INTO post_opens (id, post_id, feed_id, opened_at, via)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5
+);
+
+-- name: GetFeedEngagementForUser :many
+SELECT feeds.name, feeds.url,
+       (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS posts,
+       (SELECT COUNT(DISTINCT post_opens.post_id) FROM post_opens WHERE post_opens.feed_id = feeds.id) AS opened
+FROM feeds
+INNER JOIN feed_follows
+ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.name;
@@ -0,0 +1,26 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (
+       $1,
+       $2,
+       $3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkPostReadForFollowers :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, $1, $2
+FROM feed_follows
+WHERE feed_follows.feed_id = $3
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetReadPostsForUser :many
+SELECT posts.url, post_reads.read_at FROM post_reads
+INNER JOIN posts
+ON posts.id = post_reads.post_id
+WHERE post_reads.user_id = $1
+ORDER BY post_reads.read_at;
@@ -0,0 +1,32 @@
+-- name: AddPostTag :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4
+)
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: AddPostTagsForFollowers :exec
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT feed_follows.user_id, @post_id, tag, @created_at
+FROM feed_follows, unnest(@tags::text[]) AS tag
+WHERE feed_follows.feed_id = @feed_id
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: RemovePostTag :execrows
+DELETE FROM post_tags
+WHERE user_id = $1 AND post_id = $2 AND tag = $3;
+
+-- name: GetPostTags :many
+SELECT tag FROM post_tags
+WHERE user_id = $1 AND post_id = $2
+ORDER BY tag;
+
+-- name: GetTaggedPostsForUser :many
+SELECT posts.url, post_tags.tag, post_tags.created_at FROM post_tags
+INNER JOIN posts
+ON posts.id = post_tags.post_id
+WHERE post_tags.user_id = $1
+ORDER BY post_tags.created_at, posts.url, post_tags.tag;
@@ -1,21 +1,127 @@
--- name: CreatePost :one
-INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-)
+-- name: CreatePosts :many
+INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id, author, paywalled, reading_seconds, dedupe_key)
+SELECT item.id, @created_at, @created_at, item.title, item.url, item.description,
+       item.published_at, @feed_id, item.author, item.paywalled, item.reading_seconds, item.dedupe_key
+FROM unnest(@ids::uuid[], @titles::text[], @urls::text[], @descriptions::text[],
+            @published_ats::timestamp[], @authors::text[], @paywalled::boolean[],
+            @reading_seconds::int[], @dedupe_keys::text[])
+     AS item(id, title, url, description, published_at, author, paywalled, reading_seconds, dedupe_key)
+WHERE NOT EXISTS (SELECT 1 FROM posts WHERE posts.url = item.url)
+ON CONFLICT DO NOTHING
 RETURNING *;
 
+-- name: LockPostURLs :exec
+SELECT pg_advisory_xact_lock(hashtext('posts'), locked.key)
+FROM (SELECT DISTINCT hashtext(url) AS key FROM unnest(@urls::text[]) AS url ORDER BY key) AS locked;
+
 -- name: GetPostsForUser :many
+SELECT posts.*, feeds.name AS feedname,
+       COALESCE(feed_settings.paywalled, false) AS feed_paywalled
+FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+LEFT JOIN feed_settings
+ON feed_settings.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (NOT @unread_only::boolean OR NOT EXISTS (
+      SELECT 1 FROM post_reads
+      WHERE post_reads.user_id = @user_id AND post_reads.post_id = posts.id
+        AND (sqlc.narg('as_of')::timestamp IS NULL OR post_reads.read_at < sqlc.narg('as_of'))
+  ))
+  AND (sqlc.narg('as_of')::timestamp IS NULL OR posts.published_at < sqlc.narg('as_of'))
+  AND (NOT @hide_paywalled::boolean OR NOT (posts.paywalled OR COALESCE(feed_settings.paywalled, false)))
+  AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id = sqlc.narg('folder_id'))
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+      SELECT 1 FROM post_tags
+      WHERE post_tags.user_id = @user_id AND post_tags.post_id = posts.id AND post_tags.tag = sqlc.narg('tag')
+  ))
+  AND (sqlc.narg('max_reading_seconds')::int IS NULL OR posts.reading_seconds <= sqlc.narg('max_reading_seconds'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR
+       CASE WHEN @order_by::text = 'added' THEN posts.created_at ELSE posts.published_at END >= sqlc.narg('since'))
+  AND (NOT @dedupe::boolean OR posts.dedupe_key = '' OR NOT EXISTS (
+      SELECT 1 FROM posts AS earlier
+      INNER JOIN feed_follows AS earlier_follows
+      ON earlier_follows.feed_id = earlier.feed_id AND earlier_follows.user_id = @user_id
+      WHERE earlier.dedupe_key = posts.dedupe_key
+        AND (earlier.published_at, earlier.id) < (posts.published_at, posts.id)
+  ))
+ORDER BY CASE WHEN @order_by::text = 'added' THEN posts.created_at ELSE posts.published_at END DESC, posts.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetPostsForUserSince :many
+SELECT posts.*, feeds.name AS feedname FROM posts
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $1 AND posts.published_at >= $2
+ORDER BY feeds.name, posts.published_at DESC;
+
+-- name: GetPostsForFeed :many
 SELECT * FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC;
+
+-- name: GetPostBySeq :one
+SELECT * FROM posts
+WHERE seq = $1
+LIMIT 1;
+
+-- name: GetPostByURL :one
+SELECT * FROM posts
+WHERE url = $1
+LIMIT 1;
+
+-- name: GetPostByID :one
+SELECT * FROM posts
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetRelatedPosts :many
+WITH source AS (
+     SELECT posts.id, string_agg(quote_literal(lexeme), ' | ')::tsquery AS query
+     FROM posts, unnest(tsvector_to_array(to_tsvector('english', posts.title))) AS lexeme
+     WHERE posts.id = $1
+     GROUP BY posts.id
+)
+SELECT posts.*, feeds.name AS feedname,
+       ts_rank(to_tsvector('english', posts.title || ' ' || posts.description), source.query)::real AS rank
+FROM source, posts
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
 INNER JOIN feed_follows
 ON feed_follows.feed_id = posts.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
+WHERE feed_follows.user_id = $2
+  AND posts.id <> source.id
+  AND to_tsvector('english', posts.title || ' ' || posts.description) @@ source.query
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $3;
+
+-- name: SearchPosts :many
+SELECT posts.*, feeds.name AS feedname,
+       ts_rank(to_tsvector('english', posts.title || ' ' || posts.description), websearch_to_tsquery('english', @query))::real AS rank
+FROM posts
+INNER JOIN feeds
+ON feeds.id = posts.feed_id
+INNER JOIN feed_follows
+ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND to_tsvector('english', posts.title || ' ' || posts.description) @@ websearch_to_tsquery('english', @query)
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SetPostDescription :exec
+UPDATE posts
+SET description = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: SetPostCanonicalURL :exec
+UPDATE posts
+SET canonical_url = $2,
+    dedupe_key = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
@@ -0,0 +1,11 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (token_hash, user_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: GetSessionUserID :one
+SELECT user_id FROM sessions
+WHERE token_hash = $1;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
@@ -0,0 +1,23 @@
+-- name: StarPost :execrows
+INSERT INTO starred_posts (user_id, post_id, url, title, description, author, feed_name, published_at, starred_at)
+VALUES (
+       $1,
+       $2,
+       $3,
+       $4,
+       $5,
+       $6,
+       $7,
+       $8,
+       $9
+)
+ON CONFLICT (user_id, url) DO NOTHING;
+
+-- name: UnstarPost :execrows
+DELETE FROM starred_posts
+WHERE user_id = @user_id AND (post_id::text = @id_or_url::text OR url = @id_or_url::text);
+
+-- name: GetStarredPosts :many
+SELECT * FROM starred_posts
+WHERE user_id = $1
+ORDER BY starred_at DESC;
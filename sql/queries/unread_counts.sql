@@ -0,0 +1,6 @@
+-- name: RefreshUnreadCounts :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY unread_counts;
+
+-- name: GetUnreadCountsForUser :many
+SELECT * FROM unread_counts
+WHERE user_id = $1;
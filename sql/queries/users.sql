@@ -1,11 +1,13 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
+ON CONFLICT (name) DO NOTHING
 
 RETURNING *;
 
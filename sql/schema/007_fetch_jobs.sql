@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE fetch_jobs(
+       id UUID PRIMARY KEY,
+       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+       updated_at TIMESTAMP NOT NULL,
+       feed_id UUID UNIQUE NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+       claimed_by TEXT, -- the worker currently holding the job, if any
+       visible_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+       attempts INTEGER NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE fetch_jobs;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A feed outlives the user who added it, since others may follow it.
+ALTER TABLE feeds
+ALTER COLUMN user_id DROP NOT NULL,
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM feeds WHERE user_id IS NULL;
+
+ALTER TABLE feeds
+ALTER COLUMN user_id SET NOT NULL,
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Note that posts(url) and feed_follows(user_id) need no index of
+-- their own: the former is covered by its UNIQUE constraint, and the
+-- latter by the leading column of UNIQUE(user_id, feed_id).
+
+-- For 'browse', which fetches the newest posts of the followed feeds.
+CREATE INDEX posts_feed_id_published_at_idx ON posts (feed_id, published_at DESC);
+
+-- For finding the followers of a given feed.
+CREATE INDEX feed_follows_feed_id_idx ON feed_follows (feed_id);
+
+-- For ordering feeds by staleness when scheduling fetches.
+CREATE INDEX feeds_last_fetched_at_idx ON feeds (last_fetched_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX feeds_last_fetched_at_idx;
+DROP INDEX feed_follows_feed_id_idx;
+DROP INDEX posts_feed_id_published_at_idx;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Per-(user, feed) unread counts, refreshed by 'agg' after each round
+-- of scraping, so that listing them doesn't require a COUNT(*) join
+-- over the whole posts table.
+--
+-- There's no read state yet, so every post counts as unread.
+CREATE MATERIALIZED VIEW unread_counts AS
+SELECT feed_follows.user_id, feed_follows.feed_id, COUNT(posts.id) AS unread
+FROM feed_follows
+LEFT JOIN posts
+ON posts.feed_id = feed_follows.feed_id
+GROUP BY feed_follows.user_id, feed_follows.feed_id;
+
+-- Required for refreshing the view concurrently.
+CREATE UNIQUE INDEX unread_counts_user_id_feed_id_idx ON unread_counts (user_id, feed_id);
+
+-- +goose Down
+DROP MATERIALIZED VIEW unread_counts;
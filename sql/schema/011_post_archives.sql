@@ -0,0 +1,12 @@
+-- +goose Up
+-- Wayback Machine snapshots of posts. These are keyed by the post's
+-- URL rather than its ID, since nothing may reference posts by ID
+-- once they're partitioned, and so that a snapshot outlives its post.
+CREATE TABLE post_archives(
+       url TEXT PRIMARY KEY,
+       snapshot_url TEXT NOT NULL,
+       archived_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE post_archives;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- The items seen in each feed's most recent fetches, so that 'diff'
+-- can show how a feed changed between them. Only the last two
+-- snapshots of each feed are kept.
+CREATE TABLE feed_snapshots(
+       id UUID PRIMARY KEY,
+       feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+       fetched_at TIMESTAMP NOT NULL,
+       items JSONB NOT NULL
+);
+
+CREATE INDEX feed_snapshots_feed_id_fetched_at_idx ON feed_snapshots (feed_id, fetched_at DESC);
+
+-- +goose Down
+DROP TABLE feed_snapshots;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Posts' authors, where their feeds give one, so that users can
+-- follow a writer across every feed they publish in.
+ALTER TABLE posts ADD COLUMN author TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX posts_lower_author_idx ON posts (lower(author));
+
+CREATE TABLE author_follows(
+       id UUID PRIMARY KEY,
+       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       author TEXT NOT NULL,
+       UNIQUE (user_id, author)
+);
+
+-- +goose Down
+DROP TABLE author_follows;
+DROP INDEX posts_lower_author_idx;
+ALTER TABLE posts DROP COLUMN author;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Per-feed overrides of how 'agg' fetches a feed. Feeds without a row
+-- here use the global defaults.
+CREATE TABLE feed_settings(
+       feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+       updated_at TIMESTAMP NOT NULL,
+       -- The most items saved per fetch, or NULL for no cap.
+       max_items_per_fetch INTEGER CHECK (max_items_per_fetch > 0),
+       -- What happens to the items beyond the cap.
+       overflow_policy TEXT NOT NULL DEFAULT 'drop-oldest' CHECK (overflow_policy IN ('drop-oldest'))
+);
+
+-- +goose Down
+DROP TABLE feed_settings;
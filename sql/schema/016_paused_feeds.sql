@@ -0,0 +1,7 @@
+-- +goose Up
+-- When the feed was paused, or NULL if it isn't. 'agg' skips paused
+-- feeds.
+ALTER TABLE feed_settings ADD COLUMN paused_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feed_settings DROP COLUMN paused_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Each time a post was opened through gator (for example, by way of a
+-- short link.) Posts aren't referenced by ID, since they may be
+-- partitioned; the feed is kept so that opens can be tallied per feed.
+CREATE TABLE post_opens(
+       id UUID PRIMARY KEY,
+       post_id UUID NOT NULL,
+       feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+       opened_at TIMESTAMP NOT NULL,
+       via TEXT NOT NULL
+);
+
+CREATE INDEX post_opens_feed_id_idx ON post_opens (feed_id);
+
+-- +goose Down
+DROP TABLE post_opens;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- Which posts each user has read. As with post_opens, posts aren't
+-- referenced by foreign key, since they may be partitioned.
+CREATE TABLE post_reads(
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       post_id UUID NOT NULL,
+       read_at TIMESTAMP NOT NULL,
+       PRIMARY KEY (user_id, post_id)
+);
+
+-- Now that there's read state, unread counts leave out what's been
+-- read.
+DROP MATERIALIZED VIEW unread_counts;
+
+CREATE MATERIALIZED VIEW unread_counts AS
+SELECT feed_follows.user_id, feed_follows.feed_id,
+       COUNT(posts.id) FILTER (WHERE post_reads.post_id IS NULL) AS unread
+FROM feed_follows
+LEFT JOIN posts
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_reads
+ON post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+GROUP BY feed_follows.user_id, feed_follows.feed_id;
+
+CREATE UNIQUE INDEX unread_counts_user_id_feed_id_idx ON unread_counts (user_id, feed_id);
+
+-- Items beyond a feed's cap can now be kept, but marked as read.
+ALTER TABLE feed_settings DROP CONSTRAINT feed_settings_overflow_policy_check;
+ALTER TABLE feed_settings ADD CONSTRAINT feed_settings_overflow_policy_check
+      CHECK (overflow_policy IN ('drop-oldest', 'mark-read'));
+
+-- +goose Down
+UPDATE feed_settings SET overflow_policy = 'drop-oldest' WHERE overflow_policy = 'mark-read';
+
+ALTER TABLE feed_settings DROP CONSTRAINT feed_settings_overflow_policy_check;
+ALTER TABLE feed_settings ADD CONSTRAINT feed_settings_overflow_policy_check
+      CHECK (overflow_policy IN ('drop-oldest'));
+
+DROP MATERIALIZED VIEW unread_counts;
+
+CREATE MATERIALIZED VIEW unread_counts AS
+SELECT feed_follows.user_id, feed_follows.feed_id, COUNT(posts.id) AS unread
+FROM feed_follows
+LEFT JOIN posts
+ON posts.feed_id = feed_follows.feed_id
+GROUP BY feed_follows.user_id, feed_follows.feed_id;
+
+CREATE UNIQUE INDEX unread_counts_user_id_feed_id_idx ON unread_counts (user_id, feed_id);
+
+DROP TABLE post_reads;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- For 'search' (and 'related'), which match queries against each
+-- post's title and description. Indexing the expression, rather than
+-- storing a tsvector column, keeps the posts table (and every query
+-- selecting posts.*) unchanged; queries must use the same expression
+-- for the index to apply.
+CREATE INDEX posts_search_idx ON posts USING GIN (to_tsvector('english', title || ' ' || description));
+
+-- +goose Down
+DROP INDEX posts_search_idx;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The validators the feed's server last sent (its ETag and
+-- Last-Modified headers), so that 'agg' can ask for the feed only if
+-- it has changed since.
+ALTER TABLE feeds
+ADD COLUMN etag TEXT,
+ADD COLUMN last_modified TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN etag,
+DROP COLUMN last_modified;
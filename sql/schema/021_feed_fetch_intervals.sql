@@ -0,0 +1,7 @@
+-- +goose Up
+-- How often 'agg' fetches the feed, or NULL for the interval 'agg'
+-- was started with.
+ALTER TABLE feed_settings ADD COLUMN fetch_interval_seconds INTEGER CHECK (fetch_interval_seconds > 0);
+
+-- +goose Down
+ALTER TABLE feed_settings DROP COLUMN fetch_interval_seconds;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- How many times in a row fetching the feed has failed; 'agg' backs
+-- off accordingly, and gives up on the feed altogether after enough.
+ALTER TABLE feeds
+ADD COLUMN failure_count INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN failure_count;
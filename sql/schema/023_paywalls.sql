@@ -0,0 +1,11 @@
+-- +goose Up
+-- Whether the post's content looked to be behind a paywall when it
+-- was saved.
+ALTER TABLE posts ADD COLUMN paywalled BOOLEAN NOT NULL DEFAULT false;
+
+-- Whether the user has flagged the whole feed as paywalled.
+ALTER TABLE feed_settings ADD COLUMN paywalled BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE feed_settings DROP COLUMN paywalled;
+ALTER TABLE posts DROP COLUMN paywalled;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Each user's own folders (such as "news" or "security") for
+-- organizing the feeds they follow.
+CREATE TABLE folders(
+       id UUID PRIMARY KEY,
+       created_at TIMESTAMP NOT NULL,
+       updated_at TIMESTAMP NOT NULL,
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       name TEXT NOT NULL,
+       UNIQUE (user_id, name)
+);
+
+-- The folder a followed feed is filed under, if any.
+ALTER TABLE feed_follows ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+CREATE INDEX feed_follows_folder_id_idx ON feed_follows (folder_id);
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN folder_id;
+DROP TABLE folders;
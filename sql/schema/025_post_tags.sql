@@ -0,0 +1,16 @@
+-- +goose Up
+-- Each user's own tags on posts (such as "toread" or "reference"),
+-- for building reading lists. As with post_reads, posts aren't
+-- referenced by foreign key, since they may be partitioned.
+CREATE TABLE post_tags(
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       post_id UUID NOT NULL,
+       tag TEXT NOT NULL,
+       created_at TIMESTAMP NOT NULL,
+       PRIMARY KEY (user_id, post_id, tag)
+);
+
+CREATE INDEX post_tags_user_id_tag_idx ON post_tags (user_id, tag);
+
+-- +goose Down
+DROP TABLE post_tags;
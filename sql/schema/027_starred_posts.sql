@@ -0,0 +1,21 @@
+-- +goose Up
+-- Posts each user has starred to keep. A copy of each post is kept
+-- along with the star, so that it outlives the post itself being
+-- pruned (or its feed being unfollowed or deleted). Stars are keyed
+-- by URL, like archives, and 'post_id' is NULL for posts starred
+-- elsewhere (and imported) but not yet fetched here.
+CREATE TABLE starred_posts(
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       post_id UUID,
+       url TEXT NOT NULL,
+       title TEXT NOT NULL,
+       description TEXT NOT NULL,
+       author TEXT NOT NULL DEFAULT '',
+       feed_name TEXT NOT NULL,
+       published_at TIMESTAMP NOT NULL,
+       starred_at TIMESTAMP NOT NULL,
+       PRIMARY KEY (user_id, url)
+);
+
+-- +goose Down
+DROP TABLE starred_posts;
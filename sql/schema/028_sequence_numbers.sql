@@ -0,0 +1,29 @@
+-- +goose Up
+-- Numbers for feeds, folders, and posts, for the APIs (such as
+-- Fever's) which identify things by integer rather than by UUID.
+-- They go up as rows are added, so that clients can ask for whatever
+-- came after the last number they saw.
+ALTER TABLE feeds ADD COLUMN seq BIGSERIAL UNIQUE;
+ALTER TABLE folders ADD COLUMN seq BIGSERIAL UNIQUE;
+
+-- A unique constraint on a partitioned table has to include the
+-- partition key, so if 'partition enable' has already been run,
+-- posts get a plain index instead, as 'partition enable' gives them.
+-- The sequence keeps the numbers unique either way.
+-- +goose StatementBegin
+DO $$
+BEGIN
+       IF EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'posts'::regclass) THEN
+              ALTER TABLE posts ADD COLUMN seq BIGSERIAL;
+              CREATE INDEX posts_seq_idx ON posts (seq);
+       ELSE
+              ALTER TABLE posts ADD COLUMN seq BIGSERIAL UNIQUE;
+       END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN seq;
+ALTER TABLE folders DROP COLUMN seq;
+ALTER TABLE feeds DROP COLUMN seq;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- What copies of the same article in several feeds have in common (a
+-- globally unique ID, or else the article's link with tracking
+-- parameters and the like stripped), so that 'browse' can show just
+-- the earliest of them. Posts saved before this have none, and are
+-- never taken for copies.
+ALTER TABLE posts ADD COLUMN dedupe_key TEXT NOT NULL DEFAULT '';
+CREATE INDEX posts_dedupe_key_idx ON posts (dedupe_key);
+
+-- +goose Down
+DROP INDEX posts_dedupe_key_idx;
+ALTER TABLE posts DROP COLUMN dedupe_key;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Where a post's article really lives, as its page says with <link
+-- rel="canonical">, when that isn't where the feed links to (as with
+-- syndicated copies). Empty when unknown, or the same as 'url'.
+ALTER TABLE posts ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN canonical_url;
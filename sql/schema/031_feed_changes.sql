@@ -0,0 +1,26 @@
+-- +goose Up
+-- What each feed last said about itself, and when that changed, so
+-- that 'changes' can show feeds being renamed or moved (which is
+-- often how a feed quietly dies.)
+CREATE TABLE feed_channels(
+       feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+       title TEXT NOT NULL,
+       description TEXT NOT NULL,
+       self_url TEXT NOT NULL,
+       updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE feed_changes(
+       id UUID PRIMARY KEY,
+       feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+       changed_at TIMESTAMP NOT NULL,
+       field TEXT NOT NULL,
+       old_value TEXT NOT NULL,
+       new_value TEXT NOT NULL
+);
+
+CREATE INDEX feed_changes_feed_id_changed_at_idx ON feed_changes (feed_id, changed_at DESC);
+
+-- +goose Down
+DROP TABLE feed_changes;
+DROP TABLE feed_channels;
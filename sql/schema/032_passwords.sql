@@ -0,0 +1,17 @@
+-- +goose Up
+-- Users registered with a password have its hash here (see the
+-- 'password' package); the rest have none, and need none to log in.
+ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+
+-- A session is started by logging in as a user with a password. Only
+-- a hash of its token is kept, the token itself living in the config
+-- file.
+CREATE TABLE sessions(
+       token_hash TEXT PRIMARY KEY,
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE sessions;
+ALTER TABLE users DROP COLUMN password_hash;
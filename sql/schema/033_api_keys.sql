@@ -0,0 +1,15 @@
+-- +goose Up
+-- Keys for 'serve --api', each acting as the user who created it. As
+-- with sessions, only a hash of each key is kept.
+CREATE TABLE api_keys(
+       id UUID PRIMARY KEY,
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       name TEXT NOT NULL,
+       key_hash TEXT NOT NULL UNIQUE,
+       created_at TIMESTAMP NOT NULL,
+       last_used_at TIMESTAMP,
+       UNIQUE (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE api_keys;
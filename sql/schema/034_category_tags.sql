@@ -0,0 +1,7 @@
+-- +goose Up
+-- Whether the categories a feed gives its items are turned into tags
+-- for its followers, as they are unless the feed is opted out.
+ALTER TABLE feed_settings ADD COLUMN category_tags BOOLEAN NOT NULL DEFAULT true;
+
+-- +goose Down
+ALTER TABLE feed_settings DROP COLUMN category_tags;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Which posts have gone out in each user's digests, so that the next
+-- digest needn't repeat them. As with post_reads, posts aren't
+-- referenced by foreign key, since they may be partitioned.
+CREATE TABLE digested_posts(
+       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+       post_id UUID NOT NULL,
+       digested_at TIMESTAMP NOT NULL,
+       PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE digested_posts;
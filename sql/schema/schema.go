@@ -0,0 +1,11 @@
+package schema
+
+import "embed"
+
+/*
+  - The schema migrations, in goose's format, built into the binary so
+    that gator can bring its database up to date by itself (see the
+    'migrate' package.)
+*/
+//go:embed *.sql
+var Migrations embed.FS